@@ -386,6 +386,7 @@ func runBackupProcessor(
 						CreateTime:               timeutil.Now().UnixNano(),
 						Source:                   roachpb.AdmissionHeader_FROM_SQL,
 						NoMemoryReservedAtSource: true,
+						WorkClass:                roachpb.AdmissionHeader_BACKUP,
 					}
 					log.Infof(ctx, "sending ExportRequest for span %s (attempt %d, priority %s)",
 						span.span, span.attempts+1, header.UserPriority.String())
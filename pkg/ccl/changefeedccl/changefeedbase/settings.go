@@ -214,3 +214,14 @@ var ActiveProtectedTimestampsEnabled = settings.RegisterBoolSetting(
 	"if set, rather than only protecting changefeed targets from garbage collection during backfills, data will always be protected up to the changefeed's frontier",
 	true,
 )
+
+// BackfillAdmissionControlEnabled subjects the ScanRequests issued by
+// changefeed initial and schema change backfills to admission control, as
+// background work that yields to foreground traffic.
+var BackfillAdmissionControlEnabled = settings.RegisterBoolSetting(
+	settings.TenantWritable,
+	"changefeed.backfill.admission_control.enabled",
+	"if set, the scans issued by changefeed backfills are subject to admission control "+
+		"as background work; otherwise they bypass admission control",
+	false,
+)
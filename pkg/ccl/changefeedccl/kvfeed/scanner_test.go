@@ -13,6 +13,7 @@ import (
 	"testing"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
 	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/kvevent"
 	"github.com/cockroachdb/cockroach/pkg/gossip"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
@@ -20,10 +21,13 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/desctestutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/admission/admissionpb"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -96,3 +100,65 @@ INSERT INTO t VALUES (1), (2), (3);
 	require.Equal(t, span, sink.resolved[2].Span)
 	require.Equal(t, exportTime, sink.resolved[2].Timestamp)
 }
+
+// TestScanAdmissionHeader verifies that backfill scans are sent as background
+// work subject to admission control iff
+// changefeed.backfill.admission_control.enabled is set.
+func TestScanAdmissionHeader(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	s, db, kvdb := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	sqlDB := sqlutils.MakeSQLRunner(db)
+	sqlDB.Exec(t, `
+CREATE TABLE t (a INT PRIMARY KEY);
+INSERT INTO t VALUES (1), (2), (3);
+`)
+	descr := desctestutils.TestingGetPublicTableDescriptor(kvdb, keys.SystemSQLCodec, "defaultdb", "t")
+	span := tableSpan(uint32(descr.GetID()))
+
+	scanner := &scanRequestScanner{
+		settings: s.ClusterSettings(),
+		gossip:   gossip.MakeOptionalGossip(s.GossipI().(*gossip.Gossip)),
+		db:       kvdb,
+	}
+	testutils.RunTrueAndFalse(t, "admission-control", func(t *testing.T, enabled bool) {
+		changefeedbase.BackfillAdmissionControlEnabled.Override(ctx, &s.ClusterSettings().SV, enabled)
+		var mu struct {
+			syncutil.Mutex
+			headers []roachpb.AdmissionHeader
+		}
+		cfg := scanConfig{
+			Spans:     []roachpb.Span{span},
+			Timestamp: kvdb.Clock().Now(),
+			Knobs: TestingKnobs{
+				BeforeScanRequest: func(b *kv.Batch) error {
+					mu.Lock()
+					defer mu.Unlock()
+					mu.headers = append(mu.headers, b.AdmissionHeader)
+					return nil
+				},
+			},
+		}
+		require.NoError(t, scanner.Scan(ctx, &recordResolvedWriter{}, cfg))
+
+		mu.Lock()
+		defer mu.Unlock()
+		require.NotEmpty(t, mu.headers)
+		for _, h := range mu.headers {
+			if enabled {
+				require.Equal(t, roachpb.AdmissionHeader_FROM_SQL, h.Source)
+				require.Equal(t, roachpb.AdmissionHeader_BACKGROUND, h.WorkClass)
+				require.Equal(t, int32(admissionpb.BulkNormalPri), h.Priority)
+				require.NotZero(t, h.CreateTime)
+			} else {
+				// The scans bypass admission control.
+				require.Equal(t, roachpb.AdmissionHeader_OTHER, h.Source)
+				require.Equal(t, roachpb.AdmissionHeader_DEFAULT, h.WorkClass)
+			}
+		}
+	})
+}
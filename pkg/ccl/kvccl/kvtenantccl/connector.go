@@ -485,6 +485,34 @@ func (c *Connector) TokenBucket(
 	return nil, ctx.Err()
 }
 
+// TenantAdmissionStats implements the kvtenant.TenantAdmissionStatsProvider
+// interface. The statistics are those of the KV node that the Connector is
+// connected to.
+func (c *Connector) TenantAdmissionStats(
+	ctx context.Context, in *roachpb.TenantAdmissionStatsRequest,
+) (*roachpb.TenantAdmissionStatsResponse, error) {
+	ctx = c.AnnotateCtx(ctx)
+	for ctx.Err() == nil {
+		client, err := c.getClient(ctx)
+		if err != nil {
+			continue
+		}
+		resp, err := client.TenantAdmissionStats(ctx, in)
+		if err != nil {
+			log.Warningf(ctx, "error issuing TenantAdmissionStats RPC: %v", err)
+			if grpcutil.IsAuthError(err) {
+				// Authentication or authorization error. Propagate.
+				return nil, err
+			}
+			// Soft RPC error. Drop client and retry.
+			c.tryForgetClient(ctx, client)
+			continue
+		}
+		return resp, nil
+	}
+	return nil, ctx.Err()
+}
+
 // GetSpanConfigRecords implements the spanconfig.KVAccessor interface.
 func (c *Connector) GetSpanConfigRecords(
 	ctx context.Context, targets []spanconfig.Target,
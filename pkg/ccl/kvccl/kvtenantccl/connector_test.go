@@ -102,6 +102,12 @@ func (*mockServer) TokenBucket(
 	panic("unimplemented")
 }
 
+func (*mockServer) TenantAdmissionStats(
+	context.Context, *roachpb.TenantAdmissionStatsRequest,
+) (*roachpb.TenantAdmissionStatsResponse, error) {
+	panic("unimplemented")
+}
+
 func (m *mockServer) GetSpanConfigs(
 	context.Context, *roachpb.GetSpanConfigsRequest,
 ) (*roachpb.GetSpanConfigsResponse, error) {
@@ -128,6 +128,14 @@ const (
 	// StoreTTL is time-to-live for store-related info.
 	StoreTTL = 2 * StoresInterval
 
+	// NodeCPUOverloadInterval is the interval for gossiping the CPU overload
+	// score of a node. It is short, since the score is used to route follower
+	// reads away from nodes whose CPU is saturated.
+	NodeCPUOverloadInterval = 5 * time.Second
+
+	// NodeCPUOverloadTTL is time-to-live for the CPU overload score of a node.
+	NodeCPUOverloadTTL = 3 * NodeCPUOverloadInterval
+
 	unknownNodeID roachpb.NodeID = 0
 )
 
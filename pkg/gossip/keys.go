@@ -11,6 +11,8 @@
 package gossip
 
 import (
+	"encoding/binary"
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
@@ -52,6 +54,12 @@ const (
 	// info.
 	KeyNodeLivenessPrefix = "liveness"
 
+	// KeyNodeCPUOverloadPrefix is the key prefix for gossiping the CPU
+	// overload score of a node, see admission.CPUOverloadScore. The value is
+	// the score as a little-endian-encoded float64, see
+	// EncodeNodeCPUOverloadScore.
+	KeyNodeCPUOverloadPrefix = "cpu-overload"
+
 	// KeySentinel is a key for gossip which must not expire or
 	// else the node considers itself partitioned and will retry with
 	// bootstrap hosts.  The sentinel is gossiped by the node that holds
@@ -160,6 +168,29 @@ func MakeNodeLivenessKey(nodeID roachpb.NodeID) string {
 	return MakeKey(KeyNodeLivenessPrefix, nodeID.String())
 }
 
+// MakeNodeCPUOverloadKey returns the gossip key for the CPU overload score of
+// the given node.
+func MakeNodeCPUOverloadKey(nodeID roachpb.NodeID) string {
+	return MakeKey(KeyNodeCPUOverloadPrefix, nodeID.String())
+}
+
+// EncodeNodeCPUOverloadScore encodes the CPU overload score of a node for
+// gossiping under MakeNodeCPUOverloadKey.
+func EncodeNodeCPUOverloadScore(score float64) []byte {
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, math.Float64bits(score))
+	return buf
+}
+
+// DecodeNodeCPUOverloadScore decodes a CPU overload score encoded by
+// EncodeNodeCPUOverloadScore.
+func DecodeNodeCPUOverloadScore(buf []byte) (float64, error) {
+	if len(buf) != 8 {
+		return 0, errors.Errorf("CPU overload score has %d bytes, expected 8", len(buf))
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(buf)), nil
+}
+
 // MakeStoreKey returns the gossip key for the given store.
 func MakeStoreKey(storeID roachpb.StoreID) string {
 	return MakeKey(KeyStorePrefix, storeID.String())
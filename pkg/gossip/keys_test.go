@@ -88,3 +88,27 @@ func TestStoreIDFromKey(t *testing.T) {
 		})
 	}
 }
+
+func TestNodeCPUOverloadScoreEncoding(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	for _, score := range []float64{0, 0.5, 1, 12.25} {
+		decoded, err := DecodeNodeCPUOverloadScore(EncodeNodeCPUOverloadScore(score))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded != score {
+			t.Errorf("expected %f, got %f", score, decoded)
+		}
+	}
+	if _, err := DecodeNodeCPUOverloadScore([]byte("foo")); err == nil {
+		t.Error("expected failure to decode a malformed score")
+	}
+	nodeID, err := NodeIDFromKey(MakeNodeCPUOverloadKey(7), KeyNodeCPUOverloadPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nodeID != 7 {
+		t.Errorf("expected NodeID=7, got %d", nodeID)
+	}
+}
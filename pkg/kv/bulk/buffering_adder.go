@@ -101,6 +101,7 @@ func MakeBulkAdder(
 			writeAtBatchTS:         opts.WriteAtBatchTimestamp,
 			mem:                    bulkMon.MakeBoundAccount(),
 			limiter:                sendLimiter,
+			workClass:              opts.WorkClass,
 		},
 		timestamp:      timestamp,
 		maxBufferLimit: opts.MaxBufferSize,
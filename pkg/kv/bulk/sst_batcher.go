@@ -127,6 +127,10 @@ type SSTBatcher struct {
 	// writeAtBatchTS is passed to the writeAtBatchTs argument to db.AddSStable.
 	writeAtBatchTS bool
 
+	// workClass is the admission control work class of the AddSSTable
+	// requests.
+	workClass roachpb.AdmissionHeader_WorkClass
+
 	initialSplitDone bool
 
 	// The rest of the fields accumulated state as opposed to configuration. Some,
@@ -663,6 +667,7 @@ func (b *SSTBatcher) addSSTable(
 						CreateTime:               timeutil.Now().UnixNano(),
 						Source:                   roachpb.AdmissionHeader_FROM_SQL,
 						NoMemoryReservedAtSource: true,
+						WorkClass:                b.workClass,
 					},
 				}
 				ba.Add(req)
@@ -206,6 +206,18 @@ var senderConcurrencyLimit = settings.RegisterIntSetting(
 	settings.NonNegativeInt,
 )
 
+// followerReadCPUOverloadThreshold is the CPU overload score at or above which
+// a node's replicas are tried last by requests that can be served by any
+// replica.
+var followerReadCPUOverloadThreshold = settings.RegisterFloatSetting(
+	settings.TenantWritable,
+	"kv.dist_sender.follower_reads.cpu_overload_threshold",
+	"the gossiped CPU overload score at or above which replicas on a node are "+
+		"deprioritized when routing follower reads; 0 disables deprioritization",
+	1,
+	settings.NonNegativeFloat,
+)
+
 func max(a, b int64) int64 {
 	if a > b {
 		return a
@@ -335,6 +347,10 @@ type DistSender struct {
 	// LatencyFunc is used to estimate the latency to other nodes.
 	latencyFunc LatencyFunc
 
+	// cpuOverloadFunc, if set, is used to deprioritize replicas on nodes with a
+	// saturated CPU when routing requests with the NEAREST routing policy.
+	cpuOverloadFunc CPUOverloadFunc
+
 	// If set, the DistSender will try the replicas in the order they appear in
 	// the descriptor, instead of trying to reorder them by latency. The knob
 	// only applies to requests sent with the LEASEHOLDER routing policy.
@@ -390,6 +406,11 @@ type DistSenderConfig struct {
 	// can potentially throttle requests.
 	KVInterceptor multitenant.TenantSideKVInterceptor
 
+	// CPUOverloadFunc, if set, provides the CPU overload scores gossiped by KV
+	// nodes. It is used to route follower reads away from nodes whose CPU is
+	// saturated, see followerReadCPUOverloadThreshold.
+	CPUOverloadFunc CPUOverloadFunc
+
 	TestingKnobs ClientTestingKnobs
 }
 
@@ -476,6 +497,7 @@ func NewDistSender(cfg DistSenderConfig) *DistSender {
 	} else {
 		ds.latencyFunc = ds.rpcContext.RemoteClocks.Latency
 	}
+	ds.cpuOverloadFunc = cfg.CPUOverloadFunc
 	return ds
 }
 
@@ -1972,7 +1994,14 @@ func (ds *DistSender) sendToReplicas(
 	case roachpb.RoutingPolicy_NEAREST:
 		// Order by latency.
 		log.VEvent(ctx, 2, "routing to nearest replica; leaseholder not required")
-		replicas.OptimizeReplicaOrder(ds.getNodeDescriptor(), ds.latencyFunc)
+		nodeDesc := ds.getNodeDescriptor()
+		replicas.OptimizeReplicaOrder(nodeDesc, ds.latencyFunc)
+		// Then try replicas on nodes with a saturated CPU last.
+		if ds.cpuOverloadFunc != nil {
+			if threshold := followerReadCPUOverloadThreshold.Get(&ds.st.SV); threshold > 0 {
+				replicas.DeprioritizeCPUOverloaded(nodeDesc, ds.cpuOverloadFunc, threshold)
+			}
+		}
 
 	default:
 		log.Fatalf(ctx, "unknown routing policy: %s", ba.RoutingPolicy)
@@ -2170,6 +2199,11 @@ func (ds *DistSender) sendToReplicas(
 			case *roachpb.StoreNotFoundError, *roachpb.NodeUnavailableError:
 				// These errors are likely to be unique to the replica that reported
 				// them, so no action is required before the next retry.
+			case *roachpb.AdmissionOverloadedError:
+				// The node that reported this error shed the request without
+				// evaluating it because its admission queues are overloaded. Try
+				// the next replica; once all replicas have been tried, the request
+				// is retried with backoff.
 			case *roachpb.RangeNotFoundError:
 				// The store we routed to doesn't have this replica. This can happen when
 				// our descriptor is outright outdated, but it can also be caused by a
@@ -2262,6 +2262,85 @@ func TestSendRPCRangeNotFoundError(t *testing.T) {
 	require.Equal(t, leaseholderStoreID, rng.Lease().Replica.StoreID)
 }
 
+// TestSendRPCAdmissionOverloadedError verifies that a request shed by the
+// admission control of a replica's node is retried on the other replicas, and
+// then retried again once all replicas have shed it.
+func TestSendRPCAdmissionOverloadedError(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+
+	clock := hlc.NewClockWithSystemTimeSource(time.Nanosecond /* maxOffset */)
+	rpcContext := rpc.NewInsecureTestingContext(ctx, clock, stopper)
+	g := makeGossip(t, stopper, rpcContext)
+	if err := g.SetNodeDescriptor(newNodeDesc(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Fill RangeDescriptor with three replicas.
+	var descriptor = roachpb.RangeDescriptor{
+		RangeID:       1,
+		StartKey:      roachpb.RKey("a"),
+		EndKey:        roachpb.RKey("z"),
+		NextReplicaID: 1,
+	}
+	for i := 1; i <= 3; i++ {
+		addr := util.MakeUnresolvedAddr("tcp", fmt.Sprintf("node%d", i))
+		nd := &roachpb.NodeDescriptor{
+			NodeID:  roachpb.NodeID(i),
+			Address: util.MakeUnresolvedAddr(addr.Network(), addr.String()),
+		}
+		if err := g.AddInfoProto(gossip.MakeNodeIDKey(roachpb.NodeID(i)), nd, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+
+		descriptor.AddReplica(roachpb.NodeID(i), roachpb.StoreID(i), roachpb.VOTER_FULL)
+	}
+	descDB := mockRangeDescriptorDBForDescs(
+		TestMetaRangeDescriptor,
+		descriptor,
+	)
+
+	// Every replica sheds the first request it receives.
+	shed := map[roachpb.ReplicaID]struct{}{}
+	var attempts int
+	var testFn simpleSendFn = func(ctx context.Context, ba roachpb.BatchRequest) (*roachpb.BatchResponse, error) {
+		attempts++
+		br := ba.CreateReply()
+		if _, ok := shed[ba.Replica.ReplicaID]; !ok {
+			shed[ba.Replica.ReplicaID] = struct{}{}
+			br.Error = roachpb.NewError(roachpb.NewAdmissionOverloadedError(
+				"KV admission queue length 10 reached the maximum of 10"))
+		}
+		return br, nil
+	}
+	retryOpts := base.DefaultRetryOptions()
+	retryOpts.InitialBackoff = time.Millisecond
+	cfg := DistSenderConfig{
+		AmbientCtx:      log.MakeTestingAmbientCtxWithNewTracer(),
+		Clock:           clock,
+		NodeDescs:       g,
+		RPCContext:      rpcContext,
+		RPCRetryOptions: &retryOpts,
+		TestingKnobs: ClientTestingKnobs{
+			TransportFactory: adaptSimpleTransport(testFn),
+		},
+		RangeDescriptorDB: descDB,
+		Settings:          cluster.MakeTestingClusterSettings(),
+	}
+	ds := NewDistSender(cfg)
+	get := roachpb.NewGet(roachpb.Key("b"), false /* forUpdate */)
+	_, pErr := kv.SendWrapped(ctx, ds, get)
+	require.Nil(t, pErr)
+	require.Len(t, shed, 3)
+	require.Equal(t, 4, attempts)
+	require.Equal(t, int64(3),
+		ds.metrics.ErrCounts[roachpb.AdmissionOverloadedErrType].Count())
+}
+
 // TestGetNodeDescriptor checks that the Node descriptor automatically gets
 // looked up from Gossip.
 func TestGetNodeDescriptor(t *testing.T) {
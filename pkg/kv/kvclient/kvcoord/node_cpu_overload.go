@@ -0,0 +1,92 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvcoord
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/gossip"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// NodeCPUOverloadScores tracks the CPU overload scores that KV nodes gossip
+// under gossip.KeyNodeCPUOverloadPrefix. The scores are computed by admission
+// control on each node (see admission.CPUOverloadScore), where a score of 1 or
+// more indicates that the node's CPU is saturated.
+type NodeCPUOverloadScores struct {
+	ambientCtx log.AmbientContext
+	timeSource timeutil.TimeSource
+
+	mu struct {
+		syncutil.RWMutex
+		scores map[roachpb.NodeID]nodeCPUOverloadScore
+	}
+}
+
+type nodeCPUOverloadScore struct {
+	score float64
+	// updated is the time at which the score was last received over gossip.
+	updated time.Time
+}
+
+// NewNodeCPUOverloadScores returns a NodeCPUOverloadScores which is kept up to
+// date through the supplied gossip instance.
+func NewNodeCPUOverloadScores(
+	ambientCtx log.AmbientContext, g *gossip.Gossip, timeSource timeutil.TimeSource,
+) *NodeCPUOverloadScores {
+	s := &NodeCPUOverloadScores{ambientCtx: ambientCtx, timeSource: timeSource}
+	s.mu.scores = make(map[roachpb.NodeID]nodeCPUOverloadScore)
+	// Enable redundant callbacks since nodes regossip the same score while
+	// their load is steady, and we use the callbacks to determine whether a
+	// score is recent.
+	g.RegisterCallback(
+		gossip.MakePrefixPattern(gossip.KeyNodeCPUOverloadPrefix), s.gossipUpdate, gossip.Redundant,
+	)
+	return s
+}
+
+func (s *NodeCPUOverloadScores) gossipUpdate(key string, content roachpb.Value) {
+	nodeID, err := gossip.NodeIDFromKey(key, gossip.KeyNodeCPUOverloadPrefix)
+	if err != nil {
+		log.Errorf(s.ambientCtx.AnnotateCtx(context.TODO()), "%v", err)
+		return
+	}
+	buf, err := content.GetBytes()
+	if err != nil {
+		log.Errorf(s.ambientCtx.AnnotateCtx(context.TODO()), "n%d: %v", nodeID, err)
+		return
+	}
+	score, err := gossip.DecodeNodeCPUOverloadScore(buf)
+	if err != nil {
+		log.Errorf(s.ambientCtx.AnnotateCtx(context.TODO()), "n%d: %v", nodeID, err)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mu.scores[nodeID] = nodeCPUOverloadScore{score: score, updated: s.timeSource.Now()}
+}
+
+// Score returns the most recently gossiped CPU overload score of the given
+// node. The boolean is false if the node has not gossiped a score within
+// gossip.NodeCPUOverloadTTL. Score implements CPUOverloadFunc.
+func (s *NodeCPUOverloadScores) Score(nodeID roachpb.NodeID) (float64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	score, ok := s.mu.scores[nodeID]
+	if !ok || s.timeSource.Since(score.updated) > gossip.NodeCPUOverloadTTL {
+		return 0, false
+	}
+	return score.score, true
+}
@@ -0,0 +1,60 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvcoord
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/gossip"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeCPUOverloadScores(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	manual := timeutil.NewManualTime(timeutil.Unix(0, 0))
+	s := &NodeCPUOverloadScores{
+		ambientCtx: log.MakeTestingAmbientCtxWithNewTracer(),
+		timeSource: manual,
+	}
+	s.mu.scores = make(map[roachpb.NodeID]nodeCPUOverloadScore)
+	update := func(nodeID roachpb.NodeID, buf []byte) {
+		s.gossipUpdate(gossip.MakeNodeCPUOverloadKey(nodeID), roachpb.MakeValueFromBytes(buf))
+	}
+
+	update(1, gossip.EncodeNodeCPUOverloadScore(1.5))
+	score, ok := s.Score(1)
+	require.True(t, ok)
+	require.Equal(t, 1.5, score)
+	_, ok = s.Score(2)
+	require.False(t, ok)
+
+	// A malformed score is ignored.
+	update(1, []byte("foo"))
+	score, ok = s.Score(1)
+	require.True(t, ok)
+	require.Equal(t, 1.5, score)
+
+	// Scores which have not been regossiped within the TTL are not used.
+	manual.Advance(gossip.NodeCPUOverloadTTL + time.Second)
+	_, ok = s.Score(1)
+	require.False(t, ok)
+	update(1, gossip.EncodeNodeCPUOverloadScore(0.25))
+	score, ok = s.Score(1)
+	require.True(t, ok)
+	require.Equal(t, 0.25, score)
+}
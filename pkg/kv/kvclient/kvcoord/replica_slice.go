@@ -236,6 +236,36 @@ func (rs ReplicaSlice) OptimizeReplicaOrder(
 	})
 }
 
+// A CPUOverloadFunc returns the CPU overload score of a node and a bool
+// indicating whether the score is known. A score of 1 or more indicates that
+// the node's CPU is saturated, see admission.CPUOverloadScore.
+type CPUOverloadFunc func(roachpb.NodeID) (float64, bool)
+
+// DeprioritizeCPUOverloaded moves the replicas on nodes whose CPU overload
+// score is at or above the given threshold to the back of the slice, keeping
+// the relative order of the remaining replicas (and of the moved replicas)
+// stable. Replicas on the current node, described by nodeDesc, are never
+// moved, since serving a request locally avoids a network hop regardless of
+// the local CPU load. Replicas whose node has no known score are not moved
+// either.
+func (rs ReplicaSlice) DeprioritizeCPUOverloaded(
+	nodeDesc *roachpb.NodeDescriptor, cpuOverloadFn CPUOverloadFunc, threshold float64,
+) {
+	var overloaded ReplicaSlice
+	n := 0
+	for _, r := range rs {
+		if nodeDesc == nil || r.NodeID != nodeDesc.NodeID {
+			if score, ok := cpuOverloadFn(r.NodeID); ok && score >= threshold {
+				overloaded = append(overloaded, r)
+				continue
+			}
+		}
+		rs[n] = r
+		n++
+	}
+	copy(rs[n:], overloaded)
+}
+
 // Descriptors returns the ReplicaDescriptors inside the ReplicaSlice.
 func (rs ReplicaSlice) Descriptors() []roachpb.ReplicaDescriptor {
 	reps := make([]roachpb.ReplicaDescriptor, len(rs))
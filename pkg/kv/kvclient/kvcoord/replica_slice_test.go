@@ -263,3 +263,40 @@ func TestReplicaSliceOptimizeReplicaOrder(t *testing.T) {
 		})
 	}
 }
+
+func TestReplicaSliceDeprioritizeCPUOverloaded(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	scores := map[roachpb.NodeID]float64{1: 2, 2: 0.5, 3: 1, 4: 1.5}
+	cpuOverloadFn := func(nodeID roachpb.NodeID) (float64, bool) {
+		score, ok := scores[nodeID]
+		return score, ok
+	}
+	for _, tc := range []struct {
+		name      string
+		node      *roachpb.NodeDescriptor
+		threshold float64
+		exp       []roachpb.StoreID
+	}{
+		// Nodes 1, 3 and 4 are overloaded and move to the back in order; node 5
+		// has no score.
+		{name: "remote", node: nil, threshold: 1, exp: []roachpb.StoreID{2, 5, 1, 3, 4}},
+		// The local node is never deprioritized.
+		{name: "local", node: &roachpb.NodeDescriptor{NodeID: 1}, threshold: 1,
+			exp: []roachpb.StoreID{1, 2, 5, 3, 4}},
+		{name: "high threshold", node: nil, threshold: 1.5, exp: []roachpb.StoreID{2, 3, 5, 1, 4}},
+		{name: "none overloaded", node: nil, threshold: 3, exp: []roachpb.StoreID{1, 2, 3, 4, 5}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var rs ReplicaSlice
+			for i := 1; i <= 5; i++ {
+				rs = append(rs, ReplicaInfo{
+					ReplicaDescriptor: desc(roachpb.NodeID(i), roachpb.StoreID(i)),
+				})
+			}
+			rs.DeprioritizeCPUOverloaded(tc.node, cpuOverloadFn, tc.threshold)
+			require.Equal(t, tc.exp, getStores(rs))
+		})
+	}
+}
@@ -79,6 +79,12 @@ func (n Node) TokenBucket(
 	panic("unimplemented")
 }
 
+func (n Node) TenantAdmissionStats(
+	context.Context, *roachpb.TenantAdmissionStatsRequest,
+) (*roachpb.TenantAdmissionStatsResponse, error) {
+	panic("unimplemented")
+}
+
 func (n Node) GetSpanConfigs(
 	_ context.Context, _ *roachpb.GetSpanConfigsRequest,
 ) (*roachpb.GetSpanConfigsResponse, error) {
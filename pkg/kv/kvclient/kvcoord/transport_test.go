@@ -198,6 +198,12 @@ func (m *mockInternalClient) TokenBucket(
 	return nil, fmt.Errorf("unsupported TokenBucket call")
 }
 
+func (m *mockInternalClient) TenantAdmissionStats(
+	context.Context, *roachpb.TenantAdmissionStatsRequest, ...grpc.CallOption,
+) (*roachpb.TenantAdmissionStatsResponse, error) {
+	return nil, fmt.Errorf("unsupported TenantAdmissionStats call")
+}
+
 func (m *mockInternalClient) GetSpanConfigs(
 	_ context.Context, _ *roachpb.GetSpanConfigsRequest, _ ...grpc.CallOption,
 ) (*roachpb.GetSpanConfigsResponse, error) {
@@ -70,6 +70,10 @@ type Connector interface {
 	// bucket.
 	TokenBucketProvider
 
+	// TenantAdmissionStatsProvider provides access to the KV admission control
+	// statistics of the tenant.
+	TenantAdmissionStatsProvider
+
 	// KVAccessor provides access to the subset of the cluster's span configs
 	// applicable to secondary tenants.
 	spanconfig.KVAccessor
@@ -93,6 +97,15 @@ type TokenBucketProvider interface {
 	) (*roachpb.TokenBucketResponse, error)
 }
 
+// TenantAdmissionStatsProvider supplies an endpoint (to tenants) for the
+// TenantAdmissionStats API (defined in roachpb.Internal), used to fetch the KV
+// admission control statistics of the tenant's requests on a KV node.
+type TenantAdmissionStatsProvider interface {
+	TenantAdmissionStats(
+		ctx context.Context, in *roachpb.TenantAdmissionStatsRequest,
+	) (*roachpb.TenantAdmissionStatsResponse, error)
+}
+
 // ConnectorConfig encompasses the configuration required to create a Connector.
 type ConnectorConfig struct {
 	TenantID          roachpb.TenantID
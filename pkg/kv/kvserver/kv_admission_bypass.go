@@ -0,0 +1,120 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+)
+
+// kvAdmissionBypassMethods is a comma-separated list of request methods that
+// bypass admission control. It is meant as an escape hatch for operators, to
+// mitigate situations where admission control is throttling work that it
+// should not, without requiring a new binary.
+var kvAdmissionBypassMethods = settings.RegisterValidatedStringSetting(
+	settings.SystemOnly,
+	"admission.kv.bypass_methods",
+	"comma-separated list of KV request methods (e.g. Export,Probe) that bypass admission "+
+		"control; only batches from the system tenant consisting solely of these methods "+
+		"bypass admission",
+	"",
+	func(_ *settings.Values, s string) error {
+		_, err := parseKVAdmissionBypassMethods(s)
+		return err
+	},
+)
+
+// kvAdmissionMethodSet is a set of request methods.
+type kvAdmissionMethodSet [roachpb.NumMethods]bool
+
+// parseKVAdmissionBypassMethods parses the value of the
+// admission.kv.bypass_methods setting. Method names are matched
+// case-insensitively, and may optionally carry a "Request" suffix, so that
+// both "Export" and "ExportRequest" are accepted.
+func parseKVAdmissionBypassMethods(s string) (kvAdmissionMethodSet, error) {
+	var set kvAdmissionMethodSet
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		trimmed := name
+		if len(trimmed) > len("Request") &&
+			strings.EqualFold(trimmed[len(trimmed)-len("Request"):], "Request") {
+			trimmed = trimmed[:len(trimmed)-len("Request")]
+		}
+		found := false
+		for m := roachpb.Method(0); m < roachpb.NumMethods; m++ {
+			if strings.EqualFold(m.String(), trimmed) {
+				set[m] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return kvAdmissionMethodSet{}, errors.Errorf("unknown request method %q", name)
+		}
+	}
+	return set, nil
+}
+
+// kvAdmissionBypassAllowlist caches the parsed value of the
+// admission.kv.bypass_methods setting, so that it is not parsed for every
+// request.
+type kvAdmissionBypassAllowlist struct {
+	// methods is nil when the allowlist is empty.
+	methods atomic.Value // *kvAdmissionMethodSet
+}
+
+func newKVAdmissionBypassAllowlist(st *cluster.Settings) *kvAdmissionBypassAllowlist {
+	a := &kvAdmissionBypassAllowlist{}
+	update := func(ctx context.Context) {
+		set, err := parseKVAdmissionBypassMethods(kvAdmissionBypassMethods.Get(&st.SV))
+		if err != nil {
+			// The setting is validated, so this should not happen.
+			log.Warningf(ctx, "ignoring invalid %s: %v", kvAdmissionBypassMethods.Key(), err)
+			set = kvAdmissionMethodSet{}
+		}
+		var methods *kvAdmissionMethodSet
+		for _, ok := range set {
+			if ok {
+				methods = &set
+				break
+			}
+		}
+		a.methods.Store(methods)
+	}
+	update(context.Background())
+	kvAdmissionBypassMethods.SetOnChange(&st.SV, update)
+	return a
+}
+
+// bypass returns true if all the requests in the batch have a method in the
+// allowlist.
+func (a *kvAdmissionBypassAllowlist) bypass(ba *roachpb.BatchRequest) bool {
+	methods := a.methods.Load().(*kvAdmissionMethodSet)
+	if methods == nil || len(ba.Requests) == 0 {
+		return false
+	}
+	for _, ru := range ba.Requests {
+		if !methods[ru.GetInner().Method()] {
+			return false
+		}
+	}
+	return true
+}
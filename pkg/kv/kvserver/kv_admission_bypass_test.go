@@ -0,0 +1,107 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKVAdmissionBypassAllowlist(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	set, err := parseKVAdmissionBypassMethods(" Export, ProbeRequest,,leaseinfo ")
+	require.NoError(t, err)
+	for m := roachpb.Method(0); m < roachpb.NumMethods; m++ {
+		expected := m == roachpb.Export || m == roachpb.Probe || m == roachpb.LeaseInfo
+		require.Equal(t, expected, set[m], "%s", m)
+	}
+	_, err = parseKVAdmissionBypassMethods("Export,Frobnicate")
+	require.Error(t, err)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	a := newKVAdmissionBypassAllowlist(st)
+	var probe, export roachpb.BatchRequest
+	probe.Add(&roachpb.ProbeRequest{})
+	export.Add(&roachpb.ProbeRequest{}, &roachpb.ExportRequest{})
+	require.False(t, a.bypass(&probe))
+
+	kvAdmissionBypassMethods.Override(ctx, &st.SV, "Probe")
+	require.True(t, a.bypass(&probe))
+	// All requests in the batch must be in the allowlist.
+	require.False(t, a.bypass(&export))
+	require.False(t, a.bypass(&roachpb.BatchRequest{}))
+
+	kvAdmissionBypassMethods.Override(ctx, &st.SV, "Probe,Export")
+	require.True(t, a.bypass(&export))
+}
+
+func TestKVAdmissionTenantSystemTableBypass(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	tenants, err := parseKVAdmissionTenantIDs(" 10,, 11 ")
+	require.NoError(t, err)
+	require.Equal(t, map[roachpb.TenantID]struct{}{
+		roachpb.MakeTenantID(10): {}, roachpb.MakeTenantID(11): {},
+	}, tenants)
+	for _, invalid := range []string{"0", "1", "ten", "10,-1"} {
+		_, err := parseKVAdmissionTenantIDs(invalid)
+		require.Error(t, err, invalid)
+	}
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	b := newKVAdmissionTenantBypass(st)
+	tenantID := roachpb.MakeTenantID(10)
+	codec := keys.MakeSQLCodec(tenantID)
+	var system, user, otherTenant, otherSystem, acrossTables roachpb.BatchRequest
+	system.Add(
+		roachpb.NewGet(codec.TablePrefix(keys.DescriptorTableID), false /* forUpdate */),
+		roachpb.NewScan(codec.TablePrefix(keys.LeaseTableID), codec.TablePrefix(keys.LeaseTableID+1),
+			false /* forUpdate */),
+		roachpb.NewGet(codec.TablePrefix(keys.NamespaceTableID), false /* forUpdate */),
+		roachpb.NewPut(codec.TablePrefix(keys.SqllivenessID), roachpb.MakeValueFromString("v")),
+	)
+	otherSystem.Add(roachpb.NewGet(codec.TablePrefix(keys.JobsTableID), false /* forUpdate */))
+	acrossTables.Add(roachpb.NewScan(codec.TablePrefix(keys.DescriptorTableID),
+		codec.TablePrefix(keys.LeaseTableID+1), false /* forUpdate */))
+	user.Add(
+		roachpb.NewGet(codec.TablePrefix(keys.DescriptorTableID), false /* forUpdate */),
+		roachpb.NewGet(codec.TablePrefix(keys.MaxReservedDescID+1), false /* forUpdate */),
+	)
+	otherTenant.Add(roachpb.NewGet(
+		keys.MakeSQLCodec(roachpb.MakeTenantID(11)).TablePrefix(keys.DescriptorTableID),
+		false /* forUpdate */))
+	require.False(t, b.bypass(tenantID, &system))
+
+	kvAdmissionTenantSystemTableBypass.Override(ctx, &st.SV, "10")
+	require.True(t, b.bypass(tenantID, &system))
+	// All requests in the batch must be to the tenant's system tables.
+	require.False(t, b.bypass(tenantID, &user))
+	require.False(t, b.bypass(tenantID, &otherTenant))
+	// Only the descriptor, namespace, lease and SQL liveness tables qualify,
+	// and each request must stay within one of them.
+	require.False(t, b.bypass(tenantID, &otherSystem))
+	require.False(t, b.bypass(tenantID, &acrossTables))
+	require.False(t, b.bypass(tenantID, &roachpb.BatchRequest{}))
+	// Only the tenants in the setting can bypass admission.
+	require.False(t, b.bypass(roachpb.MakeTenantID(11), &system))
+}
@@ -0,0 +1,365 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/admission"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/datadriven"
+	"github.com/cockroachdb/errors"
+)
+
+// newTestKVAdmissionController returns a KVAdmissionControllerImpl that is
+// backed by new grant coordinators, which the caller must close. Its queues
+// can be replaced with the fakes below.
+func newTestKVAdmissionController(
+	st *cluster.Settings,
+	metrics *KVAdmissionMetrics,
+	timeSource timeutil.TimeSource,
+	knobs *KVAdmissionTestingKnobs,
+) (KVAdmissionControllerImpl, admission.GrantCoordinators) {
+	opts := admission.DefaultOptions
+	opts.Settings = st
+	gcoords, _ := admission.NewGrantCoordinators(log.MakeTestingAmbientCtxWithNewTracer(), opts)
+	ac := MakeKVAdmissionController(gcoords.Regular.GetWorkQueue(admission.KVWork),
+		gcoords.Regular, gcoords.Stores, st, metrics, timeSource, knobs,
+	).(KVAdmissionControllerImpl)
+	return ac, gcoords
+}
+
+func formatWorkInfo(info admission.WorkInfo) string {
+	return fmt.Sprintf("tenant=%d pri=%d bypass=%t",
+		info.TenantID.ToUint64(), info.Priority, info.BypassAdmission)
+}
+
+// fakeKVAdmissionQueue is a kvAdmissionWorkQueue that records its calls.
+type fakeKVAdmissionQueue struct {
+	buf        *strings.Builder
+	fastAdmit  bool
+	admitErr   error
+	numWaiting int
+}
+
+var _ kvAdmissionWorkQueue = &fakeKVAdmissionQueue{}
+
+func (q *fakeKVAdmissionQueue) TryFastAdmit(info admission.WorkInfo) bool {
+	fmt.Fprintf(q.buf, "kv: try-fast-admit %s -> %t\n", formatWorkInfo(info), q.fastAdmit)
+	return q.fastAdmit
+}
+
+func (q *fakeKVAdmissionQueue) Admit(
+	_ context.Context, info admission.WorkInfo,
+) (enabled bool, err error) {
+	if q.admitErr != nil {
+		fmt.Fprintf(q.buf, "kv: admit %s -> %v\n", formatWorkInfo(info), q.admitErr)
+		return false, q.admitErr
+	}
+	fmt.Fprintf(q.buf, "kv: admit %s\n", formatWorkInfo(info))
+	return true, nil
+}
+
+func (q *fakeKVAdmissionQueue) AdmittedWorkDone(tenantID roachpb.TenantID) {
+	fmt.Fprintf(q.buf, "kv: done tenant=%d\n", tenantID.ToUint64())
+}
+
+func (q *fakeKVAdmissionQueue) NumWaiting() int {
+	return q.numWaiting
+}
+
+// fakeStoreAdmissionQueue is a storeAdmissionWorkQueue that records its
+// calls.
+type fakeStoreAdmissionQueue struct {
+	storeID    roachpb.StoreID
+	buf        *strings.Builder
+	admitErr   error
+	numWaiting int
+}
+
+var _ storeAdmissionWorkQueue = &fakeStoreAdmissionQueue{}
+
+func (q *fakeStoreAdmissionQueue) Admit(
+	_ context.Context, info admission.StoreWriteWorkInfo,
+) (admission.StoreWorkHandle, error) {
+	if q.admitErr != nil {
+		fmt.Fprintf(q.buf, "s%d: admit %s -> %v\n", q.storeID, formatWorkInfo(info.WorkInfo), q.admitErr)
+		return admission.StoreWorkHandle{}, q.admitErr
+	}
+	fmt.Fprintf(q.buf, "s%d: admit %s\n", q.storeID, formatWorkInfo(info.WorkInfo))
+	return admission.MakeStoreWorkHandleForTesting(info.TenantID, info.WriteBytes), nil
+}
+
+func (q *fakeStoreAdmissionQueue) AdmittedWorkDone(
+	_ admission.StoreWorkHandle, ingestedIntoL0Bytes int64,
+) error {
+	if ingestedIntoL0Bytes != 0 {
+		fmt.Fprintf(q.buf, "s%d: done l0=%d\n", q.storeID, ingestedIntoL0Bytes)
+		return nil
+	}
+	fmt.Fprintf(q.buf, "s%d: done\n", q.storeID)
+	return nil
+}
+
+func (q *fakeStoreAdmissionQueue) FollowerAppliedBytes(tenantID roachpb.TenantID, bytes int64) {
+	fmt.Fprintf(q.buf, "s%d: follower-applied tenant=%d bytes=%d\n",
+		q.storeID, tenantID.ToUint64(), bytes)
+}
+
+func (q *fakeStoreAdmissionQueue) NumWaiting() int {
+	return q.numWaiting
+}
+
+// fakeStoreAdmissionQueues implements storeAdmissionQueues. Stores that are
+// absent are not subject to store admission.
+type fakeStoreAdmissionQueues map[roachpb.StoreID]*fakeStoreAdmissionQueue
+
+func (qs fakeStoreAdmissionQueues) queueForStore(storeID roachpb.StoreID) storeAdmissionWorkQueue {
+	if q, ok := qs[storeID]; ok {
+		return q
+	}
+	return nil
+}
+
+/*
+TestKVAdmissionControllerDataDriven is a datadriven test of the interactions
+of KVAdmissionControllerImpl with fake admission queues, with the following
+commands:
+init [stores=<int>,...]
+admit id=<int> method=<get|put|heartbeat|liveness-put> [tenant=<int>] [store=<int>] [source=<source>] [work-class=<class>]
+done id=<int>
+rebind id=<int> store=<int>
+set-fast-path enabled=<bool>
+set-fast-admit v=<bool>
+set-admit-error queue=<kv|s<int>> [err=<string>]
+set-num-waiting queue=<kv|s<int>> n=<int>
+set-max-queue-length n=<int>
+inject-store-error op=<admit|done> [err=<string>]
+
+The errors injected by inject-store-error go through the
+KVAdmissionTestingKnobs, rather than the fakes, and apply to all stores.
+*/
+func TestKVAdmissionControllerDataDriven(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	var buf strings.Builder
+	var st *cluster.Settings
+	var ac KVAdmissionControllerImpl
+	var kvQueue *fakeKVAdmissionQueue
+	var storeQueues fakeStoreAdmissionQueues
+	var handles map[int]*KVAdmissionHandle
+	var knobs KVAdmissionTestingKnobs
+	closeFn := func() {}
+	defer func() { closeFn() }()
+	stringAndReset := func() string {
+		str := buf.String()
+		buf.Reset()
+		return str
+	}
+
+	scanQueue := func(t *testing.T, d *datadriven.TestData) (
+		admitErr *error, numWaiting *int,
+	) {
+		var queue string
+		d.ScanArgs(t, "queue", &queue)
+		if queue == "kv" {
+			return &kvQueue.admitErr, &kvQueue.numWaiting
+		}
+		storeID, err := strconv.Atoi(strings.TrimPrefix(queue, "s"))
+		if err != nil {
+			d.Fatalf(t, "unknown queue: %s", queue)
+		}
+		q, ok := storeQueues[roachpb.StoreID(storeID)]
+		if !ok {
+			d.Fatalf(t, "unknown store: %d", storeID)
+		}
+		return &q.admitErr, &q.numWaiting
+	}
+
+	datadriven.RunTest(t, testutils.TestDataPath(t, "kv_admission_controller"),
+		func(t *testing.T, d *datadriven.TestData) string {
+			switch d.Cmd {
+			case "init":
+				closeFn()
+				st = cluster.MakeTestingClusterSettings()
+				var gcoords admission.GrantCoordinators
+				ac, gcoords = newTestKVAdmissionController(st, nil, /* metrics */
+					timeutil.NewManualTime(timeutil.Unix(0, 0)), nil /* knobs */)
+				closeFn = gcoords.Close
+				buf.Reset()
+				kvQueue = &fakeKVAdmissionQueue{buf: &buf}
+				storeQueues = fakeStoreAdmissionQueues{}
+				if d.HasArg("stores") {
+					var stores string
+					d.ScanArgs(t, "stores", &stores)
+					for _, s := range strings.Split(stores, ",") {
+						id, err := strconv.Atoi(s)
+						if err != nil {
+							d.Fatalf(t, "%v", err)
+						}
+						storeID := roachpb.StoreID(id)
+						storeQueues[storeID] = &fakeStoreAdmissionQueue{storeID: storeID, buf: &buf}
+					}
+				}
+				knobs = KVAdmissionTestingKnobs{}
+				ac.kvQueue = kvQueue
+				ac.storeQueues = errorInjectingStoreQueues{storeAdmissionQueues: storeQueues, knobs: &knobs}
+				handles = make(map[int]*KVAdmissionHandle)
+				return ""
+
+			case "admit":
+				var id int
+				d.ScanArgs(t, "id", &id)
+				if _, ok := handles[id]; ok {
+					d.Fatalf(t, "id %d is already used", id)
+				}
+				tenantID := roachpb.SystemTenantID
+				if d.HasArg("tenant") {
+					var tenant int
+					d.ScanArgs(t, "tenant", &tenant)
+					tenantID = roachpb.MakeTenantID(uint64(tenant))
+				}
+				var ba roachpb.BatchRequest
+				if d.HasArg("store") {
+					var storeID int
+					d.ScanArgs(t, "store", &storeID)
+					ba.Replica.StoreID = roachpb.StoreID(storeID)
+				}
+				ba.AdmissionHeader.Source = roachpb.AdmissionHeader_ROOT_KV
+				if d.HasArg("source") {
+					var source string
+					d.ScanArgs(t, "source", &source)
+					ba.AdmissionHeader.Source = roachpb.AdmissionHeader_Source(
+						roachpb.AdmissionHeader_Source_value[source])
+				}
+				if d.HasArg("work-class") {
+					var workClass string
+					d.ScanArgs(t, "work-class", &workClass)
+					ba.AdmissionHeader.WorkClass = roachpb.AdmissionHeader_WorkClass(
+						roachpb.AdmissionHeader_WorkClass_value[workClass])
+				}
+				var method string
+				d.ScanArgs(t, "method", &method)
+				key := roachpb.Key("a")
+				switch method {
+				case "get":
+					ba.Add(roachpb.NewGet(key, false /* forUpdate */))
+				case "put":
+					ba.Add(roachpb.NewPut(key, roachpb.MakeValueFromString("v")))
+				case "heartbeat":
+					ba.Add(&roachpb.HeartbeatTxnRequest{RequestHeader: roachpb.RequestHeader{Key: key}})
+				case "liveness-put":
+					ba.Add(roachpb.NewPut(keys.NodeLivenessKey(1), roachpb.MakeValueFromString("v")))
+				default:
+					d.Fatalf(t, "unknown method: %s", method)
+				}
+				handle, err := ac.AdmitKVWork(ctx, tenantID, &ba)
+				if err != nil {
+					fmt.Fprintf(&buf, "id %d: %v\n", id, err)
+				} else {
+					handles[id] = handle
+					fmt.Fprintf(&buf, "id %d: admitted\n", id)
+				}
+				return stringAndReset()
+
+			case "done":
+				var id int
+				d.ScanArgs(t, "id", &id)
+				handle, ok := handles[id]
+				if !ok {
+					d.Fatalf(t, "unknown id: %d", id)
+				}
+				ac.AdmittedKVWorkDone(handle, nil /* br */)
+				return stringAndReset()
+
+			case "rebind":
+				var id, storeID int
+				d.ScanArgs(t, "id", &id)
+				d.ScanArgs(t, "store", &storeID)
+				handle, ok := handles[id]
+				if !ok {
+					d.Fatalf(t, "unknown id: %d", id)
+				}
+				if err := ac.RebindStoreAdmission(ctx, handle, roachpb.StoreID(storeID)); err != nil {
+					fmt.Fprintf(&buf, "id %d: %v\n", id, err)
+				}
+				return stringAndReset()
+
+			case "set-fast-path":
+				var enabled bool
+				d.ScanArgs(t, "enabled", &enabled)
+				kvAdmissionFastPathEnabled.Override(ctx, &st.SV, enabled)
+				return ""
+
+			case "set-fast-admit":
+				d.ScanArgs(t, "v", &kvQueue.fastAdmit)
+				return ""
+
+			case "set-admit-error":
+				admitErr, _ := scanQueue(t, d)
+				*admitErr = nil
+				if d.HasArg("err") {
+					var msg string
+					d.ScanArgs(t, "err", &msg)
+					*admitErr = errors.New(msg)
+				}
+				return ""
+
+			case "set-num-waiting":
+				_, numWaiting := scanQueue(t, d)
+				d.ScanArgs(t, "n", numWaiting)
+				return ""
+
+			case "set-max-queue-length":
+				var n int
+				d.ScanArgs(t, "n", &n)
+				loadSheddingMaxQueueLengthForeground.Override(ctx, &st.SV, int64(n))
+				return ""
+
+			case "inject-store-error":
+				var op string
+				d.ScanArgs(t, "op", &op)
+				var fn func(roachpb.StoreID) error
+				if d.HasArg("err") {
+					var msg string
+					d.ScanArgs(t, "err", &msg)
+					fn = func(storeID roachpb.StoreID) error {
+						fmt.Fprintf(&buf, "s%d: injected %s error: %s\n", storeID, op, msg)
+						return errors.New(msg)
+					}
+				}
+				switch op {
+				case "admit":
+					knobs.InjectStoreAdmitError = fn
+				case "done":
+					knobs.InjectStoreAdmittedWorkDoneError = fn
+				default:
+					d.Fatalf(t, "unknown op: %s", op)
+				}
+				return ""
+
+			default:
+				return fmt.Sprintf("unknown command: %s", d.Cmd)
+			}
+		})
+}
@@ -0,0 +1,134 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/redact"
+	"golang.org/x/time/rate"
+)
+
+// kvAdmissionDecisionLogEnabled controls whether the outcomes of KV
+// admission are logged. The metrics only provide aggregates, which are often
+// insufficient to understand which requests are queued or rejected during an
+// overload.
+var kvAdmissionDecisionLogEnabled = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"admission.kv.decision_log.enabled",
+	"when true, a sample of the outcomes of KV admission (admitted, bypassed or rejected) "+
+		"is logged to the HEALTH channel",
+	false,
+)
+
+// kvAdmissionDecisionLogMaxRate bounds the rate at which the outcomes are
+// logged, so that the logging does not add to an overload.
+var kvAdmissionDecisionLogMaxRate = settings.RegisterFloatSetting(
+	settings.SystemOnly,
+	"admission.kv.decision_log.max_rate",
+	"the maximum number of KV admission outcomes logged per second, when "+
+		"admission.kv.decision_log.enabled is set",
+	10,
+	settings.PositiveFloat,
+)
+
+// kvAdmissionDecisionLog logs a rate-limited sample of the outcomes of KV
+// admission.
+type kvAdmissionDecisionLog struct {
+	settings   *cluster.Settings
+	timeSource timeutil.TimeSource
+	mu         struct {
+		syncutil.Mutex
+		// maxRate is the admission.kv.decision_log.max_rate that the limiter is
+		// configured with. The limiter is reconfigured when the setting is next
+		// read after a change, rather than from a setting callback, since
+		// callbacks cannot be unregistered, and would retain the log.
+		maxRate float64
+		limiter *rate.Limiter
+	}
+}
+
+func newKVAdmissionDecisionLog(
+	st *cluster.Settings, timeSource timeutil.TimeSource,
+) *kvAdmissionDecisionLog {
+	l := &kvAdmissionDecisionLog{settings: st, timeSource: timeSource}
+	l.mu.maxRate = kvAdmissionDecisionLogMaxRate.Get(&st.SV)
+	l.mu.limiter = rate.NewLimiter(
+		rate.Limit(l.mu.maxRate), kvAdmissionDecisionLogBurst(l.mu.maxRate))
+	return l
+}
+
+// kvAdmissionDecisionLogBurst returns the burst of the rate limiter for the
+// given rate, which is a second's worth of outcomes, so that the outcomes of
+// requests that arrive together are not all dropped but one.
+func kvAdmissionDecisionLogBurst(maxRate float64) int {
+	if maxRate < 1 {
+		return 1
+	}
+	return int(math.Ceil(maxRate))
+}
+
+// allow returns true if an outcome can be logged without exceeding the rate
+// limit. The mutex is only acquired while the log is enabled, and the limiter
+// acquires its own mutex anyway.
+func (l *kvAdmissionDecisionLog) allow() bool {
+	maxRate := kvAdmissionDecisionLogMaxRate.Get(&l.settings.SV)
+	now := l.timeSource.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if maxRate != l.mu.maxRate {
+		l.mu.maxRate = maxRate
+		l.mu.limiter.SetLimitAt(now, rate.Limit(maxRate))
+		l.mu.limiter.SetBurstAt(now, kvAdmissionDecisionLogBurst(maxRate))
+	}
+	return l.mu.limiter.AllowN(now, 1)
+}
+
+// enabled returns true if the outcomes should be passed to log.
+func (l *kvAdmissionDecisionLog) enabled() bool {
+	return l != nil && kvAdmissionDecisionLogEnabled.Get(&l.settings.SV)
+}
+
+// log logs the outcome of the admission of the batch, unless the rate limit
+// is exceeded. The outcome is a bypass if reason is not kvAdmissionNoBypass,
+// a rejection if err is non-nil, and an admission otherwise.
+func (l *kvAdmissionDecisionLog) log(
+	ctx context.Context,
+	tenantID roachpb.TenantID,
+	ba *roachpb.BatchRequest,
+	reason kvAdmissionBypassReason,
+	waitDuration time.Duration,
+	err error,
+) {
+	if !l.allow() {
+		return
+	}
+	summary := redact.SafeString(ba.Summary())
+	switch {
+	case reason != kvAdmissionNoBypass:
+		log.Health.Infof(ctx, "kv admission: bypassed (%s): tenant=%d r%d pri=%d %s",
+			reason, tenantID.ToUint64(), ba.RangeID, ba.AdmissionHeader.Priority, summary)
+	case err != nil:
+		log.Health.Infof(ctx, "kv admission: rejected after %s: tenant=%d r%d pri=%d %s: %v",
+			waitDuration, tenantID.ToUint64(), ba.RangeID, ba.AdmissionHeader.Priority, summary, err)
+	default:
+		log.Health.Infof(ctx, "kv admission: admitted after %s: tenant=%d r%d pri=%d %s",
+			waitDuration, tenantID.ToUint64(), ba.RangeID, ba.AdmissionHeader.Priority, summary)
+	}
+}
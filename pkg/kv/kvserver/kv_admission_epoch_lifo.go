@@ -0,0 +1,51 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+)
+
+// The epoch-LIFO settings select the KV work classes that are subject to
+// epoch-LIFO ordering in the KV and store admission queues, when it is
+// enabled by admission.epoch_lifo.enabled. Epoch-LIFO ordering improves the
+// tail latency of foreground work under overload, at the expense of
+// fairness, which matters less for elastic and bulk work that only cares
+// about throughput. Foreground work is work with the DEFAULT
+// AdmissionHeader.WorkClass; all other work classes are background work.
+var (
+	epochLIFOEnabledForeground = settings.RegisterBoolSetting(
+		settings.SystemOnly,
+		"admission.kv.epoch_lifo.foreground.enabled",
+		"when true, and admission.epoch_lifo.enabled is true, foreground KV work "+
+			"is subject to epoch-LIFO ordering",
+		true,
+	)
+	epochLIFOEnabledBackground = settings.RegisterBoolSetting(
+		settings.SystemOnly,
+		"admission.kv.epoch_lifo.background.enabled",
+		"when true, and admission.epoch_lifo.enabled is true, background KV work "+
+			"is subject to epoch-LIFO ordering",
+		true,
+	)
+)
+
+// epochLIFOEnabledForWorkClass returns whether work of the given class may be
+// subject to epoch-LIFO ordering.
+func epochLIFOEnabledForWorkClass(
+	sv *settings.Values, workClass roachpb.AdmissionHeader_WorkClass,
+) bool {
+	if isForegroundWorkClass(workClass) {
+		return epochLIFOEnabledForeground.Get(sv)
+	}
+	return epochLIFOEnabledBackground.Get(sv)
+}
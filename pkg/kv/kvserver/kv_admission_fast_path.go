@@ -0,0 +1,24 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import "github.com/cockroachdb/cockroach/pkg/settings"
+
+// kvAdmissionFastPathEnabled controls whether work is first offered to
+// admission.WorkQueue.TryFastAdmit, which admits work without serializing
+// with other admissions when no work is waiting in the KV admission queue.
+var kvAdmissionFastPathEnabled = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"admission.kv.fast_path.enabled",
+	"when true, KV work is admitted without serializing with other admissions "+
+		"when no work is waiting in the KV admission queue",
+	false,
+)
@@ -0,0 +1,95 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+)
+
+// followerApplyAdmissionEnabled controls whether the application of commands
+// proposed by other replicas consumes the store's write tokens. Writes are
+// only admitted on the store of the proposer, so without this, the stores of
+// followers can be overloaded during bulk writes.
+var followerApplyAdmissionEnabled = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"admission.kv.follower_apply.enabled",
+	"when true, large batches of commands proposed by other replicas consume store write "+
+		"tokens when they are applied by followers",
+	false,
+)
+
+// followerApplyMinBytes is the size of a batch of commands below which its
+// application is not accounted for, since the bookkeeping would outweigh the
+// work.
+var followerApplyMinBytes = settings.RegisterByteSizeSetting(
+	settings.SystemOnly,
+	"admission.kv.follower_apply.min_bytes",
+	"the bytes written by a batch of commands proposed by other replicas below which "+
+		"the batch is applied without consuming store write tokens",
+	256<<10, /* 256 KiB */
+)
+
+// AccountFollowerApplication implements the StoreStatsReporter interface.
+//
+// The bytes consume tokens without waiting for them: the commands are
+// already committed, and waiting would block the raft processing of the range
+// while raftMu is held. Instead, the consumed tokens hold back the work that
+// is subsequently admitted on the store.
+func (n KVAdmissionControllerImpl) AccountFollowerApplication(
+	storeID roachpb.StoreID, tenantID roachpb.TenantID, writeBytes int64,
+) {
+	if n.storeQueues == nil || !followerApplyAdmissionEnabled.Get(&n.settings.SV) ||
+		writeBytes < followerApplyMinBytes.Get(&n.settings.SV) {
+		return
+	}
+	q := n.storeQueues.queueForStore(storeID)
+	if q == nil {
+		return
+	}
+	q.FollowerAppliedBytes(tenantID, writeBytes)
+	if n.metrics != nil {
+		n.metrics.FollowerApplyAccounted.Inc(1)
+	}
+}
+
+// followerApplyExempt returns true if the application of commands on
+// followers of the range is never accounted for by store admission. These are
+// the ranges of the system keyspace, such as the node liveness and meta
+// ranges, and of the system config tables, whose writes must not be held
+// back by the load of user ranges.
+func followerApplyExempt(desc *roachpb.RangeDescriptor) bool {
+	return desc.StartKey.Less(roachpb.RKey(keys.SystemConfigTableDataMax))
+}
+
+// proposerBypassedAdmission returns true if the batch bypasses admission
+// control on the store of the proposer irrespective of the admission
+// settings, see KVAdmissionControllerImpl.AdmitKVWork. These are the admin
+// requests and the requests with the OTHER AdmissionHeader.Source from the
+// system tenant, which include node liveness heartbeats and lease requests
+// (which do not even reach AdmitKVWork). It is recorded in the RaftCommand,
+// so that followers do not account for the application of these commands
+// either.
+func proposerBypassedAdmission(ctx context.Context, ba *roachpb.BatchRequest) bool {
+	if tenantID, ok := roachpb.TenantFromContext(ctx); ok &&
+		!roachpb.IsSystemTenantID(tenantID.ToUint64()) {
+		// Requests from secondary tenants are always subject to admission.
+		// Those that bypass it because of
+		// admission.kv.tenant_system_table_bypass.tenants are still charged
+		// to the store by the proposer, see AccountTenantBypassWrite, and must
+		// be accounted for by followers as well.
+		return false
+	}
+	return ba.IsAdmin() || ba.AdmissionHeader.Source == roachpb.AdmissionHeader_OTHER
+}
@@ -0,0 +1,96 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/admission"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+)
+
+// storeL0OverloadThresholds overrides the L0 overload thresholds for
+// individual stores. The thresholds are otherwise global, but stores backed
+// by different disks, even on the same node, can have very different healthy
+// L0 shapes.
+var storeL0OverloadThresholds = settings.RegisterValidatedStringSetting(
+	settings.SystemOnly,
+	"admission.kv.store_l0_overload_thresholds",
+	"comma-separated list of per-store overrides of the L0 overload thresholds, each of the form "+
+		"<store-id>:<file-count>:<sub-level-count>; a count of 0 uses "+
+		"admission.l0_file_count_overload_threshold or admission.l0_sub_level_count_overload_threshold",
+	"",
+	func(_ *settings.Values, s string) error {
+		_, err := parseStoreL0OverloadThresholds(s)
+		return err
+	},
+)
+
+// parseStoreL0OverloadThresholds parses the value of the
+// admission.kv.store_l0_overload_thresholds setting.
+func parseStoreL0OverloadThresholds(s string) (map[int32]admission.L0OverloadThresholds, error) {
+	overrides := make(map[int32]admission.L0OverloadThresholds)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			return nil, errors.Errorf(
+				"invalid entry %q, expected <store-id>:<file-count>:<sub-level-count>", entry)
+		}
+		var vals [3]int64
+		for i, p := range parts {
+			v, err := strconv.ParseInt(strings.TrimSpace(p), 10, 64)
+			if err != nil || v < 0 {
+				return nil, errors.Errorf("invalid entry %q, expected non-negative integers", entry)
+			}
+			vals[i] = v
+		}
+		if vals[0] == 0 || vals[0] > math.MaxInt32 {
+			return nil, errors.Errorf("invalid store ID in entry %q", entry)
+		}
+		storeID := int32(vals[0])
+		if _, ok := overrides[storeID]; ok {
+			return nil, errors.Errorf("duplicate entry for store %d", storeID)
+		}
+		overrides[storeID] = admission.L0OverloadThresholds{
+			FileCount: vals[1], SubLevelCount: vals[2],
+		}
+	}
+	return overrides, nil
+}
+
+// watchStoreL0OverloadThresholds applies the
+// admission.kv.store_l0_overload_thresholds setting to the stores, now and
+// whenever it changes.
+func watchStoreL0OverloadThresholds(
+	st *cluster.Settings, storeGrantCoords *admission.StoreGrantCoordinators,
+) {
+	update := func(ctx context.Context) {
+		overrides, err := parseStoreL0OverloadThresholds(storeL0OverloadThresholds.Get(&st.SV))
+		if err != nil {
+			// The setting is validated, so this should not happen.
+			log.Warningf(ctx, "ignoring invalid %s: %v", storeL0OverloadThresholds.Key(), err)
+			overrides = nil
+		}
+		storeGrantCoords.SetL0OverloadThresholdOverrides(overrides)
+	}
+	update(context.Background())
+	storeL0OverloadThresholds.SetOnChange(&st.SV, update)
+}
@@ -0,0 +1,136 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+)
+
+// The load shedding settings bound the admission queues. During a severe
+// overload, unbounded queues only turn a collapse in throughput into a storm
+// of timeouts, so work beyond the bounds is rejected immediately instead, with
+// a roachpb.AdmissionOverloadedError. The work was not evaluated, and
+// DistSender retries it, possibly on another replica. Foreground work
+// is work with the DEFAULT AdmissionHeader.WorkClass; all other work classes
+// are background work.
+var (
+	loadSheddingMaxQueueLengthForeground = settings.RegisterIntSetting(
+		settings.SystemOnly,
+		"admission.kv.load_shedding.foreground.max_queue_length",
+		"the number of requests waiting in a KV or store admission queue beyond "+
+			"which foreground work is rejected; 0 disables the bound",
+		0,
+		settings.NonNegativeInt,
+	)
+	loadSheddingMaxQueueLengthBackground = settings.RegisterIntSetting(
+		settings.SystemOnly,
+		"admission.kv.load_shedding.background.max_queue_length",
+		"the number of requests waiting in a KV or store admission queue beyond "+
+			"which background work is rejected; 0 disables the bound",
+		0,
+		settings.NonNegativeInt,
+	)
+	loadSheddingMaxWaitForeground = settings.RegisterDurationSetting(
+		settings.SystemOnly,
+		"admission.kv.load_shedding.foreground.max_wait",
+		"the maximum time foreground work waits in the KV admission queues before "+
+			"it is rejected; 0 disables the bound",
+		0,
+		settings.NonNegativeDuration,
+	)
+	loadSheddingMaxWaitBackground = settings.RegisterDurationSetting(
+		settings.SystemOnly,
+		"admission.kv.load_shedding.background.max_wait",
+		"the maximum time background work waits in the KV admission queues before "+
+			"it is rejected; 0 disables the bound",
+		0,
+		settings.NonNegativeDuration,
+	)
+)
+
+func isForegroundWorkClass(workClass roachpb.AdmissionHeader_WorkClass) bool {
+	return workClass == roachpb.AdmissionHeader_DEFAULT
+}
+
+// loadSheddingMaxQueueLength returns the number of waiting requests beyond
+// which work of the given class is rejected, or 0 if it is not bounded.
+func loadSheddingMaxQueueLength(
+	sv *settings.Values, workClass roachpb.AdmissionHeader_WorkClass,
+) int {
+	if isForegroundWorkClass(workClass) {
+		return int(loadSheddingMaxQueueLengthForeground.Get(sv))
+	}
+	return int(loadSheddingMaxQueueLengthBackground.Get(sv))
+}
+
+// loadSheddingMaxWait returns the time after which waiting work of the given
+// class is rejected, or 0 if it is not bounded.
+func loadSheddingMaxWait(
+	sv *settings.Values, workClass roachpb.AdmissionHeader_WorkClass,
+) time.Duration {
+	if isForegroundWorkClass(workClass) {
+		return loadSheddingMaxWaitForeground.Get(sv)
+	}
+	return loadSheddingMaxWaitBackground.Get(sv)
+}
+
+// checkQueueLengths returns a roachpb.AdmissionOverloadedError if the KV
+// admission queue, or the store admission queue if non-nil, has reached the
+// maximum queue length of the work class.
+func (n KVAdmissionControllerImpl) checkQueueLengths(
+	workClass roachpb.AdmissionHeader_WorkClass, storeAdmissionQ storeAdmissionWorkQueue,
+) error {
+	maxQueueLength := loadSheddingMaxQueueLength(&n.settings.SV, workClass)
+	if maxQueueLength <= 0 {
+		return nil
+	}
+	if l := n.kvQueue.NumWaiting(); l >= maxQueueLength {
+		n.metrics.onLoadShed(kvAdmissionShedQueueLength)
+		return roachpb.NewAdmissionOverloadedError(fmt.Sprintf(
+			"KV admission queue length %d reached the maximum of %d", l, maxQueueLength))
+	}
+	if storeAdmissionQ == nil {
+		return nil
+	}
+	if l := storeAdmissionQ.NumWaiting(); l >= maxQueueLength {
+		n.metrics.onLoadShed(kvAdmissionShedQueueLength)
+		return roachpb.NewAdmissionOverloadedError(fmt.Sprintf(
+			"store admission queue length %d reached the maximum of %d", l, maxQueueLength))
+	}
+	return nil
+}
+
+// loadShedError returns a roachpb.AdmissionOverloadedError in place of err if
+// the work failed to be admitted because it waited beyond the maximum wait of
+// its class or its queue deadline, as given by reason, i.e. if ctx expired at
+// shedDeadline while callerCtx is still live. Otherwise it returns err.
+func (n KVAdmissionControllerImpl) loadShedError(
+	callerCtx, ctx context.Context,
+	shedDeadline time.Time,
+	reason kvAdmissionShedReason,
+	err error,
+) error {
+	if shedDeadline.IsZero() || callerCtx.Err() != nil || ctx.Err() == nil {
+		return err
+	}
+	n.metrics.onLoadShed(reason)
+	if reason == kvAdmissionShedQueueDeadline {
+		return roachpb.NewAdmissionOverloadedError(fmt.Sprintf(
+			"exceeded the queue deadline in the admission queues: %v", err))
+	}
+	return roachpb.NewAdmissionOverloadedError(fmt.Sprintf(
+		"exceeded the maximum wait in the admission queues: %v", err))
+}
@@ -0,0 +1,503 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/multitenant"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/admission/admissionpb"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/metric/aggmetric"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// kvAdmissionTenantMetricsMaxTenants bounds the number of tenants for which
+// per-tenant KV admission metrics are exported. Tenants seen after the limit
+// is reached are accounted for under a shared "other" label.
+var kvAdmissionTenantMetricsMaxTenants = settings.RegisterIntSetting(
+	settings.SystemOnly,
+	"admission.kv.tenant_metrics.max_tenants",
+	"the maximum number of tenants for which per-tenant KV admission metrics are exported; "+
+		"work from additional tenants is accounted for under tenant_id=\"other\"",
+	100,
+	settings.NonNegativeInt,
+)
+
+// otherTenantsLabel is the tenant label used for tenants that exceed the
+// cardinality limit.
+const otherTenantsLabel = "other"
+
+var (
+	metaKVAdmissionTenantAdmitted = metric.Metadata{
+		Name:        "admission.tenant_admitted.kv",
+		Help:        "Number of KV requests admitted, by tenant",
+		Measurement: "Requests",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaKVAdmissionTenantRejected = metric.Metadata{
+		Name:        "admission.tenant_rejected.kv",
+		Help:        "Number of KV requests that failed admission, by tenant",
+		Measurement: "Requests",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaKVAdmissionTenantWaiting = metric.Metadata{
+		Name:        "admission.tenant_waiting.kv",
+		Help:        "Number of KV requests currently waiting for admission, by tenant",
+		Measurement: "Requests",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaKVAdmissionTenantExecuting = metric.Metadata{
+		Name:        "admission.tenant_executing.kv",
+		Help:        "Number of admitted KV requests that have not yet completed, by tenant",
+		Measurement: "Requests",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaKVAdmissionTenantWaitDurationSum = metric.Metadata{
+		Name:        "admission.tenant_wait_duration_sum.kv",
+		Help:        "Cumulative time KV requests spent waiting for admission, by tenant",
+		Measurement: "Nanoseconds",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaKVAdmissionTenantReadKeys = metric.Metadata{
+		Name:        "admission.tenant_read_keys.kv",
+		Help:        "Number of keys read by admitted read-only KV requests, by tenant",
+		Measurement: "Keys",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaKVAdmissionTenantReadBytes = metric.Metadata{
+		Name:        "admission.tenant_read_bytes.kv",
+		Help:        "Number of bytes returned by admitted read-only KV requests, by tenant",
+		Measurement: "Bytes",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaKVAdmissionBypassedAdmin = metric.Metadata{
+		Name:        "admission.bypassed_admin.kv",
+		Help:        "Number of KV requests that bypassed admission since they are admin requests",
+		Measurement: "Requests",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaKVAdmissionBypassedOtherSource = metric.Metadata{
+		Name:        "admission.bypassed_other_source.kv",
+		Help:        "Number of KV requests that bypassed admission since their source is not subject to admission",
+		Measurement: "Requests",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaKVAdmissionBypassedAllowlist = metric.Metadata{
+		Name:        "admission.bypassed_allowlist.kv",
+		Help:        "Number of KV requests that bypassed admission due to admission.kv.bypass_methods",
+		Measurement: "Requests",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaKVAdmissionBypassedTenant = metric.Metadata{
+		Name:        "admission.bypassed_tenant.kv",
+		Help:        "Number of KV requests from secondary tenants that bypassed admission",
+		Measurement: "Requests",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaKVAdmissionBypassedStoreHeartbeatTxn = metric.Metadata{
+		Name:        "admission.bypassed_store_heartbeat_txn.kv",
+		Help:        "Number of transaction heartbeats that bypassed store admission",
+		Measurement: "Requests",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaKVAdmissionFollowerApplyAccounted = metric.Metadata{
+		Name:        "admission.follower_apply_accounted.kv",
+		Help:        "Number of batches of commands proposed by other replicas whose application consumed store write tokens",
+		Measurement: "Batches",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaKVAdmissionDoubleWorkDone = metric.Metadata{
+		Name:        "admission.double_work_done.kv",
+		Help:        "Number of times AdmittedKVWorkDone was called more than once for the same admitted KV request",
+		Measurement: "Requests",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaKVAdmissionLoadShedQueueLength = metric.Metadata{
+		Name:        "admission.load_shed_queue_length.kv",
+		Help:        "Number of KV requests rejected because an admission queue reached the maximum queue length of their work class",
+		Measurement: "Requests",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaKVAdmissionLoadShedMaxWait = metric.Metadata{
+		Name:        "admission.load_shed_max_wait.kv",
+		Help:        "Number of KV requests rejected because they waited in the admission queues beyond the maximum wait of their work class",
+		Measurement: "Requests",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaKVAdmissionLoadShedQueueDeadline = metric.Metadata{
+		Name:        "admission.load_shed_queue_deadline.kv",
+		Help:        "Number of KV requests rejected because they waited in the admission queues beyond the queue deadline in their AdmissionHeader",
+		Measurement: "Requests",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaKVAdmissionStoreRebinds = metric.Metadata{
+		Name:        "admission.store_rebinds.kv",
+		Help:        "Number of admitted KV requests whose store admission was moved to the store that evaluated them",
+		Measurement: "Requests",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaKVAdmissionStoreWorkDoneErrors = metric.Metadata{
+		Name:        "admission.store_work_done_errors.kv",
+		Help:        "Number of times the release of admitted KV work from a store admission queue failed",
+		Measurement: "Requests",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaKVAdmissionTenantWeightsStaleness = metric.Metadata{
+		Name: "admission.tenant_weights_staleness.kv",
+		Help: "Time since the tenant weight provider last successfully returned tenant weights, " +
+			"or -1 if it has not returned any yet",
+		Measurement: "Nanoseconds",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaKVAdmissionTenantWeightsProviderLatency = metric.Metadata{
+		Name:        "admission.tenant_weights_provider_latency.kv",
+		Help:        "Latency of calls to the tenant weight provider, including calls that timed out",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaKVAdmissionWaitDurationsHighPri = metric.Metadata{
+		Name:        "admission.wait_durations_high_pri.kv",
+		Help:        "Wait time durations in the KV admission queues of requests with a priority above normal",
+		Measurement: "Wait time Duration",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaKVAdmissionWaitDurationsNormalPri = metric.Metadata{
+		Name:        "admission.wait_durations_normal_pri.kv",
+		Help:        "Wait time durations in the KV admission queues of requests with a priority above bulk, up to normal",
+		Measurement: "Wait time Duration",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaKVAdmissionWaitDurationsBulkPri = metric.Metadata{
+		Name:        "admission.wait_durations_bulk_pri.kv",
+		Help:        "Wait time durations in the KV admission queues of requests with bulk or lower priority",
+		Measurement: "Wait time Duration",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaKVAdmissionTenantWeightsProviderErrors = metric.Metadata{
+		Name:        "admission.tenant_weights_provider_errors.kv",
+		Help:        "Number of calls to the tenant weight provider that failed, panicked or timed out",
+		Measurement: "Errors",
+		Unit:        metric.Unit_COUNT,
+	}
+)
+
+// KVAdmissionMetrics are the metrics maintained by the KVAdmissionController.
+// The per-tenant metrics are exported to prometheus with a tenant_id label,
+// and only their aggregate is recorded in the internal time series.
+type KVAdmissionMetrics struct {
+	TenantAdmitted        *aggmetric.AggCounter
+	TenantRejected        *aggmetric.AggCounter
+	TenantWaiting         *aggmetric.AggGauge
+	TenantExecuting       *aggmetric.AggGauge
+	TenantWaitDurationSum *aggmetric.AggCounter
+	TenantReadKeys        *aggmetric.AggCounter
+	TenantReadBytes       *aggmetric.AggCounter
+	// The Bypassed counters count the requests that bypassed admission, by
+	// reason (see kvAdmissionBypassReason).
+	BypassedAdmin             *metric.Counter
+	BypassedOtherSource       *metric.Counter
+	BypassedAllowlist         *metric.Counter
+	BypassedTenant            *metric.Counter
+	BypassedStoreHeartbeatTxn *metric.Counter
+	FollowerApplyAccounted    *metric.Counter
+	// DoubleWorkDone counts handles that were done more than once, which
+	// indicates a bug in the caller.
+	DoubleWorkDone *metric.Counter
+	// The LoadShed counters count the requests rejected by load shedding,
+	// by reason (see kvAdmissionShedReason).
+	LoadShedQueueLength   *metric.Counter
+	LoadShedMaxWait       *metric.Counter
+	LoadShedQueueDeadline *metric.Counter
+	// StoreRebinds counts requests that were admitted against a store other
+	// than the one that evaluated them.
+	StoreRebinds *metric.Counter
+	// StoreWorkDoneErrors counts the failed releases of work from the store
+	// admission queues.
+	StoreWorkDoneErrors *metric.Counter
+	// TenantWeightsStaleness is the age of the tenant weights in use, which
+	// grows if the TenantWeightProvider is failing. It is -1 until the
+	// provider first returns weights.
+	TenantWeightsStaleness *metric.Gauge
+	// TenantWeightsProviderLatency and TenantWeightsProviderErrors make stalls
+	// and failures of the TenantWeightProvider visible.
+	TenantWeightsProviderLatency *metric.Histogram
+	// The WaitDurations histograms are the time spent waiting in the KV and
+	// store admission queues, by priority band (see waitDurationsForPriority).
+	WaitDurationsHighPri        *metric.Histogram
+	WaitDurationsNormalPri      *metric.Histogram
+	WaitDurationsBulkPri        *metric.Histogram
+	TenantWeightsProviderErrors *metric.Counter
+
+	// The fields below are invisible to the metric package.
+	settings *cluster.Settings
+	mu       struct {
+		syncutil.RWMutex
+		tenants map[roachpb.TenantID]*kvAdmissionTenantMetrics
+		// other aggregates the tenants beyond the cardinality limit. Lazily
+		// allocated.
+		other *kvAdmissionTenantMetrics
+	}
+}
+
+var _ metric.Struct = (*KVAdmissionMetrics)(nil)
+
+// MetricStruct implements the metric.Struct interface.
+func (m *KVAdmissionMetrics) MetricStruct() {}
+
+// MakeKVAdmissionMetrics constructs the metrics for a KVAdmissionController.
+func MakeKVAdmissionMetrics(
+	st *cluster.Settings, histogramWindow time.Duration,
+) *KVAdmissionMetrics {
+	b := aggmetric.MakeBuilder(multitenant.TenantIDLabel)
+	m := &KVAdmissionMetrics{
+		TenantAdmitted:        b.Counter(metaKVAdmissionTenantAdmitted),
+		TenantRejected:        b.Counter(metaKVAdmissionTenantRejected),
+		TenantWaiting:         b.Gauge(metaKVAdmissionTenantWaiting),
+		TenantExecuting:       b.Gauge(metaKVAdmissionTenantExecuting),
+		TenantWaitDurationSum: b.Counter(metaKVAdmissionTenantWaitDurationSum),
+		TenantReadKeys:        b.Counter(metaKVAdmissionTenantReadKeys),
+		TenantReadBytes:       b.Counter(metaKVAdmissionTenantReadBytes),
+		BypassedAdmin:         metric.NewCounter(metaKVAdmissionBypassedAdmin),
+		BypassedOtherSource:   metric.NewCounter(metaKVAdmissionBypassedOtherSource),
+		BypassedAllowlist:     metric.NewCounter(metaKVAdmissionBypassedAllowlist),
+		BypassedTenant:        metric.NewCounter(metaKVAdmissionBypassedTenant),
+		BypassedStoreHeartbeatTxn: metric.NewCounter(
+			metaKVAdmissionBypassedStoreHeartbeatTxn),
+		FollowerApplyAccounted: metric.NewCounter(metaKVAdmissionFollowerApplyAccounted),
+		DoubleWorkDone:         metric.NewCounter(metaKVAdmissionDoubleWorkDone),
+		LoadShedQueueLength:    metric.NewCounter(metaKVAdmissionLoadShedQueueLength),
+		LoadShedMaxWait:        metric.NewCounter(metaKVAdmissionLoadShedMaxWait),
+		LoadShedQueueDeadline:  metric.NewCounter(metaKVAdmissionLoadShedQueueDeadline),
+		StoreRebinds:           metric.NewCounter(metaKVAdmissionStoreRebinds),
+		StoreWorkDoneErrors:    metric.NewCounter(metaKVAdmissionStoreWorkDoneErrors),
+		TenantWeightsStaleness: metric.NewGauge(metaKVAdmissionTenantWeightsStaleness),
+		TenantWeightsProviderLatency: metric.NewLatency(
+			metaKVAdmissionTenantWeightsProviderLatency, histogramWindow),
+		WaitDurationsHighPri: metric.NewLatency(
+			metaKVAdmissionWaitDurationsHighPri, histogramWindow),
+		WaitDurationsNormalPri: metric.NewLatency(
+			metaKVAdmissionWaitDurationsNormalPri, histogramWindow),
+		WaitDurationsBulkPri: metric.NewLatency(
+			metaKVAdmissionWaitDurationsBulkPri, histogramWindow),
+		TenantWeightsProviderErrors: metric.NewCounter(metaKVAdmissionTenantWeightsProviderErrors),
+		settings:                    st,
+	}
+	m.mu.tenants = make(map[roachpb.TenantID]*kvAdmissionTenantMetrics)
+	return m
+}
+
+// waitDurationsForPriority returns the wait durations histogram of the
+// priority band of the given priority: high for priorities above
+// admissionpb.NormalPri, normal for priorities above admissionpb.BulkNormalPri
+// up to NormalPri, and bulk for the rest.
+func (m *KVAdmissionMetrics) waitDurationsForPriority(
+	priority admissionpb.WorkPriority,
+) *metric.Histogram {
+	switch {
+	case priority > admissionpb.NormalPri:
+		return m.WaitDurationsHighPri
+	case priority > admissionpb.BulkNormalPri:
+		return m.WaitDurationsNormalPri
+	default:
+		return m.WaitDurationsBulkPri
+	}
+}
+
+// kvAdmissionBypassReason is the reason that a KV request bypassed admission.
+type kvAdmissionBypassReason int8
+
+const (
+	kvAdmissionNoBypass kvAdmissionBypassReason = iota
+	// kvAdmissionBypassAdmin is used for admin requests from the system tenant.
+	kvAdmissionBypassAdmin
+	// kvAdmissionBypassOtherSource is used for requests whose
+	// AdmissionHeader.Source is OTHER.
+	kvAdmissionBypassOtherSource
+	// kvAdmissionBypassAllowlist is used for requests whose methods are in
+	// admission.kv.bypass_methods.
+	kvAdmissionBypassAllowlist
+	// kvAdmissionBypassTenant is used for the requests of secondary tenants
+	// that skip the admission queues, see kvAdmissionTenantBypass.
+	kvAdmissionBypassTenant
+	// kvAdmissionBypassStoreHeartbeatTxn is used for transaction heartbeats,
+	// which are not subject to store admission, but are subject to KV
+	// admission.
+	kvAdmissionBypassStoreHeartbeatTxn
+)
+
+// SafeValue implements the redact.SafeValue interface.
+func (kvAdmissionBypassReason) SafeValue() {}
+
+func (r kvAdmissionBypassReason) String() string {
+	switch r {
+	case kvAdmissionNoBypass:
+		return "none"
+	case kvAdmissionBypassAdmin:
+		return "admin"
+	case kvAdmissionBypassOtherSource:
+		return "other-source"
+	case kvAdmissionBypassAllowlist:
+		return "allowlist"
+	case kvAdmissionBypassTenant:
+		return "tenant"
+	case kvAdmissionBypassStoreHeartbeatTxn:
+		return "store-heartbeat-txn"
+	default:
+		return "unknown"
+	}
+}
+
+// kvAdmissionShedReason is the reason that a KV request was rejected by load
+// shedding.
+type kvAdmissionShedReason int8
+
+const (
+	// kvAdmissionShedQueueLength is used for requests rejected because an
+	// admission queue reached the maximum queue length of their work class.
+	kvAdmissionShedQueueLength kvAdmissionShedReason = iota
+	// kvAdmissionShedMaxWait is used for requests that waited beyond the
+	// maximum wait of their work class.
+	kvAdmissionShedMaxWait
+	// kvAdmissionShedQueueDeadline is used for requests that waited beyond
+	// AdmissionHeader.QueueDeadline.
+	kvAdmissionShedQueueDeadline
+)
+
+// onLoadShed is called when a request is rejected by load shedding for the
+// given reason. It is a noop if m is nil.
+func (m *KVAdmissionMetrics) onLoadShed(reason kvAdmissionShedReason) {
+	if m == nil {
+		return
+	}
+	switch reason {
+	case kvAdmissionShedQueueLength:
+		m.LoadShedQueueLength.Inc(1)
+	case kvAdmissionShedMaxWait:
+		m.LoadShedMaxWait.Inc(1)
+	case kvAdmissionShedQueueDeadline:
+		m.LoadShedQueueDeadline.Inc(1)
+	}
+}
+
+// onBypass is called when a request bypasses admission for the given reason.
+// It is a noop if m is nil, or for kvAdmissionNoBypass.
+func (m *KVAdmissionMetrics) onBypass(reason kvAdmissionBypassReason) {
+	if m == nil {
+		return
+	}
+	switch reason {
+	case kvAdmissionBypassAdmin:
+		m.BypassedAdmin.Inc(1)
+	case kvAdmissionBypassOtherSource:
+		m.BypassedOtherSource.Inc(1)
+	case kvAdmissionBypassAllowlist:
+		m.BypassedAllowlist.Inc(1)
+	case kvAdmissionBypassTenant:
+		m.BypassedTenant.Inc(1)
+	case kvAdmissionBypassStoreHeartbeatTxn:
+		m.BypassedStoreHeartbeatTxn.Inc(1)
+	}
+}
+
+// kvAdmissionTenantMetrics are the child metrics for a single tenant (or for
+// the tenants beyond the cardinality limit).
+type kvAdmissionTenantMetrics struct {
+	admitted        *aggmetric.Counter
+	rejected        *aggmetric.Counter
+	waiting         *aggmetric.Gauge
+	executing       *aggmetric.Gauge
+	waitDurationSum *aggmetric.Counter
+	readKeys        *aggmetric.Counter
+	readBytes       *aggmetric.Counter
+}
+
+func (m *KVAdmissionMetrics) makeTenantMetrics(label string) *kvAdmissionTenantMetrics {
+	return &kvAdmissionTenantMetrics{
+		admitted:        m.TenantAdmitted.AddChild(label),
+		rejected:        m.TenantRejected.AddChild(label),
+		waiting:         m.TenantWaiting.AddChild(label),
+		executing:       m.TenantExecuting.AddChild(label),
+		waitDurationSum: m.TenantWaitDurationSum.AddChild(label),
+		readKeys:        m.TenantReadKeys.AddChild(label),
+		readBytes:       m.TenantReadBytes.AddChild(label),
+	}
+}
+
+// forTenant returns the child metrics for the given tenant, allocating them
+// if needed. Once the number of tracked tenants reaches the cardinality
+// limit, the shared child metrics for "other" tenants are returned.
+func (m *KVAdmissionMetrics) forTenant(tenantID roachpb.TenantID) *kvAdmissionTenantMetrics {
+	m.mu.RLock()
+	tm, ok := m.mu.tenants[tenantID]
+	m.mu.RUnlock()
+	if ok {
+		return tm
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if tm, ok := m.mu.tenants[tenantID]; ok {
+		return tm
+	}
+	if int64(len(m.mu.tenants)) >= kvAdmissionTenantMetricsMaxTenants.Get(&m.settings.SV) {
+		if m.mu.other == nil {
+			m.mu.other = m.makeTenantMetrics(otherTenantsLabel)
+		}
+		return m.mu.other
+	}
+	tm = m.makeTenantMetrics(tenantID.String())
+	m.mu.tenants[tenantID] = tm
+	return tm
+}
+
+// lookupTenant returns the child metrics for the given tenant, if the tenant
+// is tracked individually.
+func (m *KVAdmissionMetrics) lookupTenant(
+	tenantID roachpb.TenantID,
+) (*kvAdmissionTenantMetrics, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	tm, ok := m.mu.tenants[tenantID]
+	return tm, ok
+}
+
+// onAdmitStart is called before a request starts waiting for admission.
+func (tm *kvAdmissionTenantMetrics) onAdmitStart() {
+	tm.waiting.Inc(1)
+}
+
+// onAdmitEnd is called when a request is done waiting for admission, with
+// the duration of the wait and whether it was admitted.
+func (tm *kvAdmissionTenantMetrics) onAdmitEnd(waitDur time.Duration, admitted bool) {
+	tm.waiting.Dec(1)
+	tm.waitDurationSum.Inc(waitDur.Nanoseconds())
+	if admitted {
+		tm.admitted.Inc(1)
+		tm.executing.Inc(1)
+	} else {
+		tm.rejected.Inc(1)
+	}
+}
+
+// onWorkDone is called when admitted work is done executing.
+func (tm *kvAdmissionTenantMetrics) onWorkDone() {
+	tm.executing.Dec(1)
+}
+
+// onRead is called when admitted read-only work is done executing, with the
+// keys and bytes it read.
+func (tm *kvAdmissionTenantMetrics) onRead(keys, bytes int64) {
+	tm.readKeys.Inc(keys)
+	tm.readBytes.Inc(bytes)
+}
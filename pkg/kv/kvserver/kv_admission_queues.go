@@ -0,0 +1,62 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/admission"
+)
+
+// The interfaces below are the subset of the admission queues used to admit
+// KV work, so that tests can replace the queues with fakes that record the
+// interactions of the KVAdmissionControllerImpl with them.
+
+// kvAdmissionWorkQueue is implemented by *admission.WorkQueue.
+type kvAdmissionWorkQueue interface {
+	TryFastAdmit(info admission.WorkInfo) bool
+	Admit(ctx context.Context, info admission.WorkInfo) (enabled bool, err error)
+	AdmittedWorkDone(tenantID roachpb.TenantID)
+	NumWaiting() int
+}
+
+var _ kvAdmissionWorkQueue = (*admission.WorkQueue)(nil)
+
+// storeAdmissionWorkQueue is implemented by *admission.StoreWorkQueue.
+type storeAdmissionWorkQueue interface {
+	Admit(ctx context.Context, info admission.StoreWriteWorkInfo) (admission.StoreWorkHandle, error)
+	AdmittedWorkDone(h admission.StoreWorkHandle, ingestedIntoL0Bytes int64) error
+	FollowerAppliedBytes(tenantID roachpb.TenantID, bytes int64)
+	NumWaiting() int
+}
+
+var _ storeAdmissionWorkQueue = (*admission.StoreWorkQueue)(nil)
+
+// storeAdmissionQueues provides the store admission queues.
+type storeAdmissionQueues interface {
+	// queueForStore returns the admission queue of the store, or nil if the
+	// store is not subject to store admission.
+	queueForStore(storeID roachpb.StoreID) storeAdmissionWorkQueue
+}
+
+// storeGrantCoordsQueues implements storeAdmissionQueues.
+type storeGrantCoordsQueues struct {
+	*admission.StoreGrantCoordinators
+}
+
+func (c storeGrantCoordsQueues) queueForStore(storeID roachpb.StoreID) storeAdmissionWorkQueue {
+	if q := c.TryGetQueueForStore(int32(storeID)); q != nil {
+		return q
+	}
+	// NB: a nil *admission.StoreWorkQueue would be a non-nil interface.
+	return nil
+}
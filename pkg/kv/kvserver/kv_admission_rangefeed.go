@@ -0,0 +1,119 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/limit"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// kvAdmissionRangefeedCatchUpScansPerTenant limits the number of concurrent
+// rangefeed catch-up scans per tenant on a node. This prevents a tenant that
+// reconnects a large number of rangefeeds (for example, when restarting its
+// changefeeds) from starting all of their catch-up scans at once. The limit
+// applies in addition to the per-store kv.rangefeed.concurrent_catchup_iterators
+// limit.
+var kvAdmissionRangefeedCatchUpScansPerTenant = settings.RegisterIntSetting(
+	settings.SystemOnly,
+	"admission.kv.rangefeed.catchup_scans_per_tenant",
+	"the maximum number of concurrent rangefeed catch-up scans per tenant on a node, "+
+		"before queueing; 0 disables the limit",
+	0,
+	settings.NonNegativeInt,
+)
+
+// kvAdmissionRangefeedLimiter maintains a semaphore per tenant that limits
+// the number of concurrent rangefeed catch-up scans. The semaphore of a tenant
+// is discarded once it has no running or queued catch-up scans.
+type kvAdmissionRangefeedLimiter struct {
+	settings *cluster.Settings
+	mu       struct {
+		syncutil.Mutex
+		tenants map[roachpb.TenantID]*tenantRangefeedLimiter
+	}
+}
+
+type tenantRangefeedLimiter struct {
+	limit.ConcurrentRequestLimiter
+	// refs is the number of catch-up scans of the tenant that are running or
+	// queued. It is protected by kvAdmissionRangefeedLimiter.mu.
+	refs int
+}
+
+func newKVAdmissionRangefeedLimiter(st *cluster.Settings) *kvAdmissionRangefeedLimiter {
+	l := &kvAdmissionRangefeedLimiter{settings: st}
+	l.mu.tenants = make(map[roachpb.TenantID]*tenantRangefeedLimiter)
+	kvAdmissionRangefeedCatchUpScansPerTenant.SetOnChange(&st.SV, func(ctx context.Context) {
+		n := kvAdmissionRangefeedCatchUpScansPerTenant.Get(&st.SV)
+		if n == 0 {
+			// The limiters are not consulted while the limit is disabled, and are
+			// resized if it is reenabled.
+			return
+		}
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for _, tl := range l.mu.tenants {
+			tl.SetLimit(int(n))
+		}
+	})
+	return l
+}
+
+// begin blocks until the tenant is allowed to start a catch-up scan, or ctx
+// is canceled. The returned function must be called when the catch-up scan
+// is done.
+func (l *kvAdmissionRangefeedLimiter) begin(
+	ctx context.Context, tenantID roachpb.TenantID,
+) (release func(), _ error) {
+	n := kvAdmissionRangefeedCatchUpScansPerTenant.Get(&l.settings.SV)
+	if n == 0 {
+		return func() {}, nil
+	}
+	l.mu.Lock()
+	tl, ok := l.mu.tenants[tenantID]
+	if !ok {
+		tl = &tenantRangefeedLimiter{
+			ConcurrentRequestLimiter: limit.MakeConcurrentRequestLimiter(
+				fmt.Sprintf("rangefeed-catchup-scans-%s", tenantID), int(n)),
+		}
+		l.mu.tenants[tenantID] = tl
+	}
+	tl.refs++
+	l.mu.Unlock()
+	alloc, err := tl.Begin(ctx)
+	if err != nil {
+		l.unref(tenantID, tl)
+		return nil, err
+	}
+	return func() {
+		alloc.Release()
+		l.unref(tenantID, tl)
+	}, nil
+}
+
+// unref drops a reference to the semaphore of the tenant, and discards it if
+// it was the last one.
+func (l *kvAdmissionRangefeedLimiter) unref(
+	tenantID roachpb.TenantID, tl *tenantRangefeedLimiter,
+) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	tl.refs--
+	if tl.refs == 0 {
+		delete(l.mu.tenants, tenantID)
+	}
+}
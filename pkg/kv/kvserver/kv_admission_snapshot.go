@@ -0,0 +1,193 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/admission"
+	"github.com/cockroachdb/cockroach/pkg/util/admission/admissionpb"
+	"github.com/cockroachdb/cockroach/pkg/util/quotapool"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// snapshotIngestPacingEnabled controls whether incoming snapshots are subject
+// to admission by the store's admission queue before being ingested. It is
+// disabled by default since pacing snapshots can delay up-replication.
+var snapshotIngestPacingEnabled = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"admission.kv.snapshot_ingest_pacing.enabled",
+	"when true, incoming snapshots wait for admission by the store's admission queue "+
+		"before being ingested, so that rebalancing and recovery cannot overload the "+
+		"store's LSM",
+	false,
+)
+
+// snapshotIngestMaxRate is a per-store budget for receiving snapshot data.
+// Pacing at the receiver, rather than at each sender, bounds the disk
+// bandwidth used by snapshots on a store regardless of how many senders there
+// are, and leaves the remaining bandwidth for foreground writes.
+var snapshotIngestMaxRate = settings.RegisterByteSizeSetting(
+	settings.SystemOnly,
+	"admission.kv.snapshot_ingest.max_rate",
+	"the maximum rate per store at which incoming snapshot data is received; when "+
+		"non-zero, it replaces the sender side limits of kv.snapshot_rebalance.max_rate "+
+		"and kv.snapshot_recovery.max_rate; 0 disables the limit",
+	0,
+	settings.NonNegativeInt,
+)
+
+// kvAdmissionSnapshotLimiter enforces snapshotIngestMaxRate for each store.
+type kvAdmissionSnapshotLimiter struct {
+	settings   *cluster.Settings
+	timeSource timeutil.TimeSource
+	mu         struct {
+		syncutil.Mutex
+		stores map[roachpb.StoreID]*quotapool.RateLimiter
+	}
+}
+
+func newKVAdmissionSnapshotLimiter(
+	st *cluster.Settings, timeSource timeutil.TimeSource,
+) *kvAdmissionSnapshotLimiter {
+	l := &kvAdmissionSnapshotLimiter{settings: st, timeSource: timeSource}
+	l.mu.stores = make(map[roachpb.StoreID]*quotapool.RateLimiter)
+	snapshotIngestMaxRate.SetOnChange(&st.SV, func(ctx context.Context) {
+		rate, burst := l.limit()
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for _, rl := range l.mu.stores {
+			rl.UpdateLimit(rate, burst)
+		}
+	})
+	return l
+}
+
+func (l *kvAdmissionSnapshotLimiter) limit() (quotapool.Limit, int64) {
+	return rateAndBurst(float64(snapshotIngestMaxRate.Get(&l.settings.SV)))
+}
+
+// wait blocks until the given number of snapshot bytes for the store are
+// within the rate limit, or ctx is canceled.
+func (l *kvAdmissionSnapshotLimiter) wait(
+	ctx context.Context, storeID roachpb.StoreID, bytes int64,
+) error {
+	if snapshotIngestMaxRate.Get(&l.settings.SV) == 0 {
+		return nil
+	}
+	l.mu.Lock()
+	rl, ok := l.mu.stores[storeID]
+	if !ok {
+		rate, burst := l.limit()
+		rl = quotapool.NewRateLimiter(fmt.Sprintf("kv-admission-snapshot-s%d", storeID), rate, burst,
+			quotapool.WithTimeSource(l.timeSource))
+		l.mu.stores[storeID] = rl
+	}
+	l.mu.Unlock()
+	return rl.WaitN(ctx, bytes)
+}
+
+// removeStore discards the rate limiter of a store that was removed.
+func (l *kvAdmissionSnapshotLimiter) removeStore(storeID roachpb.StoreID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.mu.stores, storeID)
+}
+
+// AdmitSnapshotBytes implements the StoreStatsReporter interface.
+func (n KVAdmissionControllerImpl) AdmitSnapshotBytes(
+	ctx context.Context, storeID roachpb.StoreID, bytes int64,
+) error {
+	if n.kvAdmissionQ == nil {
+		return nil
+	}
+	return n.snapshotLimiter.wait(ctx, storeID, bytes)
+}
+
+// snapshotAdmissionPriority returns the admission priority of a snapshot.
+// Rebalancing snapshots yield to foreground work, unlike recovery snapshots,
+// which restore the replication factor.
+func snapshotAdmissionPriority(
+	priority kvserverpb.SnapshotRequest_Priority,
+) admissionpb.WorkPriority {
+	if priority == kvserverpb.SnapshotRequest_REBALANCE {
+		return admissionpb.BulkNormalPri
+	}
+	return admissionpb.NormalPri
+}
+
+// SnapshotIngestHandle is returned by AdmitSnapshotIngest, and must be passed
+// to SnapshotIngestDone. The zero value is a handle for a snapshot that was
+// not subject to admission.
+type SnapshotIngestHandle struct {
+	storeAdmissionQ storeAdmissionWorkQueue
+	storeWorkHandle admission.StoreWorkHandle
+	bytes           int64
+}
+
+// AdmitSnapshotIngest implements the StoreStatsReporter interface.
+func (n KVAdmissionControllerImpl) AdmitSnapshotIngest(
+	ctx context.Context,
+	storeID roachpb.StoreID,
+	priority kvserverpb.SnapshotRequest_Priority,
+	bytes int64,
+) (SnapshotIngestHandle, error) {
+	if n.kvAdmissionQ == nil || bytes <= 0 ||
+		!snapshotIngestPacingEnabled.Get(&n.settings.SV) {
+		return SnapshotIngestHandle{}, nil
+	}
+	q := n.storeQueues.queueForStore(storeID)
+	if q == nil {
+		return SnapshotIngestHandle{}, nil
+	}
+	// The snapshot is accounted for as an ingest request by the system tenant.
+	// The tokens consumed on admission are based on the store's estimate of the
+	// fraction of ingested bytes that land in L0, which SnapshotIngestDone
+	// corrects with the bytes that did.
+	h, err := q.Admit(ctx, admission.StoreWriteWorkInfo{
+		WorkInfo: admission.WorkInfo{
+			TenantID:   roachpb.SystemTenantID,
+			Priority:   snapshotAdmissionPriority(priority),
+			CreateTime: n.timeSource.Now().UnixNano(),
+		},
+		WriteBytes:    bytes,
+		IngestRequest: true,
+	})
+	if err != nil {
+		return SnapshotIngestHandle{}, err
+	}
+	return SnapshotIngestHandle{storeAdmissionQ: q, storeWorkHandle: h, bytes: bytes}, nil
+}
+
+// SnapshotIngestDone implements the StoreStatsReporter interface.
+func (n KVAdmissionControllerImpl) SnapshotIngestDone(
+	h SnapshotIngestHandle, ingestedIntoL0Bytes int64,
+) {
+	if h.storeAdmissionQ == nil {
+		return
+	}
+	// The ingested SSTs also contain the range deletions and the range-local
+	// state that are not part of the admitted size of the snapshot, so the
+	// bytes ingested into L0 can exceed it.
+	if ingestedIntoL0Bytes > h.bytes {
+		ingestedIntoL0Bytes = h.bytes
+	}
+	if err := h.storeAdmissionQ.AdmittedWorkDone(h.storeWorkHandle, ingestedIntoL0Bytes); err != nil &&
+		n.metrics != nil {
+		n.metrics.StoreWorkDoneErrors.Inc(1)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"math"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/admission"
+)
+
+// OnStoreAdded implements the StoreStatsReporter interface.
+func (n KVAdmissionControllerImpl) OnStoreAdded(ctx context.Context, metrics admission.StoreMetrics) {
+	if n.kvAdmissionQ == nil {
+		return
+	}
+	n.storeGrantCoords.AddStore(ctx, metrics)
+}
+
+// GetIOOverloadScores implements the StoreStatsReporter interface.
+func (n KVAdmissionControllerImpl) GetIOOverloadScores() map[roachpb.StoreID]admission.IOOverloadScore {
+	if n.kvAdmissionQ == nil {
+		return nil
+	}
+	scores := n.storeGrantCoords.GetIOOverloadScores()
+	res := make(map[roachpb.StoreID]admission.IOOverloadScore, len(scores))
+	for storeID, score := range scores {
+		res[roachpb.StoreID(storeID)] = score
+	}
+	return res
+}
+
+// GetStoreHealth implements the StoreStatsReporter interface.
+func (n KVAdmissionControllerImpl) GetStoreHealth() map[roachpb.StoreID]admission.StoreHealth {
+	if n.kvAdmissionQ == nil {
+		return nil
+	}
+	health := n.storeGrantCoords.GetStoreHealth()
+	res := make(map[roachpb.StoreID]admission.StoreHealth, len(health))
+	for storeID, h := range health {
+		res[roachpb.StoreID(storeID)] = h
+	}
+	return res
+}
+
+// GetCPUOverloadScore implements the StoreStatsReporter interface.
+func (n KVAdmissionControllerImpl) GetCPUOverloadScore() admission.CPUOverloadScore {
+	if n.kvGrantCoord == nil {
+		return admission.CPUOverloadScore{}
+	}
+	return n.kvGrantCoord.GetCPUOverloadScore()
+}
+
+// StoreWritePressure implements the StoreStatsReporter interface.
+func (n KVAdmissionControllerImpl) StoreWritePressure(storeID roachpb.StoreID) float64 {
+	if n.kvAdmissionQ == nil {
+		return 0
+	}
+	score, ok := n.storeGrantCoords.GetIOOverloadScore(int32(storeID))
+	if !ok {
+		return 0
+	}
+	if score.TokensExhausted {
+		return math.Max(score.Score, 1)
+	}
+	return score.Score
+}
+
+// AdmissionPressure implements the StoreStatsReporter interface.
+func (n KVAdmissionControllerImpl) AdmissionPressure(storeID roachpb.StoreID) int32 {
+	return admissionPressurePercent(n.StoreWritePressure(storeID))
+}
+
+// admissionPressurePercent maps a StoreWritePressure to the 0 to 100 scale of
+// BatchResponse.AdmissionPressure. Any pressure of 1 or more, at which writes
+// are being throttled, maps to 100.
+func admissionPressurePercent(pressure float64) int32 {
+	return int32(math.Round(math.Min(math.Max(pressure, 0), 1) * 100))
+}
+
+// RaftLogAppendedBytes implements the StoreStatsReporter interface.
+func (n KVAdmissionControllerImpl) RaftLogAppendedBytes(
+	storeID roachpb.StoreID, tenantID roachpb.TenantID, bytes int64,
+) {
+	if n.kvAdmissionQ == nil {
+		return
+	}
+	if q := n.storeGrantCoords.TryGetQueueForStore(int32(storeID)); q != nil {
+		q.RaftLogAppendedBytes(tenantID, bytes)
+	}
+}
+
+// OnStoreRemoved implements the StoreStatsReporter interface.
+func (n KVAdmissionControllerImpl) OnStoreRemoved(storeID roachpb.StoreID) {
+	if n.kvAdmissionQ == nil {
+		return
+	}
+	n.storeGrantCoords.RemoveStore(int32(storeID))
+	n.appliedWeights.removeStore(storeID)
+	n.snapshotLimiter.removeStore(storeID)
+}
@@ -0,0 +1,152 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+)
+
+// kvAdmissionTenantSystemTableBypass lists the secondary tenants whose
+// requests to the system tables that keep their SQL pods alive bypass
+// admission control. Requests from
+// secondary tenants are otherwise always subject to admission control, which
+// can make a tenant's SQL pods unable to renew their descriptor leases and
+// SQL liveness sessions when KV is overloaded, turning the overload into an
+// outage of the tenant. Since tenants can issue arbitrary requests to their
+// system tables, the bypass must be granted to each tenant explicitly.
+var kvAdmissionTenantSystemTableBypass = settings.RegisterValidatedStringSetting(
+	settings.SystemOnly,
+	"admission.kv.tenant_system_table_bypass.tenants",
+	"comma-separated list of secondary tenant IDs whose KV requests that only touch the "+
+		"tenant's descriptor, namespace, lease and SQL liveness tables bypass admission control",
+	"",
+	func(_ *settings.Values, s string) error {
+		_, err := parseKVAdmissionTenantIDs(s)
+		return err
+	},
+)
+
+// parseKVAdmissionTenantIDs parses a comma-separated list of secondary tenant
+// IDs. A nil map is returned if the list is empty.
+func parseKVAdmissionTenantIDs(s string) (map[roachpb.TenantID]struct{}, error) {
+	var tenants map[roachpb.TenantID]struct{}
+	for _, str := range strings.Split(s, ",") {
+		str = strings.TrimSpace(str)
+		if str == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(str, 10, 64)
+		if err != nil || id == 0 || roachpb.IsSystemTenantID(id) {
+			return nil, errors.Errorf("invalid secondary tenant ID %q", str)
+		}
+		if tenants == nil {
+			tenants = make(map[roachpb.TenantID]struct{})
+		}
+		tenants[roachpb.MakeTenantID(id)] = struct{}{}
+	}
+	return tenants, nil
+}
+
+// kvAdmissionTenantBypass caches the parsed value of the
+// admission.kv.tenant_system_table_bypass.tenants setting.
+type kvAdmissionTenantBypass struct {
+	tenants atomic.Value // map[roachpb.TenantID]struct{}
+}
+
+func newKVAdmissionTenantBypass(st *cluster.Settings) *kvAdmissionTenantBypass {
+	b := &kvAdmissionTenantBypass{}
+	update := func(ctx context.Context) {
+		tenants, err := parseKVAdmissionTenantIDs(kvAdmissionTenantSystemTableBypass.Get(&st.SV))
+		if err != nil {
+			// The setting is validated, so this should not happen.
+			log.Warningf(ctx, "ignoring invalid %s: %v", kvAdmissionTenantSystemTableBypass.Key(), err)
+			tenants = nil
+		}
+		b.tenants.Store(tenants)
+	}
+	update(context.Background())
+	kvAdmissionTenantSystemTableBypass.SetOnChange(&st.SV, update)
+	return b
+}
+
+// bypass returns true if the secondary tenant is allowed to bypass admission
+// for requests to its system tables, and all the requests in the batch are
+// to the system tables in tenantBypassSystemTableIDs.
+func (b *kvAdmissionTenantBypass) bypass(tenantID roachpb.TenantID, ba *roachpb.BatchRequest) bool {
+	tenants := b.tenants.Load().(map[roachpb.TenantID]struct{})
+	if _, ok := tenants[tenantID]; !ok {
+		return false
+	}
+	return isTenantSystemTableBatch(tenantID, ba)
+}
+
+// tenantBypassSystemTableIDs are the system tables of a tenant whose requests
+// can bypass admission control. They are the tables that the SQL pods of the
+// tenant need to access to keep serving existing sessions.
+var tenantBypassSystemTableIDs = []uint32{
+	keys.DescriptorTableID,
+	keys.NamespaceTableID,
+	keys.LeaseTableID,
+	keys.SqllivenessID,
+}
+
+// isTenantSystemTableBatch returns true if each of the requests in the batch
+// is to one of the tenantBypassSystemTableIDs tables of the tenant.
+func isTenantSystemTableBatch(tenantID roachpb.TenantID, ba *roachpb.BatchRequest) bool {
+	if len(ba.Requests) == 0 || ba.IsAdmin() {
+		return false
+	}
+	codec := keys.MakeSQLCodec(tenantID)
+	for _, ru := range ba.Requests {
+		span := ru.GetInner().Header().Span()
+		var ok bool
+		for _, id := range tenantBypassSystemTableIDs {
+			table := codec.TablePrefix(id)
+			if (roachpb.Span{Key: table, EndKey: table.PrefixEnd()}).Contains(span) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// AccountTenantBypassWrite implements the StoreStatsReporter interface.
+//
+// The bytes are charged the way AccountFollowerApplication charges the bytes
+// applied by followers, whose accounting also covers these batches, since
+// they are not marked as bypassing admission in their RaftCommand.
+func (n KVAdmissionControllerImpl) AccountTenantBypassWrite(
+	storeID roachpb.StoreID,
+	tenantID roachpb.TenantID,
+	ba *roachpb.BatchRequest,
+	writeBytes int64,
+) {
+	if n.storeQueues == nil || writeBytes <= 0 || !n.tenantBypass.bypass(tenantID, ba) {
+		return
+	}
+	if q := n.storeQueues.queueForStore(storeID); q != nil {
+		q.FollowerAppliedBytes(tenantID, writeBytes)
+	}
+}
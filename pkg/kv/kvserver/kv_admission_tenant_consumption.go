@@ -0,0 +1,113 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"math"
+	"sync/atomic"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// tenantWeightsConsumptionBasedEnabled controls whether the node level tenant
+// weights are derived from the recent consumption of each tenant, rather than
+// from the TenantWeightProvider.
+var tenantWeightsConsumptionBasedEnabled = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"admission.kv.tenant_weights.consumption_based.enabled",
+	"when true, node level tenant weights for KV admission control are derived from the "+
+		"recent number of requests admitted for each tenant",
+	false,
+)
+
+const (
+	// tenantConsumptionAlpha is the weight given to the most recent interval
+	// in the exponentially weighted moving average of admitted work.
+	tenantConsumptionAlpha = 0.5
+	// tenantConsumptionMinAvg is the moving average below which a tenant is no
+	// longer tracked.
+	tenantConsumptionMinAvg = 0.5
+	// tenantConsumptionMaxWeight is the weight given to the tenant with the
+	// least consumption. It matches the maximum weight used by the
+	// admission.WorkQueue.
+	tenantConsumptionMaxWeight = 20
+)
+
+// tenantConsumption tracks the work admitted for each tenant, to derive
+// tenant weights from.
+type tenantConsumption struct {
+	mu struct {
+		syncutil.RWMutex
+		tenants map[roachpb.TenantID]*tenantConsumptionStats
+	}
+}
+
+type tenantConsumptionStats struct {
+	// admitted is the number of requests admitted since the last call to
+	// computeWeights. Accessed atomically.
+	admitted int64
+	// avg is the moving average of admitted, and is protected by
+	// tenantConsumption.mu.
+	avg float64
+}
+
+func newTenantConsumption() *tenantConsumption {
+	tc := &tenantConsumption{}
+	tc.mu.tenants = make(map[roachpb.TenantID]*tenantConsumptionStats)
+	return tc
+}
+
+// onAdmitted is called when a request from the tenant is admitted.
+func (tc *tenantConsumption) onAdmitted(tenantID roachpb.TenantID) {
+	tc.mu.RLock()
+	stats, ok := tc.mu.tenants[tenantID]
+	tc.mu.RUnlock()
+	if !ok {
+		tc.mu.Lock()
+		if stats, ok = tc.mu.tenants[tenantID]; !ok {
+			stats = &tenantConsumptionStats{}
+			tc.mu.tenants[tenantID] = stats
+		}
+		tc.mu.Unlock()
+	}
+	atomic.AddInt64(&stats.admitted, 1)
+}
+
+// computeWeights updates the moving averages with the work admitted since
+// the last call, and returns weights inversely proportional to them, in the
+// range [1, tenantConsumptionMaxWeight].
+//
+// The admission.WorkQueue orders tenants by their usage divided by their
+// weight, so a tenant with more consumption gets a lower weight, such that it
+// is ordered after the tenants that consume less, and does not take an
+// outsized share of the admitted work.
+func (tc *tenantConsumption) computeWeights() map[uint64]uint32 {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	minAvg := math.Inf(1)
+	for tenantID, stats := range tc.mu.tenants {
+		admitted := atomic.SwapInt64(&stats.admitted, 0)
+		stats.avg = tenantConsumptionAlpha*float64(admitted) + (1-tenantConsumptionAlpha)*stats.avg
+		if stats.avg < tenantConsumptionMinAvg {
+			delete(tc.mu.tenants, tenantID)
+			continue
+		}
+		minAvg = math.Min(minAvg, stats.avg)
+	}
+	weights := make(map[uint64]uint32, len(tc.mu.tenants))
+	for tenantID, stats := range tc.mu.tenants {
+		weights[tenantID.ToUint64()] =
+			uint32(math.Max(1, math.Round(tenantConsumptionMaxWeight*minAvg/stats.avg)))
+	}
+	return weights
+}
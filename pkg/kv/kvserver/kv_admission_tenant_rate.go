@@ -0,0 +1,203 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/quotapool"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// kvAdmissionTenantRequestRateLimit is an absolute cap on the rate of KV
+// requests from each secondary tenant on a node. Unlike tenant weights, which
+// only control the relative share of each tenant when the node is
+// overloaded, this cap applies even when other tenants are idle.
+var kvAdmissionTenantRequestRateLimit = settings.RegisterFloatSetting(
+	settings.SystemOnly,
+	"admission.kv.tenant_rate_limit.requests_per_second",
+	"the maximum rate of KV requests per secondary tenant on a node; 0 disables the limit",
+	0,
+	settings.NonNegativeFloat,
+)
+
+// kvAdmissionTenantWriteBytesRateLimit is an absolute cap on the rate of KV
+// write bytes from each secondary tenant on a node.
+var kvAdmissionTenantWriteBytesRateLimit = settings.RegisterByteSizeSetting(
+	settings.SystemOnly,
+	"admission.kv.tenant_rate_limit.write_bytes_per_second",
+	"the maximum rate of KV write bytes per secondary tenant on a node; 0 disables the limit",
+	0,
+	settings.NonNegativeInt,
+)
+
+// tenantRateLimitersIdleTimeout is the time after which the rate limiters of
+// a tenant without requests are discarded. The limiters of an idle tenant are
+// full again well before that, unless a large request put them deeply into
+// debt, in which case the remaining debt is forgiven.
+const tenantRateLimitersIdleTimeout = 5 * time.Minute
+
+// kvAdmissionTenantRateLimiter enforces the per-tenant rate limits. Requests
+// that exceed the limit wait before queueing for admission.
+type kvAdmissionTenantRateLimiter struct {
+	settings   *cluster.Settings
+	timeSource timeutil.TimeSource
+	mu         struct {
+		syncutil.Mutex
+		tenants map[roachpb.TenantID]*tenantRateLimiters
+		// lastGC is the last time the idle tenants were discarded.
+		lastGC time.Time
+	}
+}
+
+type tenantRateLimiters struct {
+	requests   *quotapool.RateLimiter
+	writeBytes *quotapool.RateLimiter
+	// rateLimited is the number of requests that had to wait for quota. It is
+	// reset when the limiters of an idle tenant are discarded. Accessed
+	// atomically.
+	rateLimited int64
+	// waiting is the number of requests of the tenant in wait, and lastUsed
+	// the time of the last one. They are protected by
+	// kvAdmissionTenantRateLimiter.mu.
+	waiting  int
+	lastUsed time.Time
+}
+
+func newKVAdmissionTenantRateLimiter(
+	st *cluster.Settings, timeSource timeutil.TimeSource,
+) *kvAdmissionTenantRateLimiter {
+	l := &kvAdmissionTenantRateLimiter{settings: st, timeSource: timeSource}
+	l.mu.tenants = make(map[roachpb.TenantID]*tenantRateLimiters)
+	l.mu.lastGC = timeSource.Now()
+	update := func(ctx context.Context) {
+		requestsRate, requestsBurst := l.requestsLimit()
+		writeBytesRate, writeBytesBurst := l.writeBytesLimit()
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for _, tl := range l.mu.tenants {
+			tl.requests.UpdateLimit(requestsRate, requestsBurst)
+			tl.writeBytes.UpdateLimit(writeBytesRate, writeBytesBurst)
+		}
+	}
+	kvAdmissionTenantRequestRateLimit.SetOnChange(&st.SV, update)
+	kvAdmissionTenantWriteBytesRateLimit.SetOnChange(&st.SV, update)
+	return l
+}
+
+// rateAndBurst returns the rate and burst of a limiter from the value of its
+// setting. The burst allows for one second worth of quota.
+func rateAndBurst(v float64) (quotapool.Limit, int64) {
+	if v == 0 {
+		return quotapool.Limit(math.Inf(1)), 0
+	}
+	return quotapool.Limit(v), int64(math.Max(1, v))
+}
+
+func (l *kvAdmissionTenantRateLimiter) requestsLimit() (quotapool.Limit, int64) {
+	return rateAndBurst(kvAdmissionTenantRequestRateLimit.Get(&l.settings.SV))
+}
+
+func (l *kvAdmissionTenantRateLimiter) writeBytesLimit() (quotapool.Limit, int64) {
+	return rateAndBurst(float64(kvAdmissionTenantWriteBytesRateLimit.Get(&l.settings.SV)))
+}
+
+// wait blocks until the batch from the given tenant is within the rate
+// limits, or ctx is canceled.
+func (l *kvAdmissionTenantRateLimiter) wait(
+	ctx context.Context, tenantID roachpb.TenantID, ba *roachpb.BatchRequest,
+) error {
+	requestsEnabled := kvAdmissionTenantRequestRateLimit.Get(&l.settings.SV) != 0
+	writeBytesEnabled := kvAdmissionTenantWriteBytesRateLimit.Get(&l.settings.SV) != 0 &&
+		ba.IsWrite()
+	if !requestsEnabled && !writeBytesEnabled {
+		return nil
+	}
+	now := l.timeSource.Now()
+	l.mu.Lock()
+	l.maybeGCLocked(now)
+	tl, ok := l.mu.tenants[tenantID]
+	if !ok {
+		requestsRate, requestsBurst := l.requestsLimit()
+		writeBytesRate, writeBytesBurst := l.writeBytesLimit()
+		tl = &tenantRateLimiters{
+			requests: quotapool.NewRateLimiter(
+				fmt.Sprintf("kv-admission-tenant-requests-%s", tenantID), requestsRate, requestsBurst,
+				quotapool.WithTimeSource(l.timeSource)),
+			writeBytes: quotapool.NewRateLimiter(
+				fmt.Sprintf("kv-admission-tenant-write-bytes-%s", tenantID), writeBytesRate, writeBytesBurst,
+				quotapool.WithTimeSource(l.timeSource)),
+		}
+		l.mu.tenants[tenantID] = tl
+	}
+	tl.waiting++
+	tl.lastUsed = now
+	l.mu.Unlock()
+	// Only wait if the quota is not immediately available, to count the
+	// requests that were delayed.
+	waited := false
+	defer func() {
+		if waited {
+			atomic.AddInt64(&tl.rateLimited, 1)
+		}
+		l.mu.Lock()
+		tl.waiting--
+		l.mu.Unlock()
+	}()
+	if requestsEnabled && !tl.requests.AdmitN(1) {
+		waited = true
+		if err := tl.requests.WaitN(ctx, 1); err != nil {
+			return err
+		}
+	}
+	if writeBytesEnabled && !tl.writeBytes.AdmitN(int64(ba.Size())) {
+		waited = true
+		if err := tl.writeBytes.WaitN(ctx, int64(ba.Size())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// maybeGCLocked discards the rate limiters of the tenants that have had no
+// requests for tenantRateLimitersIdleTimeout. The tenants are only scanned once
+// per timeout.
+func (l *kvAdmissionTenantRateLimiter) maybeGCLocked(now time.Time) {
+	if now.Sub(l.mu.lastGC) < tenantRateLimitersIdleTimeout {
+		return
+	}
+	l.mu.lastGC = now
+	for tenantID, tl := range l.mu.tenants {
+		if tl.waiting == 0 && now.Sub(tl.lastUsed) >= tenantRateLimitersIdleTimeout {
+			delete(l.mu.tenants, tenantID)
+		}
+	}
+}
+
+// rateLimited returns the number of requests from the tenant that were
+// delayed by the rate limits since it was last idle.
+func (l *kvAdmissionTenantRateLimiter) rateLimited(tenantID roachpb.TenantID) int64 {
+	l.mu.Lock()
+	tl, ok := l.mu.tenants[tenantID]
+	l.mu.Unlock()
+	if !ok {
+		return 0
+	}
+	return atomic.LoadInt64(&tl.rateLimited)
+}
@@ -0,0 +1,245 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/errors"
+)
+
+// tenantWeightsLocalityOverrides allows operators to override the weight of a
+// tenant on the nodes in a locality. This is useful in geo-distributed
+// clusters to give a tenant a higher share of resources only in its home
+// region.
+var tenantWeightsLocalityOverrides = settings.RegisterValidatedStringSetting(
+	settings.SystemOnly,
+	"admission.kv.tenant_weights.locality_overrides",
+	"semicolon-separated list of locality-scoped tenant weights for KV admission control, "+
+		"of the form <locality>:<tenant ID>=<weight> (e.g. region=us-east1:10=5); where "+
+		"multiple entries match a node's locality, the most specific one applies",
+	"",
+	func(_ *settings.Values, s string) error {
+		_, err := parseTenantWeightsLocalityOverrides(s)
+		return err
+	},
+)
+
+// tenantWeightsPinned allows operators to pin the weight of a tenant,
+// overriding the weight from the TenantWeightProvider and any locality-scoped
+// weight. It is meant for use during incidents, to throttle or boost a single
+// tenant without changing the provider.
+var tenantWeightsPinned = settings.RegisterValidatedStringSetting(
+	settings.SystemOnly,
+	"admission.kv.tenant_weights.pinned",
+	"comma-separated list of tenant weights for KV admission control, of the form "+
+		"<tenant ID>=<weight> (e.g. 10=5,11=1), that override the weights computed for "+
+		"those tenants",
+	"",
+	func(_ *settings.Values, s string) error {
+		_, err := parseTenantWeightsPinned(s)
+		return err
+	},
+)
+
+// parseTenantWeightsPinned parses the value of the
+// admission.kv.tenant_weights.pinned setting.
+func parseTenantWeightsPinned(s string) (map[uint64]uint32, error) {
+	var result map[uint64]uint32
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		tenantID, weight, err := parseTenantWeight(entry)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid entry %q", entry)
+		}
+		if result == nil {
+			result = make(map[uint64]uint32)
+		}
+		result[tenantID] = weight
+	}
+	return result, nil
+}
+
+// parseTenantWeight parses a <tenant ID>=<weight> pair.
+func parseTenantWeight(s string) (tenantID uint64, weight uint32, _ error) {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 {
+		return 0, 0, errors.New("expected <tenant ID>=<weight>")
+	}
+	tenantStr, weightStr := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	tenantID, err := strconv.ParseUint(tenantStr, 10, 64)
+	if err != nil || tenantID == 0 {
+		return 0, 0, errors.Errorf("invalid tenant ID %q", tenantStr)
+	}
+	w, err := strconv.ParseUint(weightStr, 10, 32)
+	if err != nil || w == 0 {
+		return 0, 0, errors.Errorf("invalid weight %q", weightStr)
+	}
+	return tenantID, uint32(w), nil
+}
+
+// parseTenantWeightsLocalityOverrides parses the value of the
+// admission.kv.tenant_weights.locality_overrides setting.
+func parseTenantWeightsLocalityOverrides(s string) ([]TenantWeightsForLocality, error) {
+	var result []TenantWeightsForLocality
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		i := strings.LastIndex(entry, ":")
+		if i < 0 {
+			return nil, errors.Errorf("invalid entry %q: expected <locality>:<tenant ID>=<weight>", entry)
+		}
+		var l roachpb.Locality
+		if err := l.Set(entry[:i]); err != nil {
+			return nil, errors.Wrapf(err, "invalid entry %q", entry)
+		}
+		tenantID, weight, err := parseTenantWeight(entry[i+1:])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid entry %q", entry)
+		}
+		result = append(result, TenantWeightsForLocality{
+			Locality: l,
+			Weights:  map[uint64]uint32{tenantID: weight},
+		})
+	}
+	return result, nil
+}
+
+// resolveLocalityTenantWeights returns the weight of each tenant that has a
+// locality-scoped weight matching the given locality. A locality-scoped
+// weight matches if all its tiers are present in the locality, and the one
+// with the most tiers applies. Among equally specific weights, the last one
+// applies.
+func resolveLocalityTenantWeights(
+	locality roachpb.Locality, localities []TenantWeightsForLocality,
+) map[uint64]uint32 {
+	var weights map[uint64]uint32
+	specificity := make(map[uint64]int)
+	for _, lw := range localities {
+		if !localityContainsTiers(locality, lw.Locality) {
+			continue
+		}
+		for tenantID, weight := range lw.Weights {
+			if s, ok := specificity[tenantID]; ok && s > len(lw.Locality.Tiers) {
+				continue
+			}
+			if weights == nil {
+				weights = make(map[uint64]uint32)
+			}
+			weights[tenantID] = weight
+			specificity[tenantID] = len(lw.Locality.Tiers)
+		}
+	}
+	return weights
+}
+
+// localityContainsTiers returns true if all the tiers of sub are present in
+// l.
+func localityContainsTiers(l roachpb.Locality, sub roachpb.Locality) bool {
+	for _, tier := range sub.Tiers {
+		if v, ok := l.Find(tier.Key); !ok || v != tier.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// applyPinnedTenantWeights overrides the weights of the pinned tenants,
+// returning a copy of weights. A nil weights map, which gives all tenants the
+// same weight, is replaced by one containing only the pinned tenants.
+func applyPinnedTenantWeights(weights, pinned map[uint64]uint32) map[uint64]uint32 {
+	if len(pinned) == 0 {
+		return weights
+	}
+	result := make(map[uint64]uint32, len(weights)+len(pinned))
+	for tenantID, weight := range weights {
+		result[tenantID] = weight
+	}
+	for tenantID, weight := range pinned {
+		result[tenantID] = weight
+	}
+	return result
+}
+
+// tenantWeightsPriorityBands maps tenants into priority bands based on their
+// weight. Tenants in a higher band are always admitted before tenants in a
+// lower band, so that, for example, tenants with a low weight (such as
+// free-tier tenants) cannot delay the queued work of tenants with a high
+// weight. Within a band, tenants share resources in proportion to their
+// weights.
+var tenantWeightsPriorityBands = settings.RegisterValidatedStringSetting(
+	settings.SystemOnly,
+	"admission.kv.tenant_weights.priority_bands",
+	"comma-separated list of increasing tenant weight thresholds for KV admission control "+
+		"(e.g. 10,100); a tenant whose weight is at least the i-th threshold is in priority "+
+		"band i, and tenants in higher bands are always preferred; the system tenant is in "+
+		"the highest band; empty disables priority bands",
+	"",
+	func(_ *settings.Values, s string) error {
+		_, err := parseTenantWeightsPriorityBands(s)
+		return err
+	},
+)
+
+// parseTenantWeightsPriorityBands parses the value of the
+// admission.kv.tenant_weights.priority_bands setting.
+func parseTenantWeightsPriorityBands(s string) ([]uint32, error) {
+	var thresholds []uint32
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		threshold, err := strconv.ParseUint(entry, 10, 32)
+		if err != nil || threshold == 0 {
+			return nil, errors.Errorf("invalid weight threshold %q", entry)
+		}
+		if n := len(thresholds); n > 0 && uint32(threshold) <= thresholds[n-1] {
+			return nil, errors.Errorf("weight thresholds must be increasing: %q", s)
+		}
+		thresholds = append(thresholds, uint32(threshold))
+	}
+	if len(thresholds) > math.MaxUint8 {
+		return nil, errors.Errorf("at most %d weight thresholds can be specified", math.MaxUint8)
+	}
+	return thresholds, nil
+}
+
+// tenantPriorityBands returns the priority band of the tenants in weights,
+// given the increasing weight thresholds of the bands. Tenants in band 0 are
+// omitted, and nil is returned if there are no thresholds. The system tenant
+// is always in the highest band.
+func tenantPriorityBands(weights map[uint64]uint32, thresholds []uint32) map[uint64]uint8 {
+	if len(thresholds) == 0 {
+		return nil
+	}
+	bands := map[uint64]uint8{roachpb.SystemTenantID.ToUint64(): uint8(len(thresholds))}
+	for tenantID, weight := range weights {
+		if roachpb.IsSystemTenantID(tenantID) {
+			continue
+		}
+		band := sort.Search(len(thresholds), func(i int) bool { return thresholds[i] > weight })
+		if band > 0 {
+			bands[tenantID] = uint8(band)
+		}
+	}
+	return bands
+}
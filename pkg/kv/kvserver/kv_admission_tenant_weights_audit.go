@@ -0,0 +1,109 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/log/eventpb"
+)
+
+// tenantWeightsAuditLogEnabled controls whether changes to the weights applied
+// by KV admission control are logged as structured events, which provide an
+// audit trail when investigating fairness regressions.
+var tenantWeightsAuditLogEnabled = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"admission.kv.tenant_weights.audit_log.enabled",
+	"when true, a structured event is logged to the OPS channel whenever the weight "+
+		"applied to a tenant by KV admission control changes",
+	true,
+)
+
+// The sources of a tenant weight, reported in eventpb.TenantWeightChange.
+const (
+	tenantWeightSourceProvider         = "provider"
+	tenantWeightSourceLocalityOverride = "locality_override"
+	tenantWeightSourcePinned           = "pinned"
+	tenantWeightSourceDisabled         = "disabled"
+)
+
+// tenantWeightSources determines where the weight of a tenant came from.
+type tenantWeightSources struct {
+	// disabled is true if tenant weights are disabled for the queue.
+	disabled bool
+	locality map[uint64]uint32
+	pinned   map[uint64]uint32
+}
+
+func (s tenantWeightSources) source(tenantID uint64) string {
+	if s.disabled {
+		return tenantWeightSourceDisabled
+	}
+	if _, ok := s.pinned[tenantID]; ok {
+		return tenantWeightSourcePinned
+	}
+	if _, ok := s.locality[tenantID]; ok {
+		return tenantWeightSourceLocalityOverride
+	}
+	return tenantWeightSourceProvider
+}
+
+// makeTenantWeightChangeEvents returns an event for each tenant whose weight
+// changed from prev by d, in increasing order of tenant ID. storeID is zero
+// for the node-level queue.
+func makeTenantWeightChangeEvents(
+	storeID roachpb.StoreID,
+	prev map[uint64]uint32,
+	d tenantWeightsDelta,
+	sources tenantWeightSources,
+) []*eventpb.TenantWeightChange {
+	events := make([]*eventpb.TenantWeightChange, 0, len(d.updates)+len(d.removals))
+	for tenantID, weight := range d.updates {
+		events = append(events, &eventpb.TenantWeightChange{
+			StoreID:   int32(storeID),
+			TenantID:  tenantID,
+			OldWeight: prev[tenantID],
+			NewWeight: weight,
+			Source:    sources.source(tenantID),
+		})
+	}
+	for _, tenantID := range d.removals {
+		events = append(events, &eventpb.TenantWeightChange{
+			StoreID:   int32(storeID),
+			TenantID:  tenantID,
+			OldWeight: prev[tenantID],
+			Source:    sources.source(tenantID),
+		})
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].TenantID < events[j].TenantID })
+	return events
+}
+
+// logTenantWeightChanges logs the changes made by d to the weights applied to
+// the queue for storeID (zero for the node-level queue), if enabled.
+func (n KVAdmissionControllerImpl) logTenantWeightChanges(
+	ctx context.Context,
+	storeID roachpb.StoreID,
+	prev map[uint64]uint32,
+	d tenantWeightsDelta,
+	sources tenantWeightSources,
+) {
+	if d.empty() || !tenantWeightsAuditLogEnabled.Get(&n.settings.SV) {
+		return
+	}
+	for _, event := range makeTenantWeightChangeEvents(storeID, prev, d, sources) {
+		log.StructuredEvent(ctx, event)
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// tenantWeightsUpdater is implemented by the admission queues that accept
+// incremental tenant weight updates.
+type tenantWeightsUpdater interface {
+	UpdateTenantWeights(updates map[uint64]uint32, removals []uint64)
+}
+
+// tenantWeightsDelta is the change between two tenant ID => weight maps.
+type tenantWeightsDelta struct {
+	// updates contains the tenants that were added or whose weight changed.
+	updates map[uint64]uint32
+	// removals contains the tenants that were removed, in increasing order.
+	removals []uint64
+}
+
+func (d tenantWeightsDelta) empty() bool {
+	return len(d.updates) == 0 && len(d.removals) == 0
+}
+
+// diffTenantWeights returns the delta that transforms prev into next. A nil
+// map is equivalent to an empty one.
+func diffTenantWeights(prev, next map[uint64]uint32) tenantWeightsDelta {
+	var d tenantWeightsDelta
+	for tenantID, weight := range next {
+		if prevWeight, ok := prev[tenantID]; !ok || prevWeight != weight {
+			if d.updates == nil {
+				d.updates = make(map[uint64]uint32)
+			}
+			d.updates[tenantID] = weight
+		}
+	}
+	for tenantID := range prev {
+		if _, ok := next[tenantID]; !ok {
+			d.removals = append(d.removals, tenantID)
+		}
+	}
+	sort.Slice(d.removals, func(i, j int) bool { return d.removals[i] < d.removals[j] })
+	return d
+}
+
+// appliedTenantWeights remembers the tenant weights last applied to the node
+// and store queues, so that only the changes are pushed into the queues when
+// new weights are provided.
+type appliedTenantWeights struct {
+	mu struct {
+		syncutil.Mutex
+		node   map[uint64]uint32
+		stores map[roachpb.StoreID]map[uint64]uint32
+	}
+}
+
+// applyNode applies the delta from the previously applied node weights to
+// weights, to q. weights is copied, so the caller may reuse it. The
+// previously applied weights and the delta are returned.
+func (a *appliedTenantWeights) applyNode(
+	q tenantWeightsUpdater, weights map[uint64]uint32,
+) (prev map[uint64]uint32, _ tenantWeightsDelta) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	prev = a.mu.node
+	d := applyTenantWeightsDelta(q, prev, weights)
+	a.mu.node = copyTenantWeights(weights)
+	return prev, d
+}
+
+// applyStore applies the delta from the previously applied weights for the
+// store to weights, to q. weights is copied, so the caller may reuse it. The
+// previously applied weights and the delta are returned.
+func (a *appliedTenantWeights) applyStore(
+	storeID roachpb.StoreID, q tenantWeightsUpdater, weights map[uint64]uint32,
+) (prev map[uint64]uint32, _ tenantWeightsDelta) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.mu.stores == nil {
+		a.mu.stores = make(map[roachpb.StoreID]map[uint64]uint32)
+	}
+	prev = a.mu.stores[storeID]
+	d := applyTenantWeightsDelta(q, prev, weights)
+	a.mu.stores[storeID] = copyTenantWeights(weights)
+	return prev, d
+}
+
+// removeStore forgets the weights applied to a store that was removed, so
+// that they are applied in full if the store is added again.
+func (a *appliedTenantWeights) removeStore(storeID roachpb.StoreID) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.mu.stores, storeID)
+}
+
+func applyTenantWeightsDelta(
+	q tenantWeightsUpdater, prev, next map[uint64]uint32,
+) tenantWeightsDelta {
+	d := diffTenantWeights(prev, next)
+	if !d.empty() {
+		q.UpdateTenantWeights(d.updates, d.removals)
+	}
+	return d
+}
+
+func copyTenantWeights(weights map[uint64]uint32) map[uint64]uint32 {
+	if weights == nil {
+		return nil
+	}
+	c := make(map[uint64]uint32, len(weights))
+	for k, v := range weights {
+		c[k] = v
+	}
+	return c
+}
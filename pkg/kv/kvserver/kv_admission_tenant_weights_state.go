@@ -0,0 +1,99 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/admission"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// TenantWeightsState describes the tenant weights currently applied by the
+// KVAdmissionController, and the share of each queue used by each tenant. It
+// allows operators to verify that weight changes have propagated.
+type TenantWeightsState struct {
+	// LastRefresh is the time at which tenant weights were last applied. It
+	// is zero if weights have never been applied.
+	LastRefresh time.Time
+	// Node contains the node level tenant weights and usage.
+	Node []TenantWeightState
+	// Stores contains the per-store tenant weights and usage.
+	Stores []TenantWeightsStateForStore
+}
+
+// TenantWeightsStateForStore contains the tenant weights and usage for a
+// store.
+type TenantWeightsStateForStore struct {
+	roachpb.StoreID
+	Tenants []TenantWeightState
+}
+
+// TenantWeightState describes the weight and usage of a tenant in a queue.
+type TenantWeightState struct {
+	TenantID roachpb.TenantID
+	// Weight is the weight in effect for the tenant.
+	Weight uint32
+	// Used is the number of KV slots currently used by the tenant, or the
+	// number of store tokens granted to it within the last interval.
+	Used uint64
+	// Share is the fraction of Used over the usage of all tenants in the queue.
+	Share float64
+}
+
+// tenantWeightsRefresh records when tenant weights were last applied, and
+// to which stores.
+type tenantWeightsRefresh struct {
+	mu struct {
+		syncutil.Mutex
+		lastRefresh time.Time
+		storeIDs    []roachpb.StoreID
+	}
+}
+
+func (r *tenantWeightsRefresh) record(now time.Time, stores []TenantWeightsForStore) {
+	storeIDs := make([]roachpb.StoreID, len(stores))
+	for i := range stores {
+		storeIDs[i] = stores[i].StoreID
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mu.lastRefresh = now
+	r.mu.storeIDs = storeIDs
+}
+
+func (r *tenantWeightsRefresh) get() (lastRefresh time.Time, storeIDs []roachpb.StoreID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.mu.lastRefresh, r.mu.storeIDs
+}
+
+// makeTenantWeightStates converts the usage reported by an admission queue,
+// computing the share of each tenant.
+func makeTenantWeightStates(usage []admission.TenantUsage) []TenantWeightState {
+	var total uint64
+	for _, u := range usage {
+		total += u.Used
+	}
+	states := make([]TenantWeightState, len(usage))
+	for i, u := range usage {
+		states[i] = TenantWeightState{
+			TenantID: roachpb.MakeTenantID(u.TenantID),
+			Weight:   u.Weight,
+			Used:     u.Used,
+		}
+		if total > 0 {
+			states[i].Share = float64(u.Used) / float64(total)
+		}
+	}
+	return states
+}
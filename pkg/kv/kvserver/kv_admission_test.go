@@ -0,0 +1,1619 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+	"github.com/cockroachdb/cockroach/pkg/multitenant"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/admission"
+	"github.com/cockroachdb/cockroach/pkg/util/admission/admissionpb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/log/eventpb"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/pebble"
+	prometheusgo "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+type testTenantWeightProvider struct {
+	polled  chan struct{}
+	changed chan struct{}
+	mu      struct {
+		syncutil.Mutex
+		err error
+	}
+}
+
+var _ TenantWeightChangeNotifier = &testTenantWeightProvider{}
+
+func (p *testTenantWeightProvider) GetTenantWeights(context.Context) (TenantWeights, error) {
+	select {
+	case p.polled <- struct{}{}:
+	default:
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.mu.err != nil {
+		return TenantWeights{}, p.mu.err
+	}
+	return TenantWeights{Node: map[uint64]uint32{2: 5}}, nil
+}
+
+func (p *testTenantWeightProvider) TenantWeightsChanged() <-chan struct{} {
+	return p.changed
+}
+
+func (p *testTenantWeightProvider) setErr(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.mu.err = err
+}
+
+// tickerObservingTime is a manual clock that reports the interval of each
+// ticker it creates, once the ticker is created and whenever it is reset.
+type tickerObservingTime struct {
+	*timeutil.ManualTime
+	intervalC chan time.Duration
+}
+
+func (t tickerObservingTime) NewTicker(d time.Duration) timeutil.TickerI {
+	ticker := observedTicker{TickerI: t.ManualTime.NewTicker(d), intervalC: t.intervalC}
+	t.intervalC <- d
+	return ticker
+}
+
+type observedTicker struct {
+	timeutil.TickerI
+	intervalC chan<- time.Duration
+}
+
+func (t observedTicker) Reset(d time.Duration) {
+	t.TickerI.Reset(d)
+	t.intervalC <- d
+}
+
+// TestKVAdmissionControllerTenantWeightPolling uses a manual clock to verify
+// that the tenant weights are polled periodically, when the provider notifies
+// of a change, and when the pinned weights change, that a change to the poll
+// interval takes effect immediately, and that the last weights returned by
+// the provider continue to be used when it fails.
+func TestKVAdmissionControllerTenantWeightPolling(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	admission.KVTenantWeightsEnabled.Override(ctx, &st.SV, true)
+	tenantWeightsPollInterval.Override(ctx, &st.SV, time.Hour)
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+
+	mt := tickerObservingTime{
+		ManualTime: timeutil.NewManualTime(timeutil.Unix(0, 0)),
+		intervalC:  make(chan time.Duration, 1),
+	}
+	metrics := MakeKVAdmissionMetrics(st, time.Minute)
+	ac, gcoords := newTestKVAdmissionController(st, metrics, mt, nil /* knobs */)
+	defer gcoords.Close()
+	provider := &testTenantWeightProvider{
+		polled:  make(chan struct{}, 1),
+		changed: make(chan struct{}),
+	}
+	ac.SetTenantWeightProvider(provider, stopper)
+	// Wait for the polling loop to create its ticker, so that advancing the
+	// clock fires it.
+	require.Equal(t, time.Hour, <-mt.intervalC)
+
+	// waitForWeights waits until the given weights are applied at the current
+	// time. The clock must not be advanced before then, since the provider
+	// call could otherwise time out.
+	waitForWeights := func(expected ...TenantWeightState) {
+		t.Helper()
+		testutils.SucceedsSoon(t, func() error {
+			state := ac.GetTenantWeightsState()
+			if !state.LastRefresh.Equal(mt.Now()) {
+				return errors.Errorf("weights last applied at %s", state.LastRefresh)
+			}
+			if !reflect.DeepEqual(expected, state.Node) {
+				return errors.Errorf("unexpected weights %+v", state.Node)
+			}
+			return nil
+		})
+	}
+	// Until the provider first returns weights, the staleness reports that
+	// there are none yet.
+	provider.setErr(errors.New("boom"))
+	mt.Advance(time.Hour)
+	<-provider.polled
+	testutils.SucceedsSoon(t, func() error {
+		if v := metrics.TenantWeightsStaleness.Value(); v != -1 {
+			return errors.Errorf("staleness %d", v)
+		}
+		return nil
+	})
+	provider.setErr(nil)
+
+	tenant2 := TenantWeightState{TenantID: roachpb.MakeTenantID(2), Weight: 5}
+	for i := 0; i < 3; i++ {
+		mt.Advance(time.Hour)
+		<-provider.polled
+		waitForWeights(tenant2)
+	}
+
+	// A change notification triggers a poll without advancing the clock.
+	provider.changed <- struct{}{}
+	<-provider.polled
+	waitForWeights(tenant2)
+
+	// So does a change to the pinned weights, which are applied immediately.
+	tenantWeightsPinned.Override(ctx, &st.SV, "3=7")
+	<-provider.polled
+	tenant3 := TenantWeightState{TenantID: roachpb.MakeTenantID(3), Weight: 7}
+	waitForWeights(tenant2, tenant3)
+
+	// A change to the poll interval takes effect without waiting for the
+	// current interval to elapse.
+	tenantWeightsPollInterval.Override(ctx, &st.SV, 10*time.Second)
+	require.Equal(t, 10*time.Second, <-mt.intervalC)
+	mt.Advance(10 * time.Second)
+	<-provider.polled
+	waitForWeights(tenant2, tenant3)
+
+	// When the provider fails, the last weights it returned are used.
+	provider.setErr(errors.New("boom"))
+	mt.Advance(10 * time.Second)
+	<-provider.polled
+	waitForWeights(tenant2, tenant3)
+	require.Equal(t, int64(2), metrics.TenantWeightsProviderErrors.Count())
+	require.Equal(t, (10 * time.Second).Nanoseconds(), metrics.TenantWeightsStaleness.Value())
+}
+
+type funcTenantWeightProvider func(context.Context) (TenantWeights, error)
+
+func (f funcTenantWeightProvider) GetTenantWeights(ctx context.Context) (TenantWeights, error) {
+	return f(ctx)
+}
+
+// TestKVAdmissionControllerTenantWeightProviderFailure verifies that errors,
+// panics and hangs in the provider are returned as errors, and that the
+// provider's context is canceled when it hangs.
+func TestKVAdmissionControllerTenantWeightProviderFailure(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+
+	mt := timeutil.NewManualTime(timeutil.Unix(0, 0))
+	metrics := MakeKVAdmissionMetrics(st, time.Minute)
+	ac, gcoords := newTestKVAdmissionController(st, metrics, mt, nil /* knobs */)
+	defer gcoords.Close()
+	var inFlight int32
+
+	_, err := ac.getProviderTenantWeights(ctx, funcTenantWeightProvider(func(context.Context) (TenantWeights, error) {
+		return TenantWeights{}, errors.New("boom")
+	}), &inFlight, stopper)
+	require.EqualError(t, err, "boom")
+
+	_, err = ac.getProviderTenantWeights(ctx, funcTenantWeightProvider(func(context.Context) (TenantWeights, error) {
+		panic("boom")
+	}), &inFlight, stopper)
+	require.Regexp(t, "panicked: boom", err)
+	require.Equal(t, int64(2), metrics.TenantWeightsProviderLatency.TotalCount())
+
+	unblockC := make(chan struct{})
+	canceledC := make(chan struct{})
+	hung := funcTenantWeightProvider(func(ctx context.Context) (TenantWeights, error) {
+		select {
+		case <-ctx.Done():
+			close(canceledC)
+		case <-unblockC:
+		}
+		<-unblockC
+		return TenantWeights{Node: map[uint64]uint32{2: 5}}, nil
+	})
+	errC := make(chan error, 1)
+	go func() {
+		_, err := ac.getProviderTenantWeights(ctx, hung, &inFlight, stopper)
+		errC <- err
+	}()
+	// The goroutine may not have created its timer yet, so keep advancing the
+	// clock until the call times out.
+	testutils.SucceedsSoon(t, func() error {
+		mt.Advance(time.Minute)
+		select {
+		case err := <-errC:
+			require.Regexp(t, "did not return within", err)
+			return nil
+		default:
+			return errors.New("provider call has not timed out")
+		}
+	})
+	// The provider's context is canceled once the call times out.
+	<-canceledC
+	// The provider is not called again while the previous call is hung.
+	_, err = ac.getProviderTenantWeights(ctx, hung, &inFlight, stopper)
+	require.Regexp(t, "has not returned", err)
+
+	close(unblockC)
+	testutils.SucceedsSoon(t, func() error {
+		weights, err := ac.getProviderTenantWeights(ctx, funcTenantWeightProvider(
+			func(context.Context) (TenantWeights, error) {
+				return TenantWeights{Node: map[uint64]uint32{2: 5}}, nil
+			}), &inFlight, stopper)
+		if err != nil {
+			return err
+		}
+		require.Equal(t, map[uint64]uint32{2: 5}, weights.Node)
+		return nil
+	})
+}
+
+func TestKVAdmissionControllerTenantWeightsState(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	admission.KVTenantWeightsEnabled.Override(ctx, &st.SV, true)
+
+	mt := timeutil.NewManualTime(timeutil.Unix(100, 0))
+	ac, gcoords := newTestKVAdmissionController(st, nil /* metrics */, mt, nil /* knobs */)
+	defer gcoords.Close()
+	require.Equal(t, TenantWeightsState{Node: []TenantWeightState{}}, ac.GetTenantWeightsState())
+
+	ac.SetTenantWeights(TenantWeights{Node: map[uint64]uint32{2: 5, 3: 1}})
+	require.Equal(t, TenantWeightsState{
+		LastRefresh: mt.Now(),
+		Node: []TenantWeightState{
+			{TenantID: roachpb.MakeTenantID(2), Weight: 5},
+			{TenantID: roachpb.MakeTenantID(3), Weight: 1},
+		},
+	}, ac.GetTenantWeightsState())
+
+	// Locality overrides apply even when there are no node weights.
+	tenantWeightsLocalityOverrides.Override(ctx, &st.SV, "region=us-east1:10=5")
+	var locality roachpb.Locality
+	require.NoError(t, locality.Set("region=us-east1,zone=a"))
+	ac.SetTenantWeights(TenantWeights{Locality: locality})
+	require.Contains(t, ac.GetTenantWeightsState().Node,
+		TenantWeightState{TenantID: roachpb.MakeTenantID(10), Weight: 5})
+
+	require.Equal(t, []TenantWeightState{
+		{TenantID: roachpb.MakeTenantID(2), Weight: 5, Used: 3, Share: 0.75},
+		{TenantID: roachpb.MakeTenantID(3), Weight: 1, Used: 1, Share: 0.25},
+	}, makeTenantWeightStates([]admission.TenantUsage{
+		{TenantID: 2, Weight: 5, Used: 3},
+		{TenantID: 3, Weight: 1, Used: 1},
+	}))
+}
+
+func TestKVAdmissionControllerTenantAdmissionStats(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+
+	mt := timeutil.NewManualTime(timeutil.Unix(0, 0))
+	ac, gcoords := newTestKVAdmissionController(
+		st, MakeKVAdmissionMetrics(st, time.Minute), mt, nil /* knobs */)
+	defer gcoords.Close()
+	tenantID := roachpb.MakeTenantID(10)
+	require.Equal(t, roachpb.TenantAdmissionStats{}, ac.GetTenantAdmissionStats(tenantID))
+
+	// Allow one request per second, so that the second request below is rate
+	// limited, and gives up waiting since its context is canceled.
+	kvAdmissionTenantRequestRateLimit.Override(ctx, &st.SV, 1)
+	var ba roachpb.BatchRequest
+	ba.Add(roachpb.NewGet(keys.MakeSQLCodec(tenantID).TablePrefix(100), false /* forUpdate */))
+	handle, err := ac.AdmitKVWork(ctx, tenantID, &ba)
+	require.NoError(t, err)
+	var br roachpb.BatchResponse
+	br.Add(&roachpb.GetResponse{
+		ResponseHeader: roachpb.ResponseHeader{NumKeys: 1, NumBytes: 100}})
+	ac.AdmittedKVWorkDone(handle, &br)
+	require.Equal(t, int64(1), ac.metrics.TenantReadKeys.Count())
+	require.Equal(t, int64(100), ac.metrics.TenantReadBytes.Count())
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = ac.AdmitKVWork(canceledCtx, tenantID, &ba)
+	require.Error(t, err)
+
+	require.Equal(t, roachpb.TenantAdmissionStats{
+		Admitted:    1,
+		Rejected:    1,
+		RateLimited: 1,
+	}, ac.GetTenantAdmissionStats(tenantID))
+	// Other tenants are unaffected.
+	require.Equal(t, roachpb.TenantAdmissionStats{},
+		ac.GetTenantAdmissionStats(roachpb.MakeTenantID(11)))
+}
+
+// TestKVAdmissionMetricsTenantCap verifies that per-tenant admission metrics
+// are tracked individually up to admission.kv.tenant_metrics.max_tenants, and
+// that the tenants beyond the limit share the "other" child metrics.
+func TestKVAdmissionMetricsTenantCap(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	kvAdmissionTenantMetricsMaxTenants.Override(ctx, &st.SV, 2)
+	m := MakeKVAdmissionMetrics(st, time.Minute)
+
+	t10 := m.forTenant(roachpb.MakeTenantID(10))
+	t11 := m.forTenant(roachpb.MakeTenantID(11))
+	require.NotSame(t, t10, t11)
+	require.Same(t, t10, m.forTenant(roachpb.MakeTenantID(10)))
+	// The limit is reached, so the remaining tenants share the "other" child
+	// metrics and are not tracked individually.
+	t12 := m.forTenant(roachpb.MakeTenantID(12))
+	t13 := m.forTenant(roachpb.MakeTenantID(13))
+	require.Same(t, t12, t13)
+	require.NotSame(t, t10, t12)
+	require.NotSame(t, t11, t12)
+	_, ok := m.lookupTenant(roachpb.MakeTenantID(12))
+	require.False(t, ok)
+	tm, ok := m.lookupTenant(roachpb.MakeTenantID(11))
+	require.True(t, ok)
+	require.Same(t, t11, tm)
+
+	t10.onAdmitEnd(time.Second, true /* admitted */)
+	t11.onAdmitEnd(time.Second, true /* admitted */)
+	t11.onAdmitEnd(time.Second, false /* admitted */)
+	t12.onAdmitEnd(time.Second, true /* admitted */)
+	t13.onAdmitEnd(time.Second, true /* admitted */)
+	require.Equal(t, int64(4), m.TenantAdmitted.Count())
+	require.Equal(t, int64(1), m.TenantRejected.Count())
+
+	admitted := make(map[string]float64)
+	m.TenantAdmitted.Each(nil, func(pm *prometheusgo.Metric) {
+		require.Len(t, pm.Label, 1)
+		require.Equal(t, multitenant.TenantIDLabel, pm.Label[0].GetName())
+		admitted[pm.Label[0].GetValue()] = pm.Counter.GetValue()
+	})
+	require.Equal(t, map[string]float64{
+		"10":              1,
+		"11":              1,
+		otherTenantsLabel: 2,
+	}, admitted)
+
+	// Raising the limit lets new tenants be tracked individually again, while
+	// the "other" child metrics keep what they accumulated.
+	kvAdmissionTenantMetricsMaxTenants.Override(ctx, &st.SV, 3)
+	t14 := m.forTenant(roachpb.MakeTenantID(14))
+	require.NotSame(t, t12, t14)
+	require.Same(t, t12, m.forTenant(roachpb.MakeTenantID(15)))
+	require.Equal(t, int64(2), t12.admitted.Value())
+}
+
+func TestKVAdmissionControllerBypassMetrics(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+
+	metrics := MakeKVAdmissionMetrics(st, time.Minute)
+	ac, gcoords := newTestKVAdmissionController(st, metrics, nil /* timeSource */, nil /* knobs */)
+	defer gcoords.Close()
+	kvAdmissionBypassMethods.Override(ctx, &st.SV, "Get")
+	key := roachpb.Key("a")
+	admit := func(source roachpb.AdmissionHeader_Source, req roachpb.Request) {
+		var ba roachpb.BatchRequest
+		ba.AdmissionHeader.Source = source
+		ba.Add(req)
+		handle, err := ac.AdmitKVWork(ctx, roachpb.SystemTenantID, &ba)
+		require.NoError(t, err)
+		ac.AdmittedKVWorkDone(handle, nil /* br */)
+	}
+	admit(roachpb.AdmissionHeader_OTHER,
+		&roachpb.AdminSplitRequest{RequestHeader: roachpb.RequestHeader{Key: key}})
+	admit(roachpb.AdmissionHeader_OTHER,
+		&roachpb.ScanRequest{RequestHeader: roachpb.RequestHeader{Key: key, EndKey: key.Next()}})
+	admit(roachpb.AdmissionHeader_ROOT_KV, roachpb.NewGet(key, false /* forUpdate */))
+	admit(roachpb.AdmissionHeader_ROOT_KV,
+		&roachpb.HeartbeatTxnRequest{RequestHeader: roachpb.RequestHeader{Key: key}})
+	// Not a bypass.
+	admit(roachpb.AdmissionHeader_ROOT_KV,
+		&roachpb.ScanRequest{RequestHeader: roachpb.RequestHeader{Key: key, EndKey: key.Next()}})
+
+	require.Equal(t, int64(1), metrics.BypassedAdmin.Count())
+	require.Equal(t, int64(1), metrics.BypassedOtherSource.Count())
+	require.Equal(t, int64(1), metrics.BypassedAllowlist.Count())
+	require.Equal(t, int64(0), metrics.BypassedTenant.Count())
+	require.Equal(t, int64(1), metrics.BypassedStoreHeartbeatTxn.Count())
+	// Only the requests that did not bypass admission waited in the queues.
+	require.Equal(t, int64(2), metrics.WaitDurationsNormalPri.TotalCount())
+	require.Zero(t, metrics.WaitDurationsHighPri.TotalCount())
+	require.Zero(t, metrics.WaitDurationsBulkPri.TotalCount())
+	require.Equal(t, metrics.WaitDurationsHighPri, metrics.waitDurationsForPriority(admissionpb.UserHighPri))
+	require.Equal(t, metrics.WaitDurationsNormalPri, metrics.waitDurationsForPriority(admissionpb.BulkNormalPri+1))
+	require.Equal(t, metrics.WaitDurationsBulkPri, metrics.waitDurationsForPriority(admissionpb.BulkNormalPri))
+}
+
+func TestKVAdmissionControllerRebindStoreAdmission(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+
+	metrics := MakeKVAdmissionMetrics(st, time.Minute)
+	ac, gcoords := newTestKVAdmissionController(st, metrics, nil /* timeSource */, nil /* knobs */)
+	defer gcoords.Close()
+	key := roachpb.Key("a")
+	var write roachpb.BatchRequest
+	write.Replica.StoreID = 1
+	write.Add(roachpb.NewPut(key, roachpb.MakeValueFromString("v")))
+	var read roachpb.BatchRequest
+	read.Replica.StoreID = 1
+	read.Add(roachpb.NewGet(key, false /* forUpdate */))
+
+	// Rebinding to the same store, or rebinding work that is not subject to
+	// store admission, is a no-op.
+	handle, err := ac.AdmitKVWork(ctx, roachpb.SystemTenantID, &write)
+	require.NoError(t, err)
+	require.NoError(t, ac.RebindStoreAdmission(ctx, handle, 1))
+	require.Equal(t, roachpb.StoreID(1), handle.StoreID())
+	ac.AdmittedKVWorkDone(handle, nil /* br */)
+	handle, err = ac.AdmitKVWork(ctx, roachpb.SystemTenantID, &read)
+	require.NoError(t, err)
+	require.NoError(t, ac.RebindStoreAdmission(ctx, handle, 2))
+	require.Equal(t, roachpb.StoreID(0), handle.StoreID())
+	ac.AdmittedKVWorkDone(handle, nil /* br */)
+	require.Equal(t, int64(0), metrics.StoreRebinds.Count())
+
+	handle, err = ac.AdmitKVWork(ctx, roachpb.SystemTenantID, &write)
+	require.NoError(t, err)
+	require.NoError(t, ac.RebindStoreAdmission(ctx, handle, 2))
+	require.Equal(t, roachpb.StoreID(2), handle.StoreID())
+	require.Equal(t, int64(1), metrics.StoreRebinds.Count())
+	ac.AdmittedKVWorkDone(handle, nil /* br */)
+	// The handle cannot be rebound once the work is done.
+	if kvAdmissionHandleMisusePanics {
+		require.Panics(t, func() { _ = ac.RebindStoreAdmission(ctx, handle, 3) })
+	} else {
+		require.Error(t, ac.RebindStoreAdmission(ctx, handle, 3))
+	}
+}
+
+func TestKVAdmissionControllerDoubleWorkDone(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+
+	metrics := MakeKVAdmissionMetrics(st, time.Minute)
+	ac, gcoords := newTestKVAdmissionController(st, metrics, nil /* timeSource */, nil /* knobs */)
+	defer gcoords.Close()
+	var ba roachpb.BatchRequest
+	ba.Add(roachpb.NewGet(roachpb.Key("a"), false /* forUpdate */))
+
+	var buf strings.Builder
+	ac.kvQueue = &fakeKVAdmissionQueue{buf: &buf}
+	numDone := func() int { return strings.Count(buf.String(), "kv: done") }
+
+	testutils.RunTrueAndFalse(t, "panics", func(t *testing.T, panics bool) {
+		defer testutils.TestingHook(&kvAdmissionHandleMisusePanics, panics)()
+		buf.Reset()
+		handle, err := ac.AdmitKVWork(ctx, roachpb.SystemTenantID, &ba)
+		require.NoError(t, err)
+		ac.AdmittedKVWorkDone(handle, nil /* br */)
+		require.Equal(t, 1, numDone())
+		// Other work is admitted before the second call, which must not
+		// release its admission.
+		other, err := ac.AdmitKVWork(ctx, roachpb.SystemTenantID, &ba)
+		require.NoError(t, err)
+		require.NotSame(t, handle, other)
+		expDoubleWorkDone := metrics.DoubleWorkDone.Count()
+		if panics {
+			// Test builds panic on the second call.
+			require.Panics(t, func() { ac.AdmittedKVWorkDone(handle, nil /* br */) })
+		} else {
+			// Other builds count the second call, which is otherwise a no-op.
+			require.Zero(t, ac.AdmittedKVWorkDone(handle, nil /* br */))
+			expDoubleWorkDone++
+		}
+		require.Equal(t, expDoubleWorkDone, metrics.DoubleWorkDone.Count())
+		require.Equal(t, 1, numDone())
+		// The other work is still done normally.
+		ac.AdmittedKVWorkDone(other, nil /* br */)
+		require.Equal(t, 2, numDone())
+		require.Equal(t, expDoubleWorkDone, metrics.DoubleWorkDone.Count())
+	})
+}
+
+func TestKVAdmissionControllerStoreLifecycle(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+
+	ac, gcoords := newTestKVAdmissionController(st, nil /* metrics */, nil /* timeSource */, nil /* knobs */)
+	defer gcoords.Close()
+	// Stores added before the store admission is initialized are picked up
+	// from the PebbleMetricsProvider.
+	ac.OnStoreAdded(ctx, admission.StoreMetrics{StoreID: 1, Metrics: &pebble.Metrics{}})
+	require.Nil(t, gcoords.Stores.TryGetQueueForStore(1))
+	require.Empty(t, ac.GetStoreHealth())
+	require.Zero(t, ac.StoreWritePressure(1))
+	require.Zero(t, ac.AdmissionPressure(1))
+	require.Empty(t, ac.GetWaitingRequests())
+
+	snapshotIngestMaxRate.Override(ctx, &st.SV, 1<<20)
+	require.NoError(t, ac.AdmitSnapshotBytes(ctx, 1, 1))
+	ac.appliedWeights.applyStore(1, &recordingTenantWeightsUpdater{}, map[uint64]uint32{2: 3})
+	ac.OnStoreRemoved(1)
+	require.Empty(t, ac.appliedWeights.mu.stores)
+	require.Empty(t, ac.snapshotLimiter.mu.stores)
+}
+
+func TestRangeDeletionWriteBytes(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	span := roachpb.RequestHeader{Key: roachpb.Key("a"), EndKey: roachpb.Key("b")}
+	for _, tc := range []struct {
+		req      roachpb.Request
+		estimate int64
+		expected int64
+	}{
+		{&roachpb.ClearRangeRequest{RequestHeader: span}, 0, 0},
+		{&roachpb.ClearRangeRequest{RequestHeader: span}, 1 << 20, 1 << 20},
+		{&roachpb.DeleteRangeRequest{RequestHeader: span}, 1 << 20, 1 << 20},
+		// The estimate is ignored for other requests.
+		{roachpb.NewPut(span.Key, roachpb.MakeValueFromString("v")), 1 << 20, 0},
+	} {
+		var ba roachpb.BatchRequest
+		ba.Add(tc.req)
+		ba.AdmissionHeader.EstimatedWriteBytes = tc.estimate
+		require.Equal(t, tc.expected, rangeDeletionWriteBytes(&ba), "%s", tc.req.Method())
+	}
+}
+
+func TestKVAdmissionControllerSnapshotIngest(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+
+	metrics := MakeKVAdmissionMetrics(st, time.Minute)
+	ac, gcoords := newTestKVAdmissionController(st, metrics, nil /* timeSource */, nil /* knobs */)
+	defer gcoords.Close()
+	var buf strings.Builder
+	ac.storeQueues = fakeStoreAdmissionQueues{
+		1: &fakeStoreAdmissionQueue{storeID: 1, buf: &buf},
+	}
+	// Snapshots are admitted without waiting when pacing is disabled, and when
+	// the store has no admission queue.
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	h, err := ac.AdmitSnapshotIngest(canceledCtx, 1, kvserverpb.SnapshotRequest_REBALANCE, 1<<20)
+	require.NoError(t, err)
+	ac.SnapshotIngestDone(h, 1<<10)
+	snapshotIngestPacingEnabled.Override(ctx, &st.SV, true)
+	h, err = ac.AdmitSnapshotIngest(canceledCtx, 2, kvserverpb.SnapshotRequest_REBALANCE, 1<<20)
+	require.NoError(t, err)
+	ac.SnapshotIngestDone(h, 1<<10)
+	require.Empty(t, buf.String())
+
+	// The admission of a snapshot is done with the bytes ingested into L0,
+	// which are bounded by the admitted size of the snapshot.
+	h, err = ac.AdmitSnapshotIngest(ctx, 1, kvserverpb.SnapshotRequest_RECOVERY, 1000)
+	require.NoError(t, err)
+	ac.SnapshotIngestDone(h, 400)
+	h, err = ac.AdmitSnapshotIngest(ctx, 1, kvserverpb.SnapshotRequest_REBALANCE, 1000)
+	require.NoError(t, err)
+	ac.SnapshotIngestDone(h, 1500)
+	require.Equal(t, `s1: admit tenant=1 pri=0 bypass=false
+s1: done l0=400
+s1: admit tenant=1 pri=-30 bypass=false
+s1: done l0=1000
+`, buf.String())
+	require.Zero(t, metrics.StoreWorkDoneErrors.Count())
+
+	require.Equal(t, admissionpb.BulkNormalPri,
+		snapshotAdmissionPriority(kvserverpb.SnapshotRequest_REBALANCE))
+	require.Equal(t, admissionpb.NormalPri,
+		snapshotAdmissionPriority(kvserverpb.SnapshotRequest_RECOVERY))
+}
+
+func TestKVAdmissionControllerSnapshotBytes(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+
+	mt := timeutil.NewManualTime(timeutil.Unix(0, 0))
+	ac, gcoords := newTestKVAdmissionController(st, nil /* metrics */, mt, nil /* knobs */)
+	defer gcoords.Close()
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	// Without a budget, snapshot bytes are never limited.
+	require.NoError(t, ac.AdmitSnapshotBytes(canceledCtx, 1, 1<<30))
+
+	// The budget is tracked separately for each store, and the burst allows
+	// one second worth of bytes.
+	snapshotIngestMaxRate.Override(ctx, &st.SV, 1<<20)
+	require.NoError(t, ac.AdmitSnapshotBytes(ctx, 1, 1<<20))
+	require.NoError(t, ac.AdmitSnapshotBytes(ctx, 2, 1<<20))
+	require.Error(t, ac.AdmitSnapshotBytes(canceledCtx, 1, 1<<20))
+	// The budget is refilled as the time of the controller passes.
+	mt.Advance(time.Second / 2)
+	require.Error(t, ac.AdmitSnapshotBytes(canceledCtx, 1, 1<<20))
+	mt.Advance(time.Second / 2)
+	require.NoError(t, ac.AdmitSnapshotBytes(canceledCtx, 1, 1<<20))
+
+	// Disabling the budget takes effect immediately.
+	snapshotIngestMaxRate.Override(ctx, &st.SV, 0)
+	require.NoError(t, ac.AdmitSnapshotBytes(canceledCtx, 1, 1<<30))
+}
+
+func TestKVAdmissionRangefeedLimiter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	l := newKVAdmissionRangefeedLimiter(st)
+	tenantID, otherTenantID := roachpb.MakeTenantID(5), roachpb.MakeTenantID(6)
+	numTenants := func() int {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return len(l.mu.tenants)
+	}
+
+	// Catch-up scans are not limited by default.
+	release, err := l.begin(ctx, tenantID)
+	require.NoError(t, err)
+	release()
+	require.Zero(t, numTenants())
+
+	kvAdmissionRangefeedCatchUpScansPerTenant.Override(ctx, &st.SV, 2)
+	release1, err := l.begin(ctx, tenantID)
+	require.NoError(t, err)
+	release2, err := l.begin(ctx, tenantID)
+	require.NoError(t, err)
+
+	// A third catch-up scan of the tenant queues at the limit, while the
+	// catch-up scans of other tenants do not.
+	type result struct {
+		release func()
+		err     error
+	}
+	queued := make(chan result, 1)
+	go func() {
+		release, err := l.begin(ctx, tenantID)
+		queued <- result{release, err}
+	}()
+	select {
+	case <-queued:
+		t.Fatal("catch-up scan was not queued at the limit")
+	case <-time.After(10 * time.Millisecond):
+	}
+	releaseOther, err := l.begin(ctx, otherTenantID)
+	require.NoError(t, err)
+	releaseOther()
+	require.Equal(t, 1, numTenants())
+
+	// A queued catch-up scan that is canceled gives up its place.
+	canceledCtx, cancel := context.WithCancel(ctx)
+	canceled := make(chan error, 1)
+	go func() {
+		_, err := l.begin(canceledCtx, tenantID)
+		canceled <- err
+	}()
+	cancel()
+	require.Error(t, <-canceled)
+
+	// The queued catch-up scan starts once a running one completes.
+	release1()
+	r := <-queued
+	require.NoError(t, r.err)
+
+	// The semaphore of the tenant is discarded once its catch-up scans are
+	// done.
+	release2()
+	require.Equal(t, 1, numTenants())
+	r.release()
+	require.Zero(t, numTenants())
+}
+
+func TestKVAdmissionTenantRateLimiter(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	manual := timeutil.NewManualTime(timeutil.Unix(0, 0))
+	l := newKVAdmissionTenantRateLimiter(st, manual)
+	tenantID, otherTenantID := roachpb.MakeTenantID(5), roachpb.MakeTenantID(6)
+	read := &roachpb.BatchRequest{}
+	read.Add(roachpb.NewGet(roachpb.Key("a"), false /* forUpdate */))
+	write := func(valueBytes int) *roachpb.BatchRequest {
+		ba := &roachpb.BatchRequest{}
+		ba.Add(roachpb.NewPut(roachpb.Key("a"), roachpb.MakeValueFromBytes(make([]byte, valueBytes))))
+		return ba
+	}
+	// waitAsync starts waiting for the batch, and returns a channel on which
+	// the result is delivered once the wait is over.
+	waitAsync := func(ba *roachpb.BatchRequest) chan error {
+		ch := make(chan error, 1)
+		go func() { ch <- l.wait(ctx, tenantID, ba) }()
+		return ch
+	}
+	// requireWaiting checks that the wait for the batch is blocked on the
+	// rate limit, and unblocks it by advancing the clock.
+	requireWaiting := func(t *testing.T, ch chan error, d time.Duration) {
+		t.Helper()
+		testutils.SucceedsSoon(t, func() error {
+			if len(manual.Timers()) == 0 {
+				return errors.New("not waiting yet")
+			}
+			return nil
+		})
+		select {
+		case err := <-ch:
+			t.Fatalf("unexpected end of wait: %v", err)
+		default:
+		}
+		manual.Advance(d)
+		require.NoError(t, <-ch)
+	}
+
+	// Nothing is limited by default, and no limiters are created.
+	for i := 0; i < 100; i++ {
+		require.NoError(t, l.wait(ctx, tenantID, write(1000)))
+	}
+	require.Zero(t, l.rateLimited(tenantID))
+	require.Empty(t, l.mu.tenants)
+
+	// The request rate limit has a burst of one second worth of requests.
+	kvAdmissionTenantRequestRateLimit.Override(ctx, &st.SV, 2)
+	require.NoError(t, l.wait(ctx, tenantID, read))
+	require.NoError(t, l.wait(ctx, tenantID, read))
+	require.Zero(t, l.rateLimited(tenantID))
+	requireWaiting(t, waitAsync(read), 500*time.Millisecond)
+	require.Equal(t, int64(1), l.rateLimited(tenantID))
+	// Other tenants have their own limit.
+	require.NoError(t, l.wait(ctx, otherTenantID, read))
+	require.Zero(t, l.rateLimited(otherTenantID))
+	kvAdmissionTenantRequestRateLimit.Override(ctx, &st.SV, 0)
+
+	// A write larger than the burst of the write bytes limit is admitted
+	// without waiting when the limiter is full, and puts it into debt, which
+	// subsequent writes wait out. Reads are not subject to the limit.
+	kvAdmissionTenantWriteBytesRateLimit.Override(ctx, &st.SV, 1000)
+	manual.Advance(time.Second)
+	large := write(1500)
+	require.NoError(t, l.wait(ctx, tenantID, large))
+	require.Equal(t, int64(1), l.rateLimited(tenantID))
+	require.NoError(t, l.wait(ctx, tenantID, read))
+	small := write(10)
+	debtAndSmall := time.Duration(float64(large.Size()-1000+small.Size()) / 1000 * float64(time.Second))
+	requireWaiting(t, waitAsync(small), debtAndSmall+time.Millisecond)
+	require.Equal(t, int64(2), l.rateLimited(tenantID))
+
+	// A wait that is canceled fails.
+	manual.Advance(time.Second)
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	require.NoError(t, l.wait(canceledCtx, tenantID, write(500)))
+	require.Error(t, l.wait(canceledCtx, tenantID, write(500)))
+
+	// The limiters of tenants that are idle are discarded.
+	manual.Advance(tenantRateLimitersIdleTimeout)
+	require.NoError(t, l.wait(ctx, otherTenantID, write(10)))
+	require.Len(t, l.mu.tenants, 1)
+	require.Zero(t, l.rateLimited(tenantID))
+	require.NotNil(t, l.mu.tenants[otherTenantID])
+}
+
+func TestWriteAmpFeedback(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	var s writeAmpState
+	// The first sample only initializes the cumulative counts.
+	require.Equal(t, 0.0, s.update(100, 1000))
+	require.Equal(t, 10.0, s.update(200, 2000))
+	require.Equal(t, 15.0, s.update(300, 4000))
+	// Intervals without writes are ignored.
+	require.Equal(t, 15.0, s.update(300, 4500))
+
+	require.Equal(t, 1.0, writeAmpTokenMultiplier(5, 10))
+	require.Equal(t, 1.5, writeAmpTokenMultiplier(15, 10))
+	require.Equal(t, float64(maxWriteAmpTokenMultiplier), writeAmpTokenMultiplier(100, 10))
+}
+
+func TestAdmissionPressurePercent(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	require.Equal(t, int32(0), admissionPressurePercent(0))
+	require.Equal(t, int32(25), admissionPressurePercent(0.25))
+	require.Equal(t, int32(100), admissionPressurePercent(1))
+	require.Equal(t, int32(100), admissionPressurePercent(3.5))
+}
+
+func TestTenantWeightsLocalityOverrides(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	localities, err := parseTenantWeightsLocalityOverrides(
+		"region=us-east1:10=5; region=us-east1,zone=b:10=7;region=us-west1:11=3")
+	require.NoError(t, err)
+	require.Len(t, localities, 3)
+	for _, invalid := range []string{"region=us-east1", "region=us-east1:10", ":10=5",
+		"region=us-east1:0=5", "region=us-east1:10=0", "region=us-east1:ten=5"} {
+		_, err := parseTenantWeightsLocalityOverrides(invalid)
+		require.Error(t, err, invalid)
+	}
+
+	var zoneA, zoneB, west roachpb.Locality
+	require.NoError(t, zoneA.Set("region=us-east1,zone=a"))
+	require.NoError(t, zoneB.Set("region=us-east1,zone=b"))
+	require.NoError(t, west.Set("region=us-west1,zone=a"))
+	require.Equal(t, map[uint64]uint32{10: 5}, resolveLocalityTenantWeights(zoneA, localities))
+	// The most specific weight applies.
+	require.Equal(t, map[uint64]uint32{10: 7}, resolveLocalityTenantWeights(zoneB, localities))
+	require.Equal(t, map[uint64]uint32{11: 3}, resolveLocalityTenantWeights(west, localities))
+	require.Nil(t, resolveLocalityTenantWeights(roachpb.Locality{}, localities))
+
+	require.Equal(t, map[uint64]uint32{10: 5, 12: 3},
+		applyLocalityTenantWeights(map[uint64]uint32{10: 2, 12: 3}, map[uint64]uint32{10: 5}))
+	// The overrides apply even if the tenants otherwise share the same weight.
+	require.Equal(t, map[uint64]uint32{10: 5},
+		applyLocalityTenantWeights(nil, map[uint64]uint32{10: 5}))
+	require.Nil(t, applyLocalityTenantWeights(nil, nil))
+}
+
+func TestTenantWeightsPinned(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	pinned, err := parseTenantWeightsPinned(" 10=5, 11=1 ")
+	require.NoError(t, err)
+	require.Equal(t, map[uint64]uint32{10: 5, 11: 1}, pinned)
+	empty, err := parseTenantWeightsPinned("")
+	require.NoError(t, err)
+	require.Nil(t, empty)
+	for _, invalid := range []string{"10", "10=", "=5", "0=5", "10=0", "ten=5"} {
+		_, err := parseTenantWeightsPinned(invalid)
+		require.Error(t, err, invalid)
+	}
+
+	require.Equal(t, map[uint64]uint32{10: 5, 11: 1, 12: 3},
+		applyPinnedTenantWeights(map[uint64]uint32{10: 2, 12: 3}, pinned))
+	require.Equal(t, map[uint64]uint32{10: 5, 11: 1}, applyPinnedTenantWeights(nil, pinned))
+	require.Nil(t, applyPinnedTenantWeights(nil, nil))
+}
+
+func TestTenantWeightsPriorityBands(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	thresholds, err := parseTenantWeightsPriorityBands(" 10, 100 ")
+	require.NoError(t, err)
+	require.Equal(t, []uint32{10, 100}, thresholds)
+	empty, err := parseTenantWeightsPriorityBands("")
+	require.NoError(t, err)
+	require.Nil(t, empty)
+	for _, invalid := range []string{"0", "ten", "10,10", "100,10", "-1"} {
+		_, err := parseTenantWeightsPriorityBands(invalid)
+		require.Error(t, err, invalid)
+	}
+
+	require.Nil(t, tenantPriorityBands(map[uint64]uint32{10: 100}, nil))
+	require.Equal(t, map[uint64]uint8{1: 2, 11: 1, 12: 1, 13: 2},
+		tenantPriorityBands(map[uint64]uint32{1: 1, 10: 9, 11: 10, 12: 99, 13: 100}, thresholds))
+	// The system tenant is in the highest band even without a weight.
+	require.Equal(t, map[uint64]uint8{1: 2}, tenantPriorityBands(nil, thresholds))
+}
+
+type recordingTenantWeightsUpdater struct {
+	deltas []tenantWeightsDelta
+}
+
+func (u *recordingTenantWeightsUpdater) UpdateTenantWeights(
+	updates map[uint64]uint32, removals []uint64,
+) {
+	u.deltas = append(u.deltas, tenantWeightsDelta{updates: updates, removals: removals})
+}
+
+func TestTenantWeightsDelta(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	require.True(t, diffTenantWeights(nil, nil).empty())
+	require.True(t, diffTenantWeights(nil, map[uint64]uint32{}).empty())
+	require.Equal(t, tenantWeightsDelta{
+		updates:  map[uint64]uint32{11: 3, 13: 1},
+		removals: []uint64{10, 12},
+	}, diffTenantWeights(
+		map[uint64]uint32{10: 1, 11: 2, 12: 3, 14: 4},
+		map[uint64]uint32{11: 3, 13: 1, 14: 4},
+	))
+
+	// Only the changes are applied to the queues, and the node and each store
+	// are tracked separately.
+	var a appliedTenantWeights
+	var node, store1, store2 recordingTenantWeightsUpdater
+	weights := map[uint64]uint32{10: 1, 11: 2}
+	a.applyNode(&node, weights)
+	a.applyStore(1, &store1, weights)
+	// The caller can mutate weights after applying them.
+	weights[11] = 5
+	a.applyNode(&node, weights)
+	a.applyStore(2, &store2, nil)
+	a.applyStore(1, &store1, nil)
+	require.Equal(t, []tenantWeightsDelta{
+		{updates: map[uint64]uint32{10: 1, 11: 2}},
+		{updates: map[uint64]uint32{11: 5}},
+	}, node.deltas)
+	require.Equal(t, []tenantWeightsDelta{
+		{updates: map[uint64]uint32{10: 1, 11: 2}},
+		{removals: []uint64{10, 11}},
+	}, store1.deltas)
+	require.Nil(t, store2.deltas)
+}
+
+func TestTenantWeightChangeEvents(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	prev := map[uint64]uint32{10: 2, 11: 3, 12: 4}
+	next := map[uint64]uint32{10: 5, 12: 4, 13: 6, 14: 1}
+	sources := tenantWeightSources{
+		locality: map[uint64]uint32{13: 6},
+		pinned:   map[uint64]uint32{14: 1},
+	}
+	require.Equal(t, []*eventpb.TenantWeightChange{
+		{StoreID: 2, TenantID: 10, OldWeight: 2, NewWeight: 5, Source: tenantWeightSourceProvider},
+		{StoreID: 2, TenantID: 11, OldWeight: 3, Source: tenantWeightSourceProvider},
+		{StoreID: 2, TenantID: 13, NewWeight: 6, Source: tenantWeightSourceLocalityOverride},
+		{StoreID: 2, TenantID: 14, NewWeight: 1, Source: tenantWeightSourcePinned},
+	}, makeTenantWeightChangeEvents(2, prev, diffTenantWeights(prev, next), sources))
+
+	sources.disabled = true
+	require.Equal(t, []*eventpb.TenantWeightChange{
+		{TenantID: 10, OldWeight: 2, Source: tenantWeightSourceDisabled},
+		{TenantID: 11, OldWeight: 3, Source: tenantWeightSourceDisabled},
+		{TenantID: 12, OldWeight: 4, Source: tenantWeightSourceDisabled},
+	}, makeTenantWeightChangeEvents(0, prev, diffTenantWeights(prev, nil), sources))
+}
+
+func TestTenantConsumptionWeights(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	tc := newTenantConsumption()
+	admitted := map[uint64]int{}
+	admit := func(tenantID uint64, n int) {
+		admitted[tenantID] += n
+		for i := 0; i < n; i++ {
+			tc.onAdmitted(roachpb.MakeTenantID(tenantID))
+		}
+	}
+	// requireFair checks that a tenant that consumed more is never ordered
+	// before a tenant that consumed less by the admission.WorkQueue, which
+	// orders tenants by their usage divided by their weight.
+	requireFair := func(weights map[uint64]uint32) {
+		for i, wi := range weights {
+			for j, wj := range weights {
+				if admitted[i] > admitted[j] {
+					require.LessOrEqual(t, wi, wj, "weights %v", weights)
+					require.Greater(t, admitted[i]*int(wj), admitted[j]*int(wi),
+						"weights %v", weights)
+				}
+			}
+		}
+		admitted = map[uint64]int{}
+	}
+	admit(2, 100)
+	admit(3, 50)
+	admit(4, 1)
+	weights := tc.computeWeights()
+	require.Equal(t, map[uint64]uint32{2: 1, 3: 1, 4: 20}, weights)
+	requireFair(weights)
+	// Tenants that stop consuming are eventually forgotten.
+	admit(2, 100)
+	admit(3, 1)
+	weights = tc.computeWeights()
+	require.Equal(t, map[uint64]uint32{2: 3, 3: 20}, weights)
+	requireFair(weights)
+	for i := 0; i < 7; i++ {
+		require.NotEmpty(t, tc.computeWeights())
+	}
+	require.Empty(t, tc.computeWeights())
+}
+
+func TestWorkClassPriority(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	h := roachpb.AdmissionHeader{Priority: int32(admissionpb.BulkNormalPri)}
+	require.Equal(t, admissionpb.BulkNormalPri, workClassPriority(&st.SV, h))
+	indexBackfillAdmissionPriority.Override(ctx, &st.SV, int64(admissionpb.NormalPri))
+	h.WorkClass = roachpb.AdmissionHeader_INDEX_BACKFILL
+	require.Equal(t, admissionpb.NormalPri, workClassPriority(&st.SV, h))
+	h.WorkClass = roachpb.AdmissionHeader_BACKUP
+	require.Equal(t, admissionpb.BulkNormalPri, workClassPriority(&st.SV, h))
+	h.WorkClass = roachpb.AdmissionHeader_DEFAULT
+	require.Equal(t, admissionpb.BulkNormalPri, workClassPriority(&st.SV, h))
+	require.Error(t, validateWorkPriority(128))
+}
+
+func TestParseStoreL0OverloadThresholds(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	overrides, err := parseStoreL0OverloadThresholds("")
+	require.NoError(t, err)
+	require.Empty(t, overrides)
+	overrides, err = parseStoreL0OverloadThresholds("1:2000:40, 3:0:10")
+	require.NoError(t, err)
+	require.Equal(t, map[int32]admission.L0OverloadThresholds{
+		1: {FileCount: 2000, SubLevelCount: 40},
+		3: {SubLevelCount: 10},
+	}, overrides)
+	for _, s := range []string{"1:2000", "0:1:1", "1:-1:1", "a:1:1", "1:1:1,1:2:2"} {
+		_, err := parseStoreL0OverloadThresholds(s)
+		require.Error(t, err, s)
+	}
+}
+
+func TestReplicaDecoderNonLocalWriteWork(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	var d replicaDecoder
+	defer d.cmdBuf.clear()
+	require.Zero(t, d.nonLocalWriteWork())
+
+	addCmd := func(local, bypassed bool, bytes int) {
+		cmd := d.cmdBuf.allocate()
+		cmd.raftCmd = kvserverpb.RaftCommand{
+			WriteBatch:        &kvserverpb.WriteBatch{Data: make([]byte, bytes)},
+			AdmissionBypassed: bypassed,
+		}
+		if local {
+			cmd.proposal = &ProposalData{}
+		}
+	}
+	addCmd(false /* local */, false /* bypassed */, 100)
+	addCmd(false /* local */, false /* bypassed */, 50)
+	// Local commands were admitted by the proposer, commands that bypassed
+	// admission on the proposer bypass it here too, and empty commands write
+	// nothing.
+	addCmd(true /* local */, false /* bypassed */, 1000)
+	addCmd(false /* local */, true /* bypassed */, 1000)
+	addCmd(false /* local */, false /* bypassed */, 0)
+	require.Equal(t, int64(150), d.nonLocalWriteWork())
+}
+
+func TestKVAdmissionAccountFollowerApplication(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	metrics := MakeKVAdmissionMetrics(st, time.Minute)
+	var buf strings.Builder
+	n := KVAdmissionControllerImpl{
+		settings: st,
+		metrics:  metrics,
+		storeQueues: fakeStoreAdmissionQueues{
+			1: &fakeStoreAdmissionQueue{storeID: 1, buf: &buf},
+		},
+	}
+	tenantID := roachpb.MakeTenantID(5)
+
+	// Follower application is not accounted for by default.
+	n.AccountFollowerApplication(1, tenantID, 1<<20)
+	require.Empty(t, buf.String())
+
+	followerApplyAdmissionEnabled.Override(ctx, &st.SV, true)
+	followerApplyMinBytes.Override(ctx, &st.SV, 1000)
+	// Batches below the minimum size, and stores without admission queues, are
+	// skipped.
+	n.AccountFollowerApplication(1, tenantID, 999)
+	n.AccountFollowerApplication(2, tenantID, 1000)
+	require.Empty(t, buf.String())
+	require.Zero(t, metrics.FollowerApplyAccounted.Count())
+
+	n.AccountFollowerApplication(1, tenantID, 1000)
+	n.AccountFollowerApplication(1, roachpb.SystemTenantID, 5000)
+	require.Equal(t,
+		"s1: follower-applied tenant=5 bytes=1000\ns1: follower-applied tenant=1 bytes=5000\n",
+		buf.String())
+	require.Equal(t, int64(2), metrics.FollowerApplyAccounted.Count())
+
+	// Without admission control, nothing is accounted for.
+	KVAdmissionControllerImpl{settings: st}.AccountFollowerApplication(1, tenantID, 1000)
+}
+
+func TestKVAdmissionAccountTenantBypassWrite(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	var buf strings.Builder
+	n := KVAdmissionControllerImpl{
+		settings:     st,
+		tenantBypass: newKVAdmissionTenantBypass(st),
+		storeQueues: fakeStoreAdmissionQueues{
+			1: &fakeStoreAdmissionQueue{storeID: 1, buf: &buf},
+		},
+	}
+	tenantID := roachpb.MakeTenantID(10)
+	var system, user roachpb.BatchRequest
+	codec := keys.MakeSQLCodec(tenantID)
+	v := roachpb.MakeValueFromString("v")
+	system.Add(roachpb.NewPut(codec.TablePrefix(keys.SqllivenessID), v))
+	user.Add(roachpb.NewPut(codec.TablePrefix(keys.MaxReservedDescID+1), v))
+
+	// Writes that were admitted to the store are not charged again.
+	n.AccountTenantBypassWrite(1, tenantID, &system, 100)
+	require.Empty(t, buf.String())
+
+	// Writes that bypassed admission are charged to the store, without
+	// occupying a slot, regardless of follower application accounting.
+	kvAdmissionTenantSystemTableBypass.Override(ctx, &st.SV, "10")
+	n.AccountTenantBypassWrite(1, tenantID, &system, 100)
+	n.AccountTenantBypassWrite(1, tenantID, &user, 200)
+	n.AccountTenantBypassWrite(2, tenantID, &system, 300)
+	n.AccountTenantBypassWrite(1, tenantID, &system, 0)
+	require.Equal(t, "s1: follower-applied tenant=10 bytes=100\n", buf.String())
+
+	// Without admission control, nothing is accounted for.
+	KVAdmissionControllerImpl{settings: st}.AccountTenantBypassWrite(1, tenantID, &system, 100)
+}
+
+func TestFollowerApplyExemptions(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	descForKey := func(key roachpb.Key) *roachpb.RangeDescriptor {
+		return &roachpb.RangeDescriptor{
+			StartKey: roachpb.RKey(key),
+			EndKey:   roachpb.RKey(key.PrefixEnd()),
+		}
+	}
+	require.True(t, followerApplyExempt(&roachpb.RangeDescriptor{StartKey: roachpb.RKeyMin}))
+	require.True(t, followerApplyExempt(descForKey(keys.NodeLivenessPrefix)))
+	require.True(t, followerApplyExempt(descForKey(keys.SystemConfigSpan.Key)))
+	require.False(t, followerApplyExempt(descForKey(keys.SystemSQLCodec.TablePrefix(100))))
+	require.False(t, followerApplyExempt(descForKey(keys.MakeTenantPrefix(roachpb.MakeTenantID(5)))))
+
+	ctx := context.Background()
+	batch := func(source roachpb.AdmissionHeader_Source, req roachpb.Request) *roachpb.BatchRequest {
+		ba := &roachpb.BatchRequest{}
+		ba.AdmissionHeader.Source = source
+		ba.Add(req)
+		return ba
+	}
+	put := &roachpb.PutRequest{}
+	require.True(t, proposerBypassedAdmission(ctx, batch(roachpb.AdmissionHeader_OTHER, put)))
+	require.True(t, proposerBypassedAdmission(ctx,
+		batch(roachpb.AdmissionHeader_FROM_SQL, &roachpb.AdminSplitRequest{})))
+	require.False(t, proposerBypassedAdmission(ctx, batch(roachpb.AdmissionHeader_FROM_SQL, put)))
+	// The requests of secondary tenants never bypass admission.
+	tenantCtx := roachpb.NewContextForTenant(ctx, roachpb.MakeTenantID(5))
+	require.False(t, proposerBypassedAdmission(tenantCtx, batch(roachpb.AdmissionHeader_OTHER, put)))
+	systemCtx := roachpb.NewContextForTenant(ctx, roachpb.SystemTenantID)
+	require.True(t, proposerBypassedAdmission(systemCtx, batch(roachpb.AdmissionHeader_OTHER, put)))
+}
+
+// TestKVAdmissionDecisionLog verifies that the outcomes of KV admission are
+// only logged when admission.kv.decision_log.enabled is set, subject to
+// admission.kv.decision_log.max_rate, and the format of the logged outcomes.
+func TestKVAdmissionDecisionLog(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	kvAdmissionDecisionLogMaxRate.Override(ctx, &st.SV, 2)
+
+	mt := timeutil.NewManualTime(timeutil.Unix(0, 0))
+	ac, gcoords := newTestKVAdmissionController(st, nil /* metrics */, mt, nil /* knobs */)
+	defer gcoords.Close()
+	kvQueue := &fakeKVAdmissionQueue{buf: &strings.Builder{}}
+	ac.kvQueue = kvQueue
+
+	interceptor := &testLogInterceptor{}
+	defer log.InterceptWith(ctx, interceptor)()
+	admit := func(ba *roachpb.BatchRequest) {
+		handle, err := ac.AdmitKVWork(ctx, roachpb.SystemTenantID, ba)
+		if err == nil {
+			ac.AdmittedKVWorkDone(handle, nil /* br */)
+		}
+	}
+	get := &roachpb.BatchRequest{}
+	get.RangeID = 7
+	get.AdmissionHeader.Source = roachpb.AdmissionHeader_ROOT_KV
+	get.AdmissionHeader.Priority = 10
+	get.Add(roachpb.NewGet(roachpb.Key("a"), false /* forUpdate */))
+	split := &roachpb.BatchRequest{}
+	split.RangeID = 7
+	split.Add(&roachpb.AdminSplitRequest{RequestHeader: roachpb.RequestHeader{Key: roachpb.Key("a")}})
+
+	// The outcomes are not logged by default.
+	require.False(t, ac.decisionLog.enabled())
+	admit(get)
+	admit(split)
+	require.Zero(t, interceptor.count("kv admission:"))
+
+	kvAdmissionDecisionLogEnabled.Override(ctx, &st.SV, true)
+	require.True(t, ac.decisionLog.enabled())
+	admit(get)
+	admit(split)
+	require.Equal(t, 2, interceptor.count("kv admission:"))
+	require.Equal(t, 1, interceptor.count("kv admission: admitted after 0s: tenant=1 r7 pri=10 1 Get"))
+	require.Equal(t, 1, interceptor.count("kv admission: bypassed (admin): tenant=1 r7 pri=0 1 AdmSplit"))
+
+	// The burst of two outcomes is used up, until the clock advances.
+	kvQueue.admitErr = errors.New("boom")
+	admit(get)
+	require.Equal(t, 2, interceptor.count("kv admission:"))
+	mt.Advance(500 * time.Millisecond)
+	admit(get)
+	admit(get)
+	require.Equal(t, 3, interceptor.count("kv admission:"))
+	require.Equal(t, 1, interceptor.count("kv admission: rejected after 0s: tenant=1 r7 pri=10 1 Get: "))
+	require.Equal(t, 1, interceptor.count("boom"))
+
+	// A change to the rate takes effect at the next outcome.
+	kvAdmissionDecisionLogMaxRate.Override(ctx, &st.SV, 4)
+	admit(get)
+	require.Equal(t, 3, interceptor.count("kv admission:"))
+	mt.Advance(250 * time.Millisecond)
+	admit(get)
+	admit(get)
+	require.Equal(t, 4, interceptor.count("kv admission:"))
+	// The burst is a second's worth of outcomes.
+	mt.Advance(10 * time.Second)
+	for i := 0; i < 5; i++ {
+		admit(get)
+	}
+	require.Equal(t, 8, interceptor.count("kv admission:"))
+}
+
+// testLogInterceptor records the intercepted log entries.
+type testLogInterceptor struct {
+	syncutil.Mutex
+	entries []string
+}
+
+func (i *testLogInterceptor) Intercept(entry []byte) {
+	i.Lock()
+	defer i.Unlock()
+	i.entries = append(i.entries, string(entry))
+}
+
+// count returns the number of intercepted log entries that contain s.
+func (i *testLogInterceptor) count(s string) int {
+	i.Lock()
+	defer i.Unlock()
+	n := 0
+	for _, e := range i.entries {
+		if strings.Contains(e, s) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestKVAdmissionLoadShedding(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	loadSheddingMaxQueueLengthForeground.Override(ctx, &st.SV, 10)
+	loadSheddingMaxWaitBackground.Override(ctx, &st.SV, time.Second)
+	require.Equal(t, 10, loadSheddingMaxQueueLength(&st.SV, roachpb.AdmissionHeader_DEFAULT))
+	require.Zero(t, loadSheddingMaxQueueLength(&st.SV, roachpb.AdmissionHeader_BACKUP))
+	require.Zero(t, loadSheddingMaxWait(&st.SV, roachpb.AdmissionHeader_DEFAULT))
+	require.Equal(t, time.Second,
+		loadSheddingMaxWait(&st.SV, roachpb.AdmissionHeader_INDEX_BACKFILL))
+
+	metrics := MakeKVAdmissionMetrics(st, time.Minute)
+	n := KVAdmissionControllerImpl{metrics: metrics}
+	errDeadline := errors.New("deadline expired")
+	expiredCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	// Only the expiry of the load shedding deadline, and not of the caller's
+	// context, sheds the work.
+	err := n.loadShedError(ctx, expiredCtx, timeutil.Now(), kvAdmissionShedMaxWait, errDeadline)
+	require.True(t, errors.HasType(err, (*roachpb.AdmissionOverloadedError)(nil)))
+	require.Equal(t, int64(1), metrics.LoadShedMaxWait.Count())
+	err = n.loadShedError(expiredCtx, expiredCtx, timeutil.Now(), kvAdmissionShedMaxWait, errDeadline)
+	require.Equal(t, errDeadline, err)
+	err = n.loadShedError(ctx, expiredCtx, time.Time{}, kvAdmissionShedMaxWait, errDeadline)
+	require.Equal(t, errDeadline, err)
+	require.Equal(t, int64(1), metrics.LoadShedMaxWait.Count())
+	require.Zero(t, metrics.LoadShedQueueLength.Count())
+	require.Zero(t, metrics.LoadShedQueueDeadline.Count())
+}
+
+// blockingKVAdmissionQueue is a kvAdmissionWorkQueue whose Admit waits until
+// its context is done.
+type blockingKVAdmissionQueue struct {
+	fakeKVAdmissionQueue
+}
+
+func (q *blockingKVAdmissionQueue) Admit(
+	ctx context.Context, _ admission.WorkInfo,
+) (enabled bool, err error) {
+	<-ctx.Done()
+	return false, ctx.Err()
+}
+
+// TestKVAdmissionQueueDeadline tests that work that is still waiting in the
+// admission queues at its queue deadline is rejected and counted.
+func TestKVAdmissionQueueDeadline(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	metrics := MakeKVAdmissionMetrics(st, time.Minute)
+	ac, gcoords := newTestKVAdmissionController(st, metrics, nil /* timeSource */, nil /* knobs */)
+	defer gcoords.Close()
+	ac.kvQueue = &blockingKVAdmissionQueue{fakeKVAdmissionQueue{buf: &strings.Builder{}}}
+
+	ba := &roachpb.BatchRequest{}
+	ba.AdmissionHeader.Source = roachpb.AdmissionHeader_ROOT_KV
+	ba.Add(roachpb.NewGet(roachpb.Key("a"), false /* forUpdate */))
+	handle, err := ac.AdmitKVWorkWithDeadline(
+		ctx, roachpb.SystemTenantID, ba, timeutil.Now().Add(time.Millisecond))
+	require.Nil(t, handle)
+	var overloadedErr *roachpb.AdmissionOverloadedError
+	require.True(t, errors.As(err, &overloadedErr), "%v", err)
+	require.Contains(t, overloadedErr.Reason, "exceeded the queue deadline")
+	require.Equal(t, int64(1), metrics.LoadShedQueueDeadline.Count())
+	require.Zero(t, metrics.LoadShedMaxWait.Count())
+
+	// The maximum wait of the work class sheds the work when it comes first.
+	loadSheddingMaxWaitForeground.Override(ctx, &st.SV, time.Millisecond)
+	_, err = ac.AdmitKVWorkWithDeadline(
+		ctx, roachpb.SystemTenantID, ba, timeutil.Now().Add(time.Hour))
+	require.True(t, errors.As(err, &overloadedErr), "%v", err)
+	require.Contains(t, overloadedErr.Reason, "exceeded the maximum wait")
+	require.Equal(t, int64(1), metrics.LoadShedQueueDeadline.Count())
+	require.Equal(t, int64(1), metrics.LoadShedMaxWait.Count())
+	loadSheddingMaxWaitForeground.Override(ctx, &st.SV, 0)
+
+	// Work whose caller gives up first is not shed.
+	cancelCtx, cancel := context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+	_, err = ac.AdmitKVWorkWithDeadline(
+		cancelCtx, roachpb.SystemTenantID, ba, timeutil.Now().Add(time.Hour))
+	require.True(t, errors.Is(err, context.DeadlineExceeded), "%v", err)
+	require.Equal(t, int64(1), metrics.LoadShedQueueDeadline.Count())
+}
+
+// TestKVAdmissionLoadSheddingQueueLengths tests the bounds on the lengths of
+// the admission queues beyond which work is shed.
+func TestKVAdmissionLoadSheddingQueueLengths(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	metrics := MakeKVAdmissionMetrics(st, time.Minute)
+	kvQueue := &fakeKVAdmissionQueue{}
+	storeQueue := &fakeStoreAdmissionQueue{storeID: 1}
+	n := KVAdmissionControllerImpl{kvQueue: kvQueue, settings: st, metrics: metrics}
+	check := func(workClass roachpb.AdmissionHeader_WorkClass, withStore bool) error {
+		if withStore {
+			return n.checkQueueLengths(workClass, storeQueue)
+		}
+		return n.checkQueueLengths(workClass, nil)
+	}
+	requireShed := func(t *testing.T, err error, msg string) {
+		t.Helper()
+		var overloadedErr *roachpb.AdmissionOverloadedError
+		require.True(t, errors.As(err, &overloadedErr), "%v", err)
+		require.Equal(t, msg, overloadedErr.Reason)
+	}
+
+	// The queues are unbounded by default.
+	kvQueue.numWaiting, storeQueue.numWaiting = 1000, 1000
+	require.NoError(t, check(roachpb.AdmissionHeader_DEFAULT, true))
+	require.NoError(t, check(roachpb.AdmissionHeader_BACKUP, true))
+
+	loadSheddingMaxQueueLengthForeground.Override(ctx, &st.SV, 10)
+	loadSheddingMaxQueueLengthBackground.Override(ctx, &st.SV, 5)
+
+	// Work is shed once the KV admission queue reaches the bound of its class.
+	kvQueue.numWaiting, storeQueue.numWaiting = 9, 0
+	require.NoError(t, check(roachpb.AdmissionHeader_DEFAULT, true))
+	requireShed(t, check(roachpb.AdmissionHeader_BACKUP, true),
+		"KV admission queue length 9 reached the maximum of 5")
+	kvQueue.numWaiting = 10
+	requireShed(t, check(roachpb.AdmissionHeader_DEFAULT, false),
+		"KV admission queue length 10 reached the maximum of 10")
+
+	// The store admission queue is bounded too, when the work is subject to it.
+	kvQueue.numWaiting, storeQueue.numWaiting = 0, 10
+	require.NoError(t, check(roachpb.AdmissionHeader_DEFAULT, false))
+	requireShed(t, check(roachpb.AdmissionHeader_DEFAULT, true),
+		"store admission queue length 10 reached the maximum of 10")
+	storeQueue.numWaiting = 4
+	require.NoError(t, check(roachpb.AdmissionHeader_DEFAULT, true))
+	require.NoError(t, check(roachpb.AdmissionHeader_INDEX_BACKFILL, true))
+
+	require.Equal(t, int64(3), metrics.LoadShedQueueLength.Count())
+	require.Zero(t, metrics.LoadShedMaxWait.Count())
+}
+
+func TestEpochLIFOEnabledForWorkClass(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	st := cluster.MakeTestingClusterSettings()
+	sv := &st.SV
+	for _, workClass := range []roachpb.AdmissionHeader_WorkClass{
+		roachpb.AdmissionHeader_DEFAULT, roachpb.AdmissionHeader_BACKGROUND,
+	} {
+		require.True(t, epochLIFOEnabledForWorkClass(sv, workClass))
+	}
+
+	epochLIFOEnabledBackground.Override(context.Background(), sv, false)
+	require.True(t, epochLIFOEnabledForWorkClass(sv, roachpb.AdmissionHeader_DEFAULT))
+	require.False(t, epochLIFOEnabledForWorkClass(sv, roachpb.AdmissionHeader_BACKGROUND))
+
+	epochLIFOEnabledForeground.Override(context.Background(), sv, false)
+	require.False(t, epochLIFOEnabledForWorkClass(sv, roachpb.AdmissionHeader_DEFAULT))
+}
+
+func TestKVAdmissionControllerStoreErrorInjection(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	errInjected := errors.New("injected")
+	require.NoError(t, InjectErrorWithProbability(0, errInjected)(1))
+	require.Equal(t, errInjected, InjectErrorWithProbability(1, errInjected)(1))
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	var admitErr, doneErr error
+	knobs := &KVAdmissionTestingKnobs{
+		InjectStoreAdmitError: func(roachpb.StoreID) error {
+			return admitErr
+		},
+		InjectStoreAdmittedWorkDoneError: func(roachpb.StoreID) error {
+			return doneErr
+		},
+	}
+	metrics := MakeKVAdmissionMetrics(st, time.Minute)
+	ac, gcoords := newTestKVAdmissionController(st, metrics, nil /* timeSource */, knobs)
+	defer gcoords.Close()
+	gcoords.Stores.SetPebbleMetricsProvider(ctx,
+		testPebbleMetricsProvider{{StoreID: 1, Metrics: &pebble.Metrics{}}})
+	var ba roachpb.BatchRequest
+	ba.Replica.StoreID = 1
+	ba.AdmissionHeader.Source = roachpb.AdmissionHeader_ROOT_KV
+	ba.Add(roachpb.NewPut(roachpb.Key("a"), roachpb.MakeValueFromString("v")))
+
+	admitErr = errInjected
+	handle, err := ac.AdmitKVWork(ctx, roachpb.SystemTenantID, &ba)
+	require.True(t, errors.Is(err, errInjected))
+	require.Nil(t, handle)
+
+	// A failure to release the work from the store admission queue is counted.
+	admitErr, doneErr = nil, errInjected
+	handle, err = ac.AdmitKVWork(ctx, roachpb.SystemTenantID, &ba)
+	require.NoError(t, err)
+	ac.AdmittedKVWorkDone(handle, nil /* br */)
+	require.Equal(t, int64(1), metrics.StoreWorkDoneErrors.Count())
+
+	doneErr = nil
+	handle, err = ac.AdmitKVWork(ctx, roachpb.SystemTenantID, &ba)
+	require.NoError(t, err)
+	ac.AdmittedKVWorkDone(handle, nil /* br */)
+	require.Equal(t, int64(1), metrics.StoreWorkDoneErrors.Count())
+}
+
+type testPebbleMetricsProvider []admission.StoreMetrics
+
+func (p testPebbleMetricsProvider) GetPebbleMetrics() []admission.StoreMetrics {
+	return p
+}
+
+// BenchmarkAdmitKVWork measures the cost of the uncontended admission of a
+// batch, which should only allocate its handle.
+func BenchmarkAdmitKVWork(b *testing.B) {
+	defer leaktest.AfterTest(b)()
+	defer log.Scope(b).Close(b)
+
+	ctx := context.Background()
+	st := cluster.MakeTestingClusterSettings()
+	metrics := MakeKVAdmissionMetrics(st, time.Minute)
+	ac, gcoords := newTestKVAdmissionController(st, metrics, nil /* timeSource */, nil /* knobs */)
+	defer gcoords.Close()
+	gcoords.Stores.SetPebbleMetricsProvider(ctx,
+		testPebbleMetricsProvider{{StoreID: 1, Metrics: &pebble.Metrics{}}})
+	key := roachpb.Key("a")
+	for _, tc := range []struct {
+		name      string
+		source    roachpb.AdmissionHeader_Source
+		workClass roachpb.AdmissionHeader_WorkClass
+		req       roachpb.Request
+	}{
+		{
+			name:   "read",
+			source: roachpb.AdmissionHeader_ROOT_KV,
+			req:    roachpb.NewGet(key, false /* forUpdate */),
+		},
+		{
+			name:   "write",
+			source: roachpb.AdmissionHeader_ROOT_KV,
+			req:    roachpb.NewPut(key, roachpb.MakeValueFromString("v")),
+		},
+		{
+			name:      "elastic-write",
+			source:    roachpb.AdmissionHeader_ROOT_KV,
+			workClass: roachpb.AdmissionHeader_BACKGROUND,
+			req:       roachpb.NewPut(key, roachpb.MakeValueFromString("v")),
+		},
+		{
+			name:   "bypass",
+			source: roachpb.AdmissionHeader_OTHER,
+			req:    roachpb.NewPut(key, roachpb.MakeValueFromString("v")),
+		},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			var ba roachpb.BatchRequest
+			ba.Replica.StoreID = 1
+			ba.AdmissionHeader.Source = tc.source
+			ba.AdmissionHeader.WorkClass = tc.workClass
+			ba.Add(tc.req)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				handle, err := ac.AdmitKVWork(ctx, roachpb.SystemTenantID, &ba)
+				if err != nil {
+					b.Fatal(err)
+				}
+				ac.AdmittedKVWorkDone(handle, nil /* br */)
+			}
+		})
+	}
+}
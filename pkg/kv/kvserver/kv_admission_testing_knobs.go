@@ -0,0 +1,101 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/admission"
+)
+
+// KVAdmissionTestingKnobs are testing knobs for the
+// KVAdmissionControllerImpl. They inject failures into the store admission
+// queues, so that tests can verify that the store admission of work is
+// unwound when the admission or the completion of the work fails.
+type KVAdmissionTestingKnobs struct {
+	// InjectStoreAdmitError, if set, is called before work is admitted to the
+	// admission queue of a store. If it returns an error, the admission fails
+	// with that error, and the work is not admitted to the queue.
+	InjectStoreAdmitError func(storeID roachpb.StoreID) error
+	// InjectStoreAdmittedWorkDoneError, if set, is called after work is
+	// released from the admission queue of a store. If it returns an error,
+	// the release is reported as failed with that error. The work is still
+	// released from the queue, as it is when the queue itself fails the
+	// release.
+	InjectStoreAdmittedWorkDoneError func(storeID roachpb.StoreID) error
+}
+
+// InjectErrorWithProbability returns a function for the
+// KVAdmissionTestingKnobs that returns err with probability p, and nil
+// otherwise.
+func InjectErrorWithProbability(p float64, err error) func(roachpb.StoreID) error {
+	return func(roachpb.StoreID) error {
+		if rand.Float64() < p {
+			return err
+		}
+		return nil
+	}
+}
+
+// injectsStoreErrors returns true if the knobs inject errors into the store
+// admission queues.
+func (k *KVAdmissionTestingKnobs) injectsStoreErrors() bool {
+	return k != nil &&
+		(k.InjectStoreAdmitError != nil || k.InjectStoreAdmittedWorkDoneError != nil)
+}
+
+// errorInjectingStoreQueues wraps the store admission queues to inject the
+// errors of the KVAdmissionTestingKnobs. It is only used in tests, so it is
+// not concerned with the allocation of the wrapped queue.
+type errorInjectingStoreQueues struct {
+	storeAdmissionQueues
+	knobs *KVAdmissionTestingKnobs
+}
+
+func (qs errorInjectingStoreQueues) queueForStore(storeID roachpb.StoreID) storeAdmissionWorkQueue {
+	q := qs.storeAdmissionQueues.queueForStore(storeID)
+	if q == nil {
+		return nil
+	}
+	return &errorInjectingStoreQueue{storeAdmissionWorkQueue: q, storeID: storeID, knobs: qs.knobs}
+}
+
+// errorInjectingStoreQueue implements storeAdmissionWorkQueue.
+type errorInjectingStoreQueue struct {
+	storeAdmissionWorkQueue
+	storeID roachpb.StoreID
+	knobs   *KVAdmissionTestingKnobs
+}
+
+func (q *errorInjectingStoreQueue) Admit(
+	ctx context.Context, info admission.StoreWriteWorkInfo,
+) (admission.StoreWorkHandle, error) {
+	if fn := q.knobs.InjectStoreAdmitError; fn != nil {
+		if err := fn(q.storeID); err != nil {
+			return admission.StoreWorkHandle{}, err
+		}
+	}
+	return q.storeAdmissionWorkQueue.Admit(ctx, info)
+}
+
+func (q *errorInjectingStoreQueue) AdmittedWorkDone(
+	h admission.StoreWorkHandle, ingestedIntoL0Bytes int64,
+) error {
+	err := q.storeAdmissionWorkQueue.AdmittedWorkDone(h, ingestedIntoL0Bytes)
+	if fn := q.knobs.InjectStoreAdmittedWorkDoneError; fn != nil {
+		if injectedErr := fn(q.storeID); injectedErr != nil {
+			return injectedErr
+		}
+	}
+	return err
+}
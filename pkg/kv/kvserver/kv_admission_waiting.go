@@ -0,0 +1,64 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/admission"
+)
+
+// The names of the admission queues, as reported in
+// KVAdmissionWaitingRequest.Queue.
+const (
+	kvAdmissionQueueName      = "kv"
+	kvAdmissionStoreQueueName = "kv-store"
+)
+
+// KVAdmissionWaitingRequest describes a request that is waiting in one of
+// the KV admission queues.
+type KVAdmissionWaitingRequest struct {
+	// Queue is the name of the queue, i.e., "kv" for the queue that all KV
+	// work goes through, or "kv-store" for the queues of the stores that
+	// writes go through.
+	Queue string
+	// StoreID is set for the store queues.
+	StoreID roachpb.StoreID
+	admission.WaitingWork
+}
+
+// GetWaitingRequests implements the BatchAdmitter interface.
+func (n KVAdmissionControllerImpl) GetWaitingRequests() []KVAdmissionWaitingRequest {
+	if n.kvAdmissionQ == nil {
+		return nil
+	}
+	var res []KVAdmissionWaitingRequest
+	for _, w := range n.kvAdmissionQ.GetWaitingWork() {
+		res = append(res, KVAdmissionWaitingRequest{Queue: kvAdmissionQueueName, WaitingWork: w})
+	}
+	waiting := n.storeGrantCoords.GetWaitingWork()
+	storeIDs := make([]int32, 0, len(waiting))
+	for storeID := range waiting {
+		storeIDs = append(storeIDs, storeID)
+	}
+	sort.Slice(storeIDs, func(i, j int) bool { return storeIDs[i] < storeIDs[j] })
+	for _, storeID := range storeIDs {
+		for _, w := range waiting[storeID] {
+			res = append(res, KVAdmissionWaitingRequest{
+				Queue:       kvAdmissionStoreQueueName,
+				StoreID:     roachpb.StoreID(storeID),
+				WaitingWork: w,
+			})
+		}
+	}
+	return res
+}
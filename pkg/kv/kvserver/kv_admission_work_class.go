@@ -0,0 +1,65 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"math"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/admission/admissionpb"
+	"github.com/cockroachdb/errors"
+)
+
+func validateWorkPriority(v int64) error {
+	if v < math.MinInt8 || v > math.MaxInt8 {
+		return errors.Errorf("priority must be in [%d, %d]: %d", math.MinInt8, math.MaxInt8, v)
+	}
+	return nil
+}
+
+// indexBackfillAdmissionPriority is the priority at which the AddSSTable
+// requests of index backfills are admitted. Index backfills block schema
+// changes, so operators may want to admit them ahead of backups, which
+// share the same bulk priority by default.
+var indexBackfillAdmissionPriority = settings.RegisterIntSetting(
+	settings.SystemOnly,
+	"admission.kv.index_backfill.priority",
+	"the admission priority of the ingestion of index backfills, in [-128, 127]; "+
+		"work with a higher priority is admitted first",
+	int64(admissionpb.BulkNormalPri),
+	validateWorkPriority,
+)
+
+// backupAdmissionPriority is the priority at which the Export requests of
+// backups are admitted.
+var backupAdmissionPriority = settings.RegisterIntSetting(
+	settings.SystemOnly,
+	"admission.kv.backup.priority",
+	"the admission priority of the exports of backups, in [-128, 127]; "+
+		"work with a higher priority is admitted first",
+	int64(admissionpb.BulkNormalPri),
+	validateWorkPriority,
+)
+
+// workClassPriority returns the priority at which the work with the given
+// AdmissionHeader is admitted, before any adjustments for the kind of
+// request.
+func workClassPriority(sv *settings.Values, h roachpb.AdmissionHeader) admissionpb.WorkPriority {
+	switch h.WorkClass {
+	case roachpb.AdmissionHeader_INDEX_BACKFILL:
+		return admissionpb.WorkPriority(indexBackfillAdmissionPriority.Get(sv))
+	case roachpb.AdmissionHeader_BACKUP:
+		return admissionpb.WorkPriority(backupAdmissionPriority.Get(sv))
+	default:
+		return admissionpb.WorkPriority(h.Priority)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/admission"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+)
+
+// writeAmpFeedbackEnabled controls whether the store tokens are adjusted for
+// the write amplification of each store. The store tokens are computed from
+// the bytes added to and compacted out of L0, which assumes a fixed cost of
+// compacting each byte further down the LSM. When the write amplification is
+// higher than writeAmpFeedbackBaseline, for instance because a workload
+// switched from small overwrites to large blind writes, each admitted byte
+// costs proportionally more tokens.
+var writeAmpFeedbackEnabled = settings.RegisterBoolSetting(
+	settings.SystemOnly,
+	"admission.kv.write_amp_feedback.enabled",
+	"when true, the store tokens are reduced for stores whose write amplification "+
+		"exceeds admission.kv.write_amp_feedback.baseline",
+	false,
+)
+
+// writeAmpFeedbackBaseline is the write amplification at which each admitted
+// byte costs one token.
+var writeAmpFeedbackBaseline = settings.RegisterFloatSetting(
+	settings.SystemOnly,
+	"admission.kv.write_amp_feedback.baseline",
+	"the write amplification of a store up to which the store tokens are not reduced",
+	10,
+	settings.PositiveFloat,
+)
+
+// writeAmpFeedbackInterval is the interval at which the write amplification
+// is sampled. It matches the interval at which the store tokens are adjusted.
+const writeAmpFeedbackInterval = 15 * time.Second
+
+// maxWriteAmpTokenMultiplier bounds the reduction in store tokens, so that a
+// burst of compactions cannot starve the store of writes.
+const maxWriteAmpTokenMultiplier = 4
+
+// writeAmpState tracks the smoothed write amplification of a store.
+type writeAmpState struct {
+	initialized bool
+	// The cumulative bytes written to the store, and written by flushes and
+	// compactions, as of the last sample.
+	cumBytesIn, cumBytesWritten uint64
+	smoothedWriteAmp            float64
+}
+
+// update incorporates the cumulative byte counts of the store, and returns
+// the smoothed write amplification over the sampling intervals.
+func (s *writeAmpState) update(cumBytesIn, cumBytesWritten uint64) float64 {
+	if !s.initialized {
+		s.initialized = true
+		s.cumBytesIn, s.cumBytesWritten = cumBytesIn, cumBytesWritten
+		return s.smoothedWriteAmp
+	}
+	intBytesIn := int64(cumBytesIn) - int64(s.cumBytesIn)
+	intBytesWritten := int64(cumBytesWritten) - int64(s.cumBytesWritten)
+	s.cumBytesIn, s.cumBytesWritten = cumBytesIn, cumBytesWritten
+	if intBytesIn <= 0 || intBytesWritten < 0 {
+		// Nothing was written in the interval, so there is nothing to learn
+		// from it.
+		return s.smoothedWriteAmp
+	}
+	writeAmp := float64(intBytesWritten) / float64(intBytesIn)
+	if s.smoothedWriteAmp == 0 {
+		s.smoothedWriteAmp = writeAmp
+	} else {
+		const alpha = 0.5
+		s.smoothedWriteAmp = alpha*writeAmp + (1-alpha)*s.smoothedWriteAmp
+	}
+	return s.smoothedWriteAmp
+}
+
+// writeAmpTokenMultiplier returns the cost in store tokens of each admitted
+// byte, for the given write amplification.
+func writeAmpTokenMultiplier(writeAmp, baseline float64) float64 {
+	return math.Min(math.Max(writeAmp/baseline, 1), maxWriteAmpTokenMultiplier)
+}
+
+// StartWriteAmpFeedback implements the StoreStatsReporter interface.
+func (n KVAdmissionControllerImpl) StartWriteAmpFeedback(
+	provider admission.PebbleMetricsProvider, stopper *stop.Stopper,
+) {
+	if n.kvAdmissionQ == nil {
+		return
+	}
+	// An error is returned only if the stopper is quiescing, in which case
+	// there is nothing to adjust.
+	_ = stopper.RunAsyncTask(context.Background(), "kv-admission-write-amp",
+		func(ctx context.Context) {
+			ticker := n.timeSource.NewTicker(writeAmpFeedbackInterval)
+			defer ticker.Stop()
+			states := make(map[roachpb.StoreID]*writeAmpState)
+			for {
+				select {
+				case <-ticker.Ch():
+					n.updateWriteAmpFeedback(provider.GetPebbleMetrics(), states)
+				case <-stopper.ShouldQuiesce():
+					return
+				}
+			}
+		})
+}
+
+func (n KVAdmissionControllerImpl) updateWriteAmpFeedback(
+	metrics []admission.StoreMetrics, states map[roachpb.StoreID]*writeAmpState,
+) {
+	if !writeAmpFeedbackEnabled.Get(&n.settings.SV) {
+		// Restore the default token cost of the stores, and start afresh if
+		// re-enabled.
+		for storeID := range states {
+			n.storeGrantCoords.SetTokenMultiplier(int32(storeID), 1)
+			delete(states, storeID)
+		}
+		return
+	}
+	baseline := writeAmpFeedbackBaseline.Get(&n.settings.SV)
+	for _, m := range metrics {
+		storeID := roachpb.StoreID(m.StoreID)
+		s, ok := states[storeID]
+		if !ok {
+			s = &writeAmpState{}
+			states[storeID] = s
+		}
+		total := m.Total()
+		writeAmp := s.update(total.BytesIn, total.BytesFlushed+total.BytesCompacted)
+		n.storeGrantCoords.SetTokenMultiplier(m.StoreID, writeAmpTokenMultiplier(writeAmp, baseline))
+	}
+}
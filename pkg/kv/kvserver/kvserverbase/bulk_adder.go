@@ -68,6 +68,10 @@ type BulkAdderOptions struct {
 	// the first buffer to pick split points in the hope it is a representative
 	// sample of the overall input.
 	InitialSplitsIfUnordered int
+
+	// WorkClass is the admission control work class of the AddSSTable requests
+	// sent by this adder.
+	WorkClass roachpb.AdmissionHeader_WorkClass
 }
 
 // BulkAdderFactory describes a factory function for BulkAdders.
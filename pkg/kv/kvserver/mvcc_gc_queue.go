@@ -414,7 +414,7 @@ func makeMVCCGCQueueScoreImpl(
 type replicaGCer struct {
 	repl                *Replica
 	count               int32 // update atomically
-	admissionController KVAdmissionController
+	admissionController BatchAdmitter
 	storeID             roachpb.StoreID
 }
 
@@ -441,7 +441,7 @@ func (r *replicaGCer) send(ctx context.Context, req roachpb.GCRequest) error {
 	ba.Add(&req)
 	// Since we are talking directly to the replica, we need to explicitly do
 	// admission control here, as we are bypassing server.Node.
-	var admissionHandle interface{}
+	var admissionHandle *KVAdmissionHandle
 	if r.admissionController != nil {
 		ba.AdmissionHeader = roachpb.AdmissionHeader{
 			// GC is currently assigned NormalPri.
@@ -467,7 +467,7 @@ func (r *replicaGCer) send(ctx context.Context, req roachpb.GCRequest) error {
 	}
 	_, pErr := r.repl.Send(ctx, ba)
 	if r.admissionController != nil {
-		r.admissionController.AdmittedKVWorkDone(admissionHandle)
+		r.admissionController.AdmittedKVWorkDone(admissionHandle, nil /* br */)
 	}
 	if pErr != nil {
 		log.VErrEventf(ctx, 2, "%v", pErr.String())
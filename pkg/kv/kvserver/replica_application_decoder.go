@@ -73,6 +73,28 @@ func (d *replicaDecoder) decode(ctx context.Context, ents []raftpb.Entry) error
 	return nil
 }
 
+// nonLocalWriteWork returns the bytes written by the decoded commands that
+// were not proposed locally, and were therefore not admitted on this store.
+// Commands whose proposer bypassed admission are ignored. The bytes of
+// AddSSTable commands are conservatively counted in full, as if they were
+// written to L0. It must be called after retrieveLocalProposals.
+func (d *replicaDecoder) nonLocalWriteWork() (writeBytes int64) {
+	var it replicatedCmdBufSlice
+	for it.init(&d.cmdBuf); it.Valid(); it.Next() {
+		cmd := it.cur()
+		if cmd.IsLocal() || cmd.raftCmd.AdmissionBypassed {
+			continue
+		}
+		if wb := cmd.raftCmd.WriteBatch; wb != nil {
+			writeBytes += int64(len(wb.Data))
+		}
+		if sst := cmd.raftCmd.ReplicatedEvalResult.AddSSTable; sst != nil {
+			writeBytes += int64(len(sst.Data))
+		}
+	}
+	return writeBytes
+}
+
 // retrieveLocalProposals binds each of the decoder's commands to their local
 // proposals if they were proposed locally. The method also sets the ctx fields
 // on all commands.
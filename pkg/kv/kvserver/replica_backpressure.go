@@ -73,6 +73,19 @@ var backpressureByteTolerance = settings.RegisterByteSizeSetting(
 		"backpressure will not apply",
 	32<<20 /* 32 MiB */)
 
+// backpressureAdmissionAware, when set, defers the backpressure of writes to
+// oversized ranges to admission control while the store is under write token
+// pressure. Blocking such writes on a split is counterproductive in that
+// case, since the split itself has to wait for store tokens, and the writes
+// are already being throttled by store admission.
+var backpressureAdmissionAware = settings.RegisterBoolSetting(
+	settings.TenantWritable,
+	"kv.range.backpressure_admission_aware.enabled",
+	"when true, writes to ranges that have grown past the backpressure threshold are not "+
+		"blocked on a split while admission control is throttling writes to the store",
+	false,
+)
+
 // backpressurableSpans contains spans of keys where write backpressuring
 // is permitted. Writes to any keys within these spans may cause a batch
 // to be backpressured.
@@ -148,6 +161,17 @@ func (r *Replica) shouldBackpressureWrites() bool {
 	return true
 }
 
+// backpressureDeferredToAdmission returns whether the backpressure of writes
+// to the range is deferred to store admission control, since the store is
+// under write token pressure. See backpressureAdmissionAware.
+func (r *Replica) backpressureDeferredToAdmission() bool {
+	ac := r.store.cfg.KVAdmissionController
+	if ac == nil || !backpressureAdmissionAware.Get(&r.store.cfg.Settings.SV) {
+		return false
+	}
+	return ac.StoreWritePressure(r.store.StoreID()) >= 1
+}
+
 // maybeBackpressureBatch blocks to apply backpressure if the replica deems
 // that backpressure is necessary.
 func (r *Replica) maybeBackpressureBatch(ctx context.Context, ba *roachpb.BatchRequest) error {
@@ -159,7 +183,7 @@ func (r *Replica) maybeBackpressureBatch(ctx context.Context, ba *roachpb.BatchR
 	// if one exists. This does not place a hard upper bound on the size of
 	// a range because we don't track all in-flight requests (like we do for
 	// the quota pool), but it does create an effective soft upper bound.
-	for first := true; r.shouldBackpressureWrites(); first = false {
+	for first := true; r.shouldBackpressureWrites() && !r.backpressureDeferredToAdmission(); first = false {
 		if first {
 			r.store.metrics.BackpressuredOnSplitRequests.Inc(1)
 			defer r.store.metrics.BackpressuredOnSplitRequests.Dec(1)
@@ -0,0 +1,112 @@
+// Copyright 2022 The Cockroach Authors.
+//
+// Use of this software is governed by the Business Source License
+// included in the file licenses/BSL.txt.
+//
+// As of the Change Date specified in that file, in accordance with
+// the Business Source License, use of this software will be governed
+// by the Apache License, Version 2.0, included in the file
+// licenses/APL.txt.
+
+package kvserver
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// writePressureController is a KVAdmissionController that reports a fixed
+// write pressure for all stores.
+type writePressureController struct {
+	KVAdmissionController
+	pressure float64
+}
+
+func (c *writePressureController) StoreWritePressure(roachpb.StoreID) float64 {
+	return c.pressure
+}
+
+// TestMaybeBackpressureBatchDeferredToAdmission verifies that writes to a
+// range that has grown past the backpressure threshold are not blocked on a
+// split while the store is under write token pressure, if
+// kv.range.backpressure_admission_aware.enabled is set.
+func TestMaybeBackpressureBatchDeferredToAdmission(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+	cfg := TestStoreConfig(nil /* clock */)
+	ac := &writePressureController{
+		KVAdmissionController: MakeKVAdmissionController(
+			nil /* kvAdmissionQ */, nil /* kvGrantCoord */, nil /* storeGrantCoords */, cfg.Settings,
+			nil /* metrics */, nil /* timeSource */, nil /* knobs */),
+	}
+	cfg.KVAdmissionController = ac
+	tc.StartWithStoreConfig(ctx, t, stopper, cfg)
+
+	// Create a replica that is not hooked up to the store, and that is large
+	// enough to be backpressured. See TestSplitQueueShouldQueue.
+	desc := *tc.repl.Desc()
+	repl, err := newReplica(ctx, &desc, tc.store, desc.Replicas().VoterDescriptors()[0].ReplicaID)
+	require.NoError(t, err)
+	repl.mu.Lock()
+	repl.mu.state.Stats = &enginepb.MVCCStats{KeyBytes: 3 << 20}
+	repl.mu.Unlock()
+	conf := roachpb.TestingDefaultSpanConfig()
+	conf.RangeMaxBytes = 1 << 20
+	repl.SetSpanConfig(conf)
+	require.True(t, repl.shouldBackpressureWrites())
+
+	// Place the range in split queue purgatory, such that a backpressured
+	// write fails immediately with the split error, rather than blocking.
+	func() {
+		tc.store.splitQueue.mu.Lock()
+		defer tc.store.splitQueue.mu.Unlock()
+		if tc.store.splitQueue.mu.purgatory == nil {
+			tc.store.splitQueue.mu.purgatory = map[roachpb.RangeID]purgatoryError{}
+		}
+		tc.store.splitQueue.mu.purgatory[repl.RangeID] = unsplittableRangeError{}
+	}()
+
+	var ba roachpb.BatchRequest
+	ba.Add(roachpb.NewPut(keys.SystemSQLCodec.TablePrefix(100), roachpb.MakeValueFromString("v")))
+	require.True(t, canBackpressureBatch(&ba))
+
+	for _, c := range []struct {
+		admissionAware bool
+		pressure       float64
+		backpressured  bool
+	}{
+		{admissionAware: false, pressure: 2, backpressured: true},
+		{admissionAware: true, pressure: 0.5, backpressured: true},
+		{admissionAware: true, pressure: 1, backpressured: false},
+		{admissionAware: true, pressure: 2, backpressured: false},
+	} {
+		t.Run(fmt.Sprintf("aware=%t/pressure=%.1f", c.admissionAware, c.pressure),
+			func(t *testing.T) {
+				backpressureAdmissionAware.Override(ctx, &cfg.Settings.SV, c.admissionAware)
+				ac.pressure = c.pressure
+				require.Equal(t, !c.backpressured, repl.backpressureDeferredToAdmission())
+				err := repl.maybeBackpressureBatch(ctx, &ba)
+				if c.backpressured {
+					require.Regexp(t, "split failed while applying backpressure", err)
+					require.True(t, errors.HasType(err, unsplittableRangeError{}))
+				} else {
+					require.NoError(t, err)
+				}
+			})
+	}
+}
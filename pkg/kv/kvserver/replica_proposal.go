@@ -772,6 +772,13 @@ func (r *Replica) requestToProposal(
 			WriteBatch:           res.WriteBatch,
 			LogicalOpLog:         res.LogicalOpLog,
 			TraceData:            r.getTraceData(ctx),
+			AdmissionBypassed:    proposerBypassedAdmission(ctx, ba),
+		}
+		if ac := r.store.cfg.KVAdmissionController; ac != nil && res.WriteBatch != nil {
+			if tenantID, ok := roachpb.TenantFromContext(ctx); ok {
+				writeBytes := int64(len(res.WriteBatch.Data))
+				ac.AccountTenantBypassWrite(r.store.StoreID(), tenantID, ba, writeBytes)
+			}
 		}
 	}
 
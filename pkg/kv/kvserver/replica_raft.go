@@ -916,6 +916,17 @@ func (r *Replica) handleRaftReadyRaftMuLocked(
 		stats.appendedSideloadedCount += numSideloaded
 		stats.appendedSideloadedBytes += sideLoadedEntriesSize
 		stats.tAppendEnd = timeutil.Now()
+		// Report the bytes appended to the log by the leader, which is usually
+		// also the leaseholder, to store admission. Sideloaded entries are
+		// ingested as sstables, and admitted as such.
+		if ac := r.store.cfg.KVAdmissionController; ac != nil &&
+			otherEntriesSize > 0 && leaderID == r.replicaID {
+			tenantID, ok := r.TenantID()
+			if !ok {
+				tenantID = roachpb.SystemTenantID
+			}
+			ac.RaftLogAppendedBytes(r.store.StoreID(), tenantID, otherEntriesSize)
+		}
 	}
 
 	if !raft.IsEmptyHardState(rd.HardState) {
@@ -1013,6 +1024,18 @@ func (r *Replica) handleRaftReadyRaftMuLocked(
 
 	stats.tApplicationBegin = timeutil.Now()
 	if len(rd.CommittedEntries) > 0 {
+		// The commands proposed by other replicas were admitted on the
+		// proposer's store, but not on this one, so their application consumes
+		// the write tokens of this store (without waiting for them).
+		if ac := r.store.cfg.KVAdmissionController; ac != nil && !followerApplyExempt(r.Desc()) {
+			if writeBytes := dec.nonLocalWriteWork(); writeBytes > 0 {
+				tenantID, ok := r.TenantID()
+				if !ok {
+					tenantID = roachpb.SystemTenantID
+				}
+				ac.AccountFollowerApplication(r.store.StoreID(), tenantID, writeBytes)
+			}
+		}
 		err := appTask.ApplyCommittedEntries(ctx)
 		stats.apply = sm.moveStats()
 		if errors.Is(err, apply.ErrRemoved) {
@@ -547,6 +547,11 @@ type IncomingSnapshot struct {
 	snapType         kvserverpb.SnapshotRequest_Type
 	placeholder      *ReplicaPlaceholder
 	raftAppliedIndex uint64 // logging only
+	// ingestedIntoL0Bytes, if non-nil, is set to the approximate number of
+	// bytes of the snapshot that were ingested into L0 when it is applied, for
+	// the store admission accounting of the snapshot. It is shared by the
+	// copies of the IncomingSnapshot.
+	ingestedIntoL0Bytes *int64
 }
 
 func (s IncomingSnapshot) String() string {
@@ -944,9 +949,14 @@ func (r *Replica) applySnapshot(
 			return err
 		}
 	}
-	if err := r.store.engine.IngestExternalFiles(ctx, inSnap.SSTStorageScratch.SSTs()); err != nil {
+	ingestStats, err :=
+		r.store.engine.IngestExternalFilesWithStats(ctx, inSnap.SSTStorageScratch.SSTs())
+	if err != nil {
 		return errors.Wrapf(err, "while ingesting %s", inSnap.SSTStorageScratch.SSTs())
 	}
+	if inSnap.ingestedIntoL0Bytes != nil {
+		*inSnap.ingestedIntoL0Bytes = int64(ingestStats.ApproxIngestedIntoL0Bytes)
+	}
 	stats.ingestion = timeutil.Now()
 
 	state, err := stateloader.Make(desc.RangeID).Load(ctx, r.store.engine, desc)
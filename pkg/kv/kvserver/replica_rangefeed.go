@@ -180,14 +180,26 @@ func (r *Replica) rangeFeedWithRangeID(
 	lockedStream := &lockedRangefeedStream{wrapped: stream}
 	errC := make(chan *roachpb.Error, 1)
 
-	// If we will be using a catch-up iterator, wait for the limiter here before
+	// If we will be using a catch-up iterator, wait for the limiters here before
 	// locking raftMu.
 	usingCatchUpIter := false
 	var iterSemRelease func()
 	if !args.Timestamp.IsEmpty() {
 		usingCatchUpIter = true
+		tenantRelease := func() {}
+		if ac := r.store.cfg.KVAdmissionController; ac != nil {
+			tenantID, ok := r.TenantID()
+			if !ok {
+				tenantID = roachpb.SystemTenantID
+			}
+			var err error
+			if tenantRelease, err = ac.AdmitRangefeedCatchUpScan(ctx, tenantID); err != nil {
+				return roachpb.NewError(err)
+			}
+		}
 		alloc, err := r.store.limiters.ConcurrentRangefeedIters.Begin(ctx)
 		if err != nil {
+			tenantRelease()
 			return roachpb.NewError(err)
 		}
 		// Finish the iterator limit if we exit before the iterator finishes.
@@ -200,7 +212,10 @@ func (r *Replica) rangeFeedWithRangeID(
 		// scan.
 		var iterSemReleaseOnce sync.Once
 		iterSemRelease = func() {
-			iterSemReleaseOnce.Do(alloc.Release)
+			iterSemReleaseOnce.Do(func() {
+				alloc.Release()
+				tenantRelease()
+			})
 		}
 		defer iterSemRelease()
 	}
@@ -60,8 +60,10 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlutil"
 	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/admission"
 	"github.com/cockroachdb/cockroach/pkg/util/admission/admissionpb"
+	"github.com/cockroachdb/cockroach/pkg/util/buildutil"
 	"github.com/cockroachdb/cockroach/pkg/util/contextutil"
 	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
 	"github.com/cockroachdb/cockroach/pkg/util/envutil"
@@ -2999,6 +3001,11 @@ func (s *Store) Capacity(ctx context.Context, useCached bool) (roachpb.StoreCapa
 	capacity.QueriesPerSecond = totalQueriesPerSecond
 	capacity.WritesPerSecond = totalWritesPerSecond
 	capacity.L0Sublevels = l0SublevelsMax
+	if ac := s.cfg.KVAdmissionController; ac != nil {
+		if score, ok := ac.GetIOOverloadScores()[s.StoreID()]; ok {
+			capacity.IOOverloadScore = score.Score
+		}
+	}
 	capacity.BytesPerReplica = roachpb.PercentilesFromData(bytesPerReplica)
 	capacity.WritesPerReplica = roachpb.PercentilesFromData(writesPerReplica)
 	s.recordNewPerSecondStats(totalQueriesPerSecond, totalWritesPerSecond)
@@ -3688,37 +3695,233 @@ func min(a, b int) int {
 	return b
 }
 
-// KVAdmissionController provides admission control for the KV layer.
+// KVAdmissionController provides admission control for the KV layer. It is
+// composed of narrower interfaces, so that consumers can depend on, and
+// tests can fake, only the part of admission control that they use.
 type KVAdmissionController interface {
+	BatchAdmitter
+	RangefeedPacerFactory
+	StoreStatsReporter
+	TenantWeightSink
+}
+
+// BatchAdmitter admits the KV work of batch requests.
+type BatchAdmitter interface {
 	// AdmitKVWork must be called before performing KV work.
 	// BatchRequest.AdmissionHeader and BatchRequest.Replica.StoreID must be
 	// populated for admission to work correctly. If err is non-nil, the
-	// returned handle can be ignored. If err is nil, AdmittedKVWorkDone must be
-	// called after the KV work is done executing.
+	// returned handle is nil. If err is nil, AdmittedKVWorkDone must be called
+	// with the handle after the KV work is done executing, see
+	// KVAdmissionHandle. Work may be rejected without being evaluated if the
+	// admission queues are beyond the bounds set by the
+	// admission.kv.load_shedding settings.
 	AdmitKVWork(
 		ctx context.Context, tenantID roachpb.TenantID, ba *roachpb.BatchRequest,
-	) (handle interface{}, err error)
+	) (handle *KVAdmissionHandle, err error)
+	// AdmitKVWorkWithDeadline is like AdmitKVWork, except that the time spent
+	// waiting in the admission queues is additionally bounded by
+	// queueDeadline, independent of the deadline of ctx. Work that is still
+	// waiting at queueDeadline fails with a roachpb.AdmissionOverloadedError,
+	// which allows callers to fail fast and retry elsewhere when a node is
+	// overloaded. A zero queueDeadline imposes no additional bound. The node
+	// uses the AdmissionHeader.QueueDeadline of the batch.
+	AdmitKVWorkWithDeadline(
+		ctx context.Context,
+		tenantID roachpb.TenantID,
+		ba *roachpb.BatchRequest,
+		queueDeadline time.Time,
+	) (handle *KVAdmissionHandle, err error)
 	// AdmittedKVWorkDone is called after the admitted KV work is done
-	// executing.
-	AdmittedKVWorkDone(handle interface{})
+	// executing. It must be called exactly once per handle, and may be called
+	// with a nil handle, in which case it is a no-op. The response is
+	// optional, and is used to account for the keys and bytes read by the
+	// work. It returns the total time the work spent waiting in the admission
+	// queues, which is returned to clients in BatchResponse.AdmissionWait.
+	AdmittedKVWorkDone(
+		handle *KVAdmissionHandle, br *roachpb.BatchResponse,
+	) (admissionWait time.Duration)
+	// RebindStoreAdmission is called with a handle returned by AdmitKVWork
+	// once the store that evaluates the work is resolved, and before the work
+	// executes. AdmitKVWork admits write work against the store in
+	// BatchRequest.Replica.StoreID, so if storeID differs, the work is released
+	// from that store's admission queue and from the KV admission queue, and
+	// admitted to the queue of storeID and then to the KV admission queue
+	// again, in the order of AdmitKVWork. Regardless of the returned error,
+	// AdmittedKVWorkDone must still be called for the handle.
+	RebindStoreAdmission(
+		ctx context.Context, handle *KVAdmissionHandle, storeID roachpb.StoreID,
+	) error
+	// GetWaitingRequests returns the requests that are waiting in the KV
+	// admission queues, for debugging. The requests of each queue are in
+	// decreasing order of the time that they have been waiting.
+	GetWaitingRequests() []KVAdmissionWaitingRequest
+}
+
+// RangefeedPacerFactory paces the work of rangefeeds.
+type RangefeedPacerFactory interface {
+	// AdmitRangefeedCatchUpScan must be called before starting a rangefeed
+	// catch-up scan on behalf of the given tenant. It may block to limit the
+	// number of concurrent catch-up scans per tenant. If err is nil, release
+	// must be called when the catch-up scan is done.
+	AdmitRangefeedCatchUpScan(
+		ctx context.Context, tenantID roachpb.TenantID,
+	) (release func(), err error)
+}
+
+// StoreStatsReporter is informed by the stores of the node of their
+// lifecycle, and of the work that they perform outside of batch requests,
+// such as ingesting snapshots and applying commands proposed by other
+// replicas. In turn, it reports the health of the stores, and of the CPU of
+// the node, as seen by admission control.
+type StoreStatsReporter interface {
+	// AdmitSnapshotBytes is called as the data of an incoming snapshot is
+	// received by the store. It may block to keep the rate at which the store
+	// receives snapshot data within admission.kv.snapshot_ingest.max_rate. If
+	// err is non-nil, the snapshot should be abandoned.
+	AdmitSnapshotBytes(ctx context.Context, storeID roachpb.StoreID, bytes int64) error
+	// AdmitSnapshotIngest must be called before ingesting an incoming snapshot
+	// of the given size into the store. It may block until the store's
+	// admission queue admits the ingestion, which paces snapshots when the
+	// store's LSM is overloaded. If err is non-nil, the snapshot should not be
+	// ingested. Otherwise, SnapshotIngestDone must be called with the handle
+	// once the snapshot was ingested or abandoned.
+	AdmitSnapshotIngest(
+		ctx context.Context,
+		storeID roachpb.StoreID,
+		priority kvserverpb.SnapshotRequest_Priority,
+		bytes int64,
+	) (SnapshotIngestHandle, error)
+	// SnapshotIngestDone is called after the ingestion of a snapshot admitted
+	// by AdmitSnapshotIngest, with the approximate number of bytes that were
+	// ingested into L0, which is 0 if the snapshot was abandoned.
+	SnapshotIngestDone(h SnapshotIngestHandle, ingestedIntoL0Bytes int64)
+	// OnStoreAdded must be called when a store is added to the node after
+	// admission.StoreGrantCoordinators.SetPebbleMetricsProvider was called, so
+	// that writes to it are subject to store admission. The metrics are used
+	// to compute the store's initial tokens. Tenant weights are applied to the
+	// store the next time they are refreshed.
+	OnStoreAdded(ctx context.Context, metrics admission.StoreMetrics)
+	// OnStoreRemoved must be called when a store is removed from the node. Work
+	// waiting for admission to the store is admitted, and the per-store state
+	// is discarded.
+	OnStoreRemoved(storeID roachpb.StoreID)
+	// RaftLogAppendedBytes is called by the raft leader of a range after
+	// appending entries of the given size to the range's raft log in the
+	// store. These bytes are written in addition to the writes of the admitted
+	// KV work, so they are charged to the tenant's store tokens.
+	RaftLogAppendedBytes(storeID roachpb.StoreID, tenantID roachpb.TenantID, bytes int64)
+	// StartWriteAmpFeedback starts periodically sampling the write
+	// amplification of each store from the provider, and adjusting the cost of
+	// the store tokens when admission.kv.write_amp_feedback.enabled is set. The
+	// stopper is used to terminate the sampling.
+	StartWriteAmpFeedback(provider admission.PebbleMetricsProvider, stopper *stop.Stopper)
+	// GetIOOverloadScores returns admission control's view of the IO health of
+	// each store on the node. Stores that are not yet subject to store
+	// admission are absent.
+	GetIOOverloadScores() map[roachpb.StoreID]admission.IOOverloadScore
+	// GetStoreHealth returns admission control's summary of the health of
+	// each store on the node, which is the authoritative overload signal for
+	// components such as circuit breakers and the store rebalancer. Stores
+	// that are not yet subject to store admission are absent.
+	GetStoreHealth() map[roachpb.StoreID]admission.StoreHealth
+	// GetCPUOverloadScore returns admission control's smoothed view of the
+	// CPU health of the node, derived from the KV slot queue and the number of
+	// runnable goroutines. It is gossiped, so that follower reads can be
+	// routed away from nodes whose CPU is saturated.
+	GetCPUOverloadScore() admission.CPUOverloadScore
+	// StoreWritePressure returns the pressure on the write tokens of the
+	// given store. A pressure of 1 or more means that store admission is
+	// throttling writes to the store. It is consulted by ranges to decide
+	// whether to apply their own backpressure to writes.
+	StoreWritePressure(storeID roachpb.StoreID) float64
+	// AdmissionPressure returns the StoreWritePressure of the given store on
+	// a scale from 0 to 100, as returned to clients in
+	// BatchResponse.AdmissionPressure.
+	AdmissionPressure(storeID roachpb.StoreID) int32
+	// AccountFollowerApplication is called before a replica applies committed
+	// commands that were proposed by other replicas, and which therefore were
+	// not admitted on this store. When admission.kv.follower_apply.enabled is
+	// set, the given bytes consume the store's write tokens. It never waits,
+	// since it is called with raftMu held.
+	AccountFollowerApplication(storeID roachpb.StoreID, tenantID roachpb.TenantID, writeBytes int64)
+	// AccountTenantBypassWrite is called by the leaseholder once a batch of a
+	// secondary tenant is evaluated, with the bytes that it writes. A batch
+	// that bypassed admission because of
+	// admission.kv.tenant_system_table_bypass.tenants was not admitted to the
+	// store admission queue, so the bytes consume the store's write tokens,
+	// without waiting for them. It never waits, since the batch is already
+	// evaluated.
+	AccountTenantBypassWrite(
+		storeID roachpb.StoreID,
+		tenantID roachpb.TenantID,
+		ba *roachpb.BatchRequest,
+		writeBytes int64,
+	)
+}
+
+// TenantWeightSink is provided with the weights of the tenants, which
+// determine their share of the admission queues.
+type TenantWeightSink interface {
 	// SetTenantWeightProvider is used to set the provider that will be
 	// periodically polled for weights. The stopper should be used to terminate
-	// the periodic polling.
+	// the periodic polling. If the provider also implements
+	// TenantWeightChangeNotifier, it is additionally polled whenever it
+	// notifies of a change. The provider may be nil, in which case only the
+	// consumption-based weights (if enabled) are used. It must be called at
+	// most once.
 	SetTenantWeightProvider(provider TenantWeightProvider, stopper *stop.Stopper)
+	// SetTenantWeights applies the given weights immediately, rather than
+	// waiting for the provider to be polled. The weights are replaced again
+	// the next time the provider is polled.
+	SetTenantWeights(weights TenantWeights)
+	// GetTenantWeightsState returns the currently applied tenant weights, and
+	// the share of the admission queues used by each tenant.
+	GetTenantWeightsState() TenantWeightsState
+	// GetTenantAdmissionStats returns the KV admission control statistics of
+	// the given tenant on this node.
+	GetTenantAdmissionStats(tenantID roachpb.TenantID) roachpb.TenantAdmissionStats
 }
 
 // TenantWeightProvider can be periodically asked to provide the tenant
-// weights.
+// weights. If GetTenantWeights returns an error, panics, or does not return
+// within admission.kv.tenant_weights.provider_timeout, the last weights
+// successfully returned continue to be used.
 type TenantWeightProvider interface {
-	GetTenantWeights() TenantWeights
+	// GetTenantWeights returns the tenant weights. The context is canceled
+	// when admission.kv.tenant_weights.provider_timeout expires, after which
+	// the result is ignored, so the provider should stop computing the
+	// weights.
+	GetTenantWeights(ctx context.Context) (TenantWeights, error)
+}
+
+// TenantWeightChangeNotifier can optionally be implemented by a
+// TenantWeightProvider that knows when its weights change, so that they are
+// applied without waiting for the next periodic poll.
+type TenantWeightChangeNotifier interface {
+	// TenantWeightsChanged returns a channel that receives a value whenever the
+	// weights returned by GetTenantWeights may have changed.
+	TenantWeightsChanged() <-chan struct{}
 }
 
 // TenantWeights contains the various tenant weights.
 type TenantWeights struct {
 	// Node is the node level tenant ID => weight.
 	Node map[uint64]uint32
+	// NodeBursts is the node level tenant ID => burst allowance, in KV slots.
+	// A tenant can use up to its burst allowance without being penalized
+	// relative to other tenants. Tenants not in the map have no burst
+	// allowance.
+	NodeBursts map[uint64]uint64
 	// Stores contains the per-store tenant weights.
 	Stores []TenantWeightsForStore
+	// Locality is the locality of the node, against which Localities are
+	// resolved.
+	Locality roachpb.Locality
+	// Localities contains locality-scoped tenant weights. Where one of these
+	// matches Locality, it overrides the node and store level weights of the
+	// tenant. These are in addition to the weights specified by the
+	// admission.kv.tenant_weights.locality_overrides setting.
+	Localities []TenantWeightsForLocality
 }
 
 // TenantWeightsForStore contains the tenant weights for a store.
@@ -3726,6 +3929,16 @@ type TenantWeightsForStore struct {
 	roachpb.StoreID
 	// Weights is tenant ID => weight.
 	Weights map[uint64]uint32
+	// Bursts is tenant ID => burst allowance, in store tokens per interval.
+	Bursts map[uint64]uint64
+}
+
+// TenantWeightsForLocality contains the tenant weights for nodes in a
+// locality.
+type TenantWeightsForLocality struct {
+	roachpb.Locality
+	// Weights is tenant ID => weight.
+	Weights map[uint64]uint32
 }
 
 // KVAdmissionControllerImpl implements KVAdmissionController interface.
@@ -3733,146 +3946,844 @@ type KVAdmissionControllerImpl struct {
 	// Admission control queues and coordinators. Both should be nil or non-nil.
 	kvAdmissionQ     *admission.WorkQueue
 	storeGrantCoords *admission.StoreGrantCoordinators
-	settings         *cluster.Settings
+	// kvGrantCoord is the coordinator of kvAdmissionQ, and may be nil.
+	kvGrantCoord *admission.GrantCoordinator
+	settings     *cluster.Settings
+	// kvQueue and storeQueues admit KV work to kvAdmissionQ and
+	// storeGrantCoords respectively, and are non-nil iff kvAdmissionQ is
+	// non-nil. Tests can replace them with fakes.
+	kvQueue     kvAdmissionWorkQueue
+	storeQueues storeAdmissionQueues
+	// metrics can be nil, in which case no metrics are maintained.
+	metrics    *KVAdmissionMetrics
+	timeSource timeutil.TimeSource
+	// bypassAllowlist, tenantBypass, rangefeedLimiter, tenantRateLimiter,
+	// consumption, weightsRefresh, appliedWeights, weightsSettingsChanges and
+	// snapshotLimiter are non-nil iff kvAdmissionQ is non-nil.
+	bypassAllowlist   *kvAdmissionBypassAllowlist
+	tenantBypass      *kvAdmissionTenantBypass
+	rangefeedLimiter  *kvAdmissionRangefeedLimiter
+	tenantRateLimiter *kvAdmissionTenantRateLimiter
+	consumption       *tenantConsumption
+	weightsRefresh    *tenantWeightsRefresh
+	appliedWeights    *appliedTenantWeights
+	// weightsSettingsChanges signals the tenant weights polling loop started
+	// by SetTenantWeightProvider.
+	weightsSettingsChanges *tenantWeightsSettingsChanges
+	snapshotLimiter        *kvAdmissionSnapshotLimiter
+	decisionLog            *kvAdmissionDecisionLog
 }
 
 var _ KVAdmissionController = KVAdmissionControllerImpl{}
 
-type admissionHandle struct {
+// KVAdmissionHandle represents KV work admitted by
+// KVAdmissionController.AdmitKVWork, and is opaque outside of the
+// controller. A handle must be used once: AdmittedKVWorkDone must be called
+// exactly once with it, after which the handle must not be used again. In
+// test builds, misuse of a handle panics. A handle must not be copied, which
+// is checked by go vet.
+type KVAdmissionHandle struct {
+	_                                  util.NoCopy
 	tenantID                           roachpb.TenantID
 	callAdmittedWorkDoneOnKVAdmissionQ bool
-	storeAdmissionQ                    *admission.StoreWorkQueue
-	storeWorkHandle                    admission.StoreWorkHandle
+	// storeID is the store that write work was admitted against, and is zero
+	// if the work is not subject to store admission. storeWorkInfo is retained
+	// so that RebindStoreAdmission can admit the work to another store.
+	storeID         roachpb.StoreID
+	storeWorkInfo   admission.StoreWriteWorkInfo
+	storeAdmissionQ storeAdmissionWorkQueue
+	storeWorkHandle admission.StoreWorkHandle
+	// tenantMetrics is non-nil iff the work was admitted and metrics are
+	// being maintained.
+	tenantMetrics *kvAdmissionTenantMetrics
+	// readOnly is true if the work only reads, in which case the keys and
+	// bytes in its response are accounted for as read.
+	readOnly bool
+	// admissionWait is the total time the work spent waiting in the admission
+	// queues, including when its store admission was rebound.
+	admissionWait time.Duration
+	// done is set to 1 by AdmittedKVWorkDone. A handle is single-use, and
+	// calling AdmittedKVWorkDone more than once would corrupt the accounting
+	// in the admission queues.
+	done int32
+}
+
+// TenantID returns the tenant of the admitted work.
+func (ah *KVAdmissionHandle) TenantID() roachpb.TenantID {
+	return ah.tenantID
+}
+
+// StoreID returns the store that the admission of write work is bound to,
+// see RebindStoreAdmission, and zero for work that is not subject to store
+// admission.
+func (ah *KVAdmissionHandle) StoreID() roachpb.StoreID {
+	return ah.storeID
+}
+
+// kvAdmissionHandleMisusePanics is true if the misuse of a KVAdmissionHandle
+// panics, rather than being counted or returned as an error. It is set in test
+// builds, and tests of the production behavior override it.
+var kvAdmissionHandleMisusePanics = buildutil.CrdbTestBuild
+
+// newKVAdmissionHandle returns a handle for work of the given tenant.
+//
+// NB: Handles are deliberately not recycled. A stale handle that is passed to
+// AdmittedKVWorkDone again after being recycled would not be detected as
+// done, and would release the admission of the work that reused it.
+func newKVAdmissionHandle(tenantID roachpb.TenantID, readOnly bool) *KVAdmissionHandle {
+	return &KVAdmissionHandle{tenantID: tenantID, readOnly: readOnly}
 }
 
-// MakeKVAdmissionController returns a KVAdmissionController. Both parameters
-// must together either be nil or non-nil.
+// MakeKVAdmissionController returns a KVAdmissionController. Both
+// kvAdmissionQ and storeGrantCoords must together either be nil or non-nil.
+// kvGrantCoord is the coordinator of kvAdmissionQ, and is optional, like the
+// metrics and knobs. If timeSource is nil, the system clock is used.
 func MakeKVAdmissionController(
 	kvAdmissionQ *admission.WorkQueue,
+	kvGrantCoord *admission.GrantCoordinator,
 	storeGrantCoords *admission.StoreGrantCoordinators,
 	settings *cluster.Settings,
+	metrics *KVAdmissionMetrics,
+	timeSource timeutil.TimeSource,
+	knobs *KVAdmissionTestingKnobs,
 ) KVAdmissionController {
-	return KVAdmissionControllerImpl{
+	if timeSource == nil {
+		timeSource = timeutil.DefaultTimeSource{}
+	}
+	n := KVAdmissionControllerImpl{
 		kvAdmissionQ:     kvAdmissionQ,
+		kvGrantCoord:     kvGrantCoord,
 		storeGrantCoords: storeGrantCoords,
 		settings:         settings,
-	}
+		metrics:          metrics,
+		timeSource:       timeSource,
+	}
+	if kvAdmissionQ != nil {
+		n.kvQueue = kvAdmissionQ
+		n.storeQueues = storeGrantCoordsQueues{storeGrantCoords}
+		if knobs.injectsStoreErrors() {
+			n.storeQueues = errorInjectingStoreQueues{storeAdmissionQueues: n.storeQueues, knobs: knobs}
+		}
+		n.bypassAllowlist = newKVAdmissionBypassAllowlist(settings)
+		n.tenantBypass = newKVAdmissionTenantBypass(settings)
+		n.rangefeedLimiter = newKVAdmissionRangefeedLimiter(settings)
+		n.tenantRateLimiter = newKVAdmissionTenantRateLimiter(settings, timeSource)
+		n.consumption = newTenantConsumption()
+		n.weightsRefresh = &tenantWeightsRefresh{}
+		n.appliedWeights = &appliedTenantWeights{}
+		n.weightsSettingsChanges = newTenantWeightsSettingsChanges(settings)
+		n.snapshotLimiter = newKVAdmissionSnapshotLimiter(settings, timeSource)
+		n.decisionLog = newKVAdmissionDecisionLog(settings, timeSource)
+		watchStoreL0OverloadThresholds(settings, storeGrantCoords)
+	}
+	return n
 }
 
-// AdmitKVWork implements the KVAdmissionController interface.
+// AdmitKVWork implements the BatchAdmitter interface.
 func (n KVAdmissionControllerImpl) AdmitKVWork(
 	ctx context.Context, tenantID roachpb.TenantID, ba *roachpb.BatchRequest,
-) (handle interface{}, err error) {
-	ah := admissionHandle{tenantID: tenantID}
-	if n.kvAdmissionQ != nil {
-		bypassAdmission := ba.IsAdmin()
-		source := ba.AdmissionHeader.Source
-		if !roachpb.IsSystemTenantID(tenantID.ToUint64()) {
-			// Request is from a SQL node.
-			bypassAdmission = false
-			source = roachpb.AdmissionHeader_FROM_SQL
-		}
-		if source == roachpb.AdmissionHeader_OTHER {
-			bypassAdmission = true
-		}
-		createTime := ba.AdmissionHeader.CreateTime
-		if !bypassAdmission && createTime == 0 {
-			// TODO(sumeer): revisit this for multi-tenant. Specifically, the SQL use
-			// of zero CreateTime needs to be revisited. It should use high priority.
-			createTime = timeutil.Now().UnixNano()
-		}
-		admissionInfo := admission.WorkInfo{
-			TenantID:        tenantID,
-			Priority:        admissionpb.WorkPriority(ba.AdmissionHeader.Priority),
-			CreateTime:      createTime,
-			BypassAdmission: bypassAdmission,
-		}
-		var err error
-		// Don't subject HeartbeatTxnRequest to the storeAdmissionQ. Even though
-		// it would bypass admission, it would consume a slot. When writes are
-		// throttled, we start generating more txn heartbeats, which then consume
-		// all the slots, causing no useful work to happen. We do want useful work
-		// to continue even when throttling since there are often significant
-		// number of tokens available.
-		if ba.IsWrite() && !ba.IsSingleHeartbeatTxnRequest() {
-			ah.storeAdmissionQ = n.storeGrantCoords.TryGetQueueForStore(int32(ba.Replica.StoreID))
-		}
-		admissionEnabled := true
-		if ah.storeAdmissionQ != nil {
-			// TODO(sumeer): Plumb WriteBytes for ingest requests.
-			ah.storeWorkHandle, err = ah.storeAdmissionQ.Admit(
-				ctx, admission.StoreWriteWorkInfo{WorkInfo: admissionInfo})
-			if err != nil {
-				return admissionHandle{}, err
-			}
-			if !ah.storeWorkHandle.AdmissionEnabled() {
-				// Set storeAdmissionQ to nil so that we don't call AdmittedWorkDone
-				// on it. Additionally, the code below will not call
-				// kvAdmissionQ.Admit, and so callAdmittedWorkDoneOnKVAdmissionQ will
-				// stay false.
-				ah.storeAdmissionQ = nil
-			}
+) (handle *KVAdmissionHandle, err error) {
+	return n.AdmitKVWorkWithDeadline(ctx, tenantID, ba, time.Time{})
+}
+
+// AdmitKVWorkWithDeadline implements the BatchAdmitter interface.
+//
+// This is on the path of every batch, so it avoids deferred closures, such
+// that an uncontended admission only allocates its handle.
+func (n KVAdmissionControllerImpl) AdmitKVWorkWithDeadline(
+	ctx context.Context,
+	tenantID roachpb.TenantID,
+	ba *roachpb.BatchRequest,
+	queueDeadline time.Time,
+) (handle *KVAdmissionHandle, err error) {
+	ah := newKVAdmissionHandle(tenantID, ba.IsReadOnly())
+	if n.kvAdmissionQ == nil {
+		return ah, nil
+	}
+	logDecision := n.decisionLog.enabled()
+	var startTime time.Time
+	if n.metrics != nil || logDecision {
+		startTime = n.timeSource.Now()
+	}
+	if n.metrics != nil {
+		// NB: the handle is dropped if the work is not admitted, so
+		// tenantMetrics is only carried over to AdmittedKVWorkDone if the work is
+		// admitted.
+		ah.tenantMetrics = n.metrics.forTenant(tenantID)
+		ah.tenantMetrics.onAdmitStart()
+	}
+	bypassReason, err := n.admitKVWork(ctx, ah, ba, queueDeadline)
+	if n.metrics != nil || logDecision {
+		waitDuration := n.timeSource.Since(startTime)
+		if ah.tenantMetrics != nil {
+			ah.tenantMetrics.onAdmitEnd(waitDuration, err == nil)
 		}
-		if admissionEnabled {
-			ah.callAdmittedWorkDoneOnKVAdmissionQ, err = n.kvAdmissionQ.Admit(ctx, admissionInfo)
-			if err != nil {
-				return admissionHandle{}, err
-			}
+		if logDecision {
+			n.decisionLog.log(ctx, tenantID, ba, bypassReason, waitDuration, err)
 		}
 	}
+	if err != nil {
+		return nil, err
+	}
 	return ah, nil
 }
 
-// AdmittedKVWorkDone implements the KVAdmissionController interface.
-func (n KVAdmissionControllerImpl) AdmittedKVWorkDone(handle interface{}) {
-	ah := handle.(admissionHandle)
-	if ah.callAdmittedWorkDoneOnKVAdmissionQ {
-		n.kvAdmissionQ.AdmittedWorkDone(ah.tenantID)
+// admitKVWork admits the batch to the admission queues on behalf of
+// AdmitKVWorkWithDeadline, recording the admission in the handle. It returns
+// the reason the batch bypassed admission, if any.
+func (n KVAdmissionControllerImpl) admitKVWork(
+	ctx context.Context, ah *KVAdmissionHandle, ba *roachpb.BatchRequest, queueDeadline time.Time,
+) (bypassReason kvAdmissionBypassReason, err error) {
+	tenantID := ah.tenantID
+	// Work that waits beyond queueDeadline, or beyond the maximum wait of its
+	// class, is shed, whichever comes first.
+	callerCtx := ctx
+	shedDeadline, shedReason := queueDeadline, kvAdmissionShedQueueDeadline
+	if maxWait := loadSheddingMaxWait(&n.settings.SV, ba.AdmissionHeader.WorkClass); maxWait > 0 {
+		if d := n.timeSource.Now().Add(maxWait); shedDeadline.IsZero() || d.Before(shedDeadline) {
+			shedDeadline, shedReason = d, kvAdmissionShedMaxWait
+		}
+	}
+	if !shedDeadline.IsZero() {
+		// NB: ctx is only used for waiting in the admission queues below, and
+		// is not retained by the handle.
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, shedDeadline)
+		defer cancel()
+	}
+	if ba.IsAdmin() {
+		bypassReason = kvAdmissionBypassAdmin
+	}
+	source := ba.AdmissionHeader.Source
+	if !roachpb.IsSystemTenantID(tenantID.ToUint64()) {
+		// Request is from a SQL node.
+		if n.tenantBypass.bypass(tenantID, ba) {
+			// The WorkQueue ignores BypassAdmission for secondary tenants, so
+			// the request skips the queues altogether. It does not hold a slot,
+			// which is acceptable since these requests are few and small, but
+			// its writes are charged to the store once evaluated, see
+			// AccountTenantBypassWrite.
+			bypassReason = kvAdmissionBypassTenant
+			n.metrics.onBypass(bypassReason)
+			return bypassReason, nil
+		}
+		bypassReason = kvAdmissionNoBypass
+		source = roachpb.AdmissionHeader_FROM_SQL
+		// The rate limits are enforced before queueing, so that a tenant that
+		// is over its limit does not occupy a place in the queues.
+		if err := n.tenantRateLimiter.wait(ctx, tenantID, ba); err != nil {
+			return bypassReason, err
+		}
+	}
+	if bypassReason == kvAdmissionNoBypass && source == roachpb.AdmissionHeader_OTHER {
+		bypassReason = kvAdmissionBypassOtherSource
+	}
+	if bypassReason == kvAdmissionNoBypass && roachpb.IsSystemTenantID(tenantID.ToUint64()) &&
+		n.bypassAllowlist.bypass(ba) {
+		bypassReason = kvAdmissionBypassAllowlist
+	}
+	bypassAdmission := bypassReason != kvAdmissionNoBypass
+	n.metrics.onBypass(bypassReason)
+	createTime := ba.AdmissionHeader.CreateTime
+	if !bypassAdmission && createTime == 0 {
+		// TODO(sumeer): revisit this for multi-tenant. Specifically, the SQL use
+		// of zero CreateTime needs to be revisited. It should use high priority.
+		createTime = n.timeSource.Now().UnixNano()
+	}
+	priority := workClassPriority(&n.settings.SV, ba.AdmissionHeader)
+	if roachpb.IsSystemTenantID(tenantID.ToUint64()) && isNodeLivenessBatch(ba) {
+		// Writes to the liveness records must not be starved behind user
+		// traffic, since failing them causes ranges to become unavailable,
+		// which only makes an overload worse. NodeLiveness heartbeats are sent
+		// with the OTHER source, and bypass the admission queues entirely,
+		// since even HighPri work waits for store tokens. This boosts the
+		// other writers of the liveness records, whose work is admitted. Lease
+		// requests are evaluated directly by the replica and never reach
+		// admission control.
+		priority = admissionpb.HighPri
+	}
+	if ba.AdmissionHeader.WorkClass == roachpb.AdmissionHeader_BACKGROUND &&
+		priority > admissionpb.TTLLowPri {
+		priority = admissionpb.TTLLowPri
+	}
+	var queueStartTime time.Time
+	if !bypassAdmission {
+		queueStartTime = n.timeSource.Now()
+	}
+	admissionInfo := admission.WorkInfo{
+		TenantID:        tenantID,
+		Priority:        priority,
+		CreateTime:      createTime,
+		BypassAdmission: bypassAdmission,
+		FairnessKey:     ba.AdmissionHeader.FairnessKey,
+		DisableEpochLIFO: !epochLIFOEnabledForWorkClass(
+			&n.settings.SV, ba.AdmissionHeader.WorkClass),
+	}
+	// Don't subject HeartbeatTxnRequest to the storeAdmissionQ. Even though
+	// it would bypass admission, it would consume a slot. When writes are
+	// throttled, we start generating more txn heartbeats, which then consume
+	// all the slots, causing no useful work to happen. We do want useful work
+	// to continue even when throttling since there are often significant
+	// number of tokens available.
+	if ba.IsWrite() && ba.IsSingleHeartbeatTxnRequest() {
+		n.metrics.onBypass(kvAdmissionBypassStoreHeartbeatTxn)
+	} else if ba.IsWrite() {
+		ah.storeID = ba.Replica.StoreID
+		ah.storeWorkInfo = admission.StoreWriteWorkInfo{
+			WorkInfo:   admissionInfo,
+			WriteBytes: rangeDeletionWriteBytes(ba),
+		}
+		ah.storeAdmissionQ = n.storeQueues.queueForStore(ba.Replica.StoreID)
+	}
+	err = n.admitToQueues(
+		callerCtx, ctx, ah, ba.AdmissionHeader.WorkClass, admissionInfo, shedDeadline, shedReason)
+	if !bypassAdmission {
+		ah.admissionWait = n.timeSource.Since(queueStartTime)
+		if n.metrics != nil {
+			n.metrics.waitDurationsForPriority(priority).RecordValue(ah.admissionWait.Nanoseconds())
+		}
+	}
+	if err != nil {
+		return bypassReason, err
+	}
+	if tenantWeightsConsumptionBasedEnabled.Get(&n.settings.SV) {
+		n.consumption.onAdmitted(tenantID)
+	}
+	return bypassReason, nil
+}
+
+// admitToQueues admits the work to the store admission queue of the handle,
+// if any, and then to the KV admission queue. An error caused by ctx
+// expiring at shedDeadline, while callerCtx is still live, is returned as a
+// load shedding error for shedReason, see loadShedError.
+func (n KVAdmissionControllerImpl) admitToQueues(
+	callerCtx, ctx context.Context,
+	ah *KVAdmissionHandle,
+	workClass roachpb.AdmissionHeader_WorkClass,
+	admissionInfo admission.WorkInfo,
+	shedDeadline time.Time,
+	shedReason kvAdmissionShedReason,
+) (err error) {
+	if !admissionInfo.BypassAdmission {
+		if err := n.checkQueueLengths(workClass, ah.storeAdmissionQ); err != nil {
+			return err
+		}
 	}
+	admissionEnabled := true
 	if ah.storeAdmissionQ != nil {
-		// TODO(sumeer): Plumb ingestedIntoL0Bytes and handle error return value.
-		_ = ah.storeAdmissionQ.AdmittedWorkDone(ah.storeWorkHandle, 0)
+		// TODO(sumeer): Plumb WriteBytes for ingest requests.
+		ah.storeWorkHandle, err = ah.storeAdmissionQ.Admit(ctx, ah.storeWorkInfo)
+		if err != nil {
+			return n.loadShedError(callerCtx, ctx, shedDeadline, shedReason, err)
+		}
+		if !ah.storeWorkHandle.AdmissionEnabled() {
+			// Set storeAdmissionQ to nil so that we don't call AdmittedWorkDone
+			// on it. Additionally, the code below will not call
+			// kvAdmissionQ.Admit, and so callAdmittedWorkDoneOnKVAdmissionQ will
+			// stay false.
+			ah.storeAdmissionQ = nil
+		}
+	}
+	if admissionEnabled {
+		if err := n.admitToKVQueue(ctx, ah, admissionInfo); err != nil {
+			// The work will not be executed, so unwind its store admission.
+			n.releaseStoreAdmission(ah)
+			return n.loadShedError(callerCtx, ctx, shedDeadline, shedReason, err)
+		}
+	}
+	return nil
+}
+
+// admitToKVQueue admits the work of the handle to the KV admission queue,
+// through the fast path if it is enabled.
+func (n KVAdmissionControllerImpl) admitToKVQueue(
+	ctx context.Context, ah *KVAdmissionHandle, admissionInfo admission.WorkInfo,
+) (err error) {
+	if kvAdmissionFastPathEnabled.Get(&n.settings.SV) && n.kvQueue.TryFastAdmit(admissionInfo) {
+		ah.callAdmittedWorkDoneOnKVAdmissionQ = true
+		return nil
+	}
+	ah.callAdmittedWorkDoneOnKVAdmissionQ, err = n.kvQueue.Admit(ctx, admissionInfo)
+	return err
+}
+
+// isNodeLivenessBatch returns true if the batch contains a request touching
+// the node liveness keyspace.
+func isNodeLivenessBatch(ba *roachpb.BatchRequest) bool {
+	for _, ru := range ba.Requests {
+		if keys.NodeLivenessSpan.ContainsKey(ru.GetInner().Header().Key) {
+			return true
+		}
+	}
+	return false
+}
+
+// readKeysAndBytes returns the number of keys and bytes read by a batch,
+// from the headers of its responses. The bytes are only populated by the
+// requests that support them, see ResponseHeader.NumBytes.
+func readKeysAndBytes(br *roachpb.BatchResponse) (keys, bytes int64) {
+	for _, ru := range br.Responses {
+		h := ru.GetInner().Header()
+		keys += h.NumKeys
+		bytes += h.NumBytes
+	}
+	return keys, bytes
+}
+
+// rangeDeletionWriteBytes returns the write bytes to charge to the store for
+// a batch that deletes spans of keys, from the estimate in its
+// AdmissionHeader. It returns zero for other batches, whose write bytes are
+// estimated by the store admission queue.
+func rangeDeletionWriteBytes(ba *roachpb.BatchRequest) int64 {
+	if ba.AdmissionHeader.EstimatedWriteBytes <= 0 {
+		return 0
+	}
+	for _, ru := range ba.Requests {
+		switch ru.GetInner().Method() {
+		case roachpb.ClearRange, roachpb.DeleteRange:
+			return ba.AdmissionHeader.EstimatedWriteBytes
+		}
+	}
+	return 0
+}
+
+// AdmittedKVWorkDone implements the BatchAdmitter interface.
+func (n KVAdmissionControllerImpl) AdmittedKVWorkDone(
+	ah *KVAdmissionHandle, br *roachpb.BatchResponse,
+) (admissionWait time.Duration) {
+	if ah == nil {
+		// AdmitKVWork returned an error.
+		return 0
+	}
+	if !atomic.CompareAndSwapInt32(&ah.done, 0, 1) {
+		if kvAdmissionHandleMisusePanics {
+			panic(errors.AssertionFailedf("AdmittedKVWorkDone called twice for the same handle"))
+		}
+		if n.metrics != nil {
+			n.metrics.DoubleWorkDone.Inc(1)
+		}
+		return 0
+	}
+	if ah.tenantMetrics != nil {
+		ah.tenantMetrics.onWorkDone()
+		if ah.readOnly && br != nil {
+			ah.tenantMetrics.onRead(readKeysAndBytes(br))
+		}
+	}
+	if ah.callAdmittedWorkDoneOnKVAdmissionQ {
+		n.kvQueue.AdmittedWorkDone(ah.tenantID)
+	}
+	n.releaseStoreAdmission(ah)
+	return ah.admissionWait
+}
+
+// RebindStoreAdmission implements the BatchAdmitter interface.
+func (n KVAdmissionControllerImpl) RebindStoreAdmission(
+	ctx context.Context, ah *KVAdmissionHandle, storeID roachpb.StoreID,
+) error {
+	if ah == nil {
+		return nil
+	}
+	if atomic.LoadInt32(&ah.done) != 0 {
+		err := errors.AssertionFailedf("RebindStoreAdmission called after AdmittedKVWorkDone")
+		if kvAdmissionHandleMisusePanics {
+			panic(err)
+		}
+		return err
+	}
+	if ah.storeID == 0 || ah.storeID == storeID {
+		return nil
+	}
+	log.VEventf(ctx, 2, "rebinding store admission from s%d to s%d", ah.storeID, storeID)
+	if n.metrics != nil {
+		n.metrics.StoreRebinds.Inc(1)
+	}
+	// The KV slot is released as well, rather than held while waiting for the
+	// tokens of storeID, such that work waiting on a store does not occupy
+	// the slots of the node.
+	if ah.callAdmittedWorkDoneOnKVAdmissionQ {
+		n.kvQueue.AdmittedWorkDone(ah.tenantID)
+		ah.callAdmittedWorkDoneOnKVAdmissionQ = false
+	}
+	n.releaseStoreAdmission(ah)
+	ah.storeID = storeID
+	queueStartTime := n.timeSource.Now()
+	err := n.rebindToQueues(ctx, ah, storeID)
+	ah.admissionWait += n.timeSource.Since(queueStartTime)
+	return err
+}
+
+// rebindToQueues admits the work of the handle to the store admission queue
+// of storeID, if any, and then to the KV admission queue.
+func (n KVAdmissionControllerImpl) rebindToQueues(
+	ctx context.Context, ah *KVAdmissionHandle, storeID roachpb.StoreID,
+) error {
+	if storeAdmissionQ := n.storeQueues.queueForStore(storeID); storeAdmissionQ != nil {
+		storeWorkHandle, err := storeAdmissionQ.Admit(ctx, ah.storeWorkInfo)
+		if err != nil {
+			return err
+		}
+		if !storeWorkHandle.AdmissionEnabled() {
+			// As in admitToQueues, the work is not admitted to the KV admission
+			// queue either.
+			return nil
+		}
+		ah.storeAdmissionQ, ah.storeWorkHandle = storeAdmissionQ, storeWorkHandle
+	}
+	return n.admitToKVQueue(ctx, ah, ah.storeWorkInfo.WorkInfo)
+}
+
+// releaseStoreAdmission releases the work of the handle from its store
+// admission queue, if any. The work is released even if the queue returns an
+// error, which only reports inaccurate accounting, so the error is counted
+// rather than returned.
+func (n KVAdmissionControllerImpl) releaseStoreAdmission(ah *KVAdmissionHandle) {
+	if ah.storeAdmissionQ == nil {
+		return
+	}
+	// TODO(sumeer): Plumb ingestedIntoL0Bytes.
+	if err := ah.storeAdmissionQ.AdmittedWorkDone(ah.storeWorkHandle, 0); err != nil &&
+		n.metrics != nil {
+		n.metrics.StoreWorkDoneErrors.Inc(1)
+	}
+	ah.storeAdmissionQ = nil
+}
+
+// AdmitRangefeedCatchUpScan implements the RangefeedPacerFactory interface.
+func (n KVAdmissionControllerImpl) AdmitRangefeedCatchUpScan(
+	ctx context.Context, tenantID roachpb.TenantID,
+) (release func(), err error) {
+	if n.rangefeedLimiter == nil {
+		return func() {}, nil
+	}
+	return n.rangefeedLimiter.begin(ctx, tenantID)
+}
+
+// tenantWeightsPollInterval is the interval at which the
+// TenantWeightProvider is polled.
+var tenantWeightsPollInterval = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"admission.kv.tenant_weights.poll_interval",
+	"the interval at which tenant weights are recomputed for KV admission control",
+	10*time.Minute,
+	func(v time.Duration) error {
+		const minInterval, maxInterval = 10 * time.Second, 24 * time.Hour
+		if v < minInterval || v > maxInterval {
+			return errors.Errorf("cannot be set to a value outside [%s, %s]: %s",
+				minInterval, maxInterval, v)
+		}
+		return nil
+	},
+)
+
+// tenantWeightsProviderTimeout bounds the time spent polling the
+// TenantWeightProvider.
+var tenantWeightsProviderTimeout = settings.RegisterDurationSetting(
+	settings.SystemOnly,
+	"admission.kv.tenant_weights.provider_timeout",
+	"the maximum time to wait for tenant weights to be computed for KV admission control, "+
+		"after which the last computed weights continue to be used",
+	time.Minute,
+	settings.PositiveDuration,
+)
+
+// tenantWeightsSettingsChanges signals the tenant weights polling loop when
+// the settings it depends on change. Setting callbacks cannot be unregistered,
+// so they are registered once, when the controller is made, rather than by
+// SetTenantWeightProvider.
+type tenantWeightsSettingsChanges struct {
+	// intervalC is signaled when admission.kv.tenant_weights.poll_interval
+	// changes.
+	intervalC chan struct{}
+	// pinnedC is signaled when admission.kv.tenant_weights.pinned changes.
+	// Pinned weights are applied immediately, rather than at the next poll,
+	// since they are typically changed in response to an incident.
+	pinnedC chan struct{}
+}
+
+func newTenantWeightsSettingsChanges(st *cluster.Settings) *tenantWeightsSettingsChanges {
+	c := &tenantWeightsSettingsChanges{
+		intervalC: make(chan struct{}, 1),
+		pinnedC:   make(chan struct{}, 1),
+	}
+	signal := func(ch chan struct{}) func(context.Context) {
+		return func(context.Context) {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
 	}
+	tenantWeightsPollInterval.SetOnChange(&st.SV, signal(c.intervalC))
+	tenantWeightsPinned.SetOnChange(&st.SV, signal(c.pinnedC))
+	return c
 }
 
-// SetTenantWeightProvider implements the KVAdmissionController interface.
+// SetTenantWeightProvider implements the TenantWeightSink interface.
 func (n KVAdmissionControllerImpl) SetTenantWeightProvider(
 	provider TenantWeightProvider, stopper *stop.Stopper,
 ) {
-	go func() {
-		const weightCalculationPeriod = 10 * time.Minute
-		ticker := time.NewTicker(weightCalculationPeriod)
+	if n.kvAdmissionQ == nil {
+		// There are no admission queues to apply the weights to.
+		return
+	}
+	var changedC <-chan struct{}
+	if notifier, ok := provider.(TenantWeightChangeNotifier); ok {
+		changedC = notifier.TenantWeightsChanged()
+	}
+	// An error is returned only if the stopper is quiescing, in which case
+	// there is nothing to poll for.
+	ctx := context.Background()
+	_ = stopper.RunAsyncTask(ctx, "kv-admission-tenant-weights", func(ctx context.Context) {
+		ticker := n.timeSource.NewTicker(tenantWeightsPollInterval.Get(&n.settings.SV))
+		defer ticker.Stop()
 		// Used for short-circuiting the weights calculation if all weights are
 		// disabled.
 		allWeightsDisabled := false
-		for {
-			select {
-			case <-ticker.C:
-				kvDisabled := !admission.KVTenantWeightsEnabled.Get(&n.settings.SV)
-				kvStoresDisabled := !admission.KVStoresTenantWeightsEnabled.Get(&n.settings.SV)
-				if allWeightsDisabled && kvDisabled && kvStoresDisabled {
-					// Have already transitioned to disabled, so noop.
-					continue
-				}
-				weights := provider.GetTenantWeights()
-				if kvDisabled {
-					weights.Node = nil
+		// The last weights successfully returned by the provider, and when they
+		// were returned. These are used if the provider fails. lastGoodTime is
+		// zero until the provider first returns weights.
+		var lastGoodWeights TenantWeights
+		var lastGoodTime time.Time
+		// Set while a call to the provider has not returned.
+		var providerInFlight int32
+		updateWeights := func() {
+			kvDisabled := !admission.KVTenantWeightsEnabled.Get(&n.settings.SV)
+			kvStoresDisabled := !admission.KVStoresTenantWeightsEnabled.Get(&n.settings.SV)
+			if allWeightsDisabled && kvDisabled && kvStoresDisabled {
+				// Have already transitioned to disabled, so noop.
+				return
+			}
+			var weights TenantWeights
+			if provider != nil {
+				if w, err := n.getProviderTenantWeights(
+					ctx, provider, &providerInFlight, stopper); err != nil {
+					if lastGoodTime.IsZero() {
+						log.Warningf(ctx, "no tenant weights yet: %v", err)
+					} else {
+						log.Warningf(ctx, "using tenant weights from %s ago: %v",
+							n.timeSource.Since(lastGoodTime), err)
+					}
+					if n.metrics != nil {
+						n.metrics.TenantWeightsProviderErrors.Inc(1)
+					}
+				} else {
+					lastGoodWeights, lastGoodTime = w, n.timeSource.Now()
 				}
-				n.kvAdmissionQ.SetTenantWeights(weights.Node)
-				for _, storeWeights := range weights.Stores {
-					q := n.storeGrantCoords.TryGetQueueForStore(int32(storeWeights.StoreID))
-					if q != nil {
-						if kvStoresDisabled {
-							storeWeights.Weights = nil
-						}
-						q.SetTenantWeights(storeWeights.Weights)
+				weights = lastGoodWeights
+				if n.metrics != nil {
+					staleness := int64(-1)
+					if !lastGoodTime.IsZero() {
+						staleness = n.timeSource.Since(lastGoodTime).Nanoseconds()
 					}
+					n.metrics.TenantWeightsStaleness.Update(staleness)
 				}
-				allWeightsDisabled = kvDisabled && kvStoresDisabled
+			}
+			if tenantWeightsConsumptionBasedEnabled.Get(&n.settings.SV) {
+				weights.Node = n.consumption.computeWeights()
+			}
+			n.SetTenantWeights(weights)
+			allWeightsDisabled = kvDisabled && kvStoresDisabled
+		}
+		for {
+			select {
+			case <-ticker.Ch():
+				updateWeights()
+			case <-changedC:
+				updateWeights()
+			case <-n.weightsSettingsChanges.pinnedC:
+				updateWeights()
+			case <-n.weightsSettingsChanges.intervalC:
+				ticker.Reset(tenantWeightsPollInterval.Get(&n.settings.SV))
 			case <-stopper.ShouldQuiesce():
-				ticker.Stop()
 				return
 			}
 		}
+	})
+}
+
+// getProviderTenantWeights polls the provider for tenant weights. An error is
+// returned if the provider returns an error, panics, or does not return within
+// admission.kv.tenant_weights.provider_timeout. inFlight is set while the
+// provider is being called, and the provider is not called again until a
+// previous call that timed out returns. The provider is passed a context that
+// is canceled when this method returns, including on timeout, and its latency
+// is recorded when it returns.
+func (n KVAdmissionControllerImpl) getProviderTenantWeights(
+	ctx context.Context, provider TenantWeightProvider, inFlight *int32, stopper *stop.Stopper,
+) (TenantWeights, error) {
+	if !atomic.CompareAndSwapInt32(inFlight, 0, 1) {
+		return TenantWeights{}, errors.New("previous call to tenant weight provider has not returned")
+	}
+	timeout := tenantWeightsProviderTimeout.Get(&n.settings.SV)
+	// NB: The timeout is enforced using timeSource below, and the context is
+	// canceled when we stop waiting. The deadline on the context is only a
+	// backstop, for use by the provider.
+	providerCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	type result struct {
+		weights TenantWeights
+		err     error
+	}
+	// Buffered, so that a provider that returns after the timeout does not
+	// leak the goroutine.
+	resultC := make(chan result, 1)
+	// NB: This does not use a stopper task, since a hung provider would then
+	// prevent the stopper from stopping.
+	go func() {
+		var res result
+		start := n.timeSource.Now()
+		defer func() {
+			if n.metrics != nil {
+				n.metrics.TenantWeightsProviderLatency.RecordValue(n.timeSource.Since(start).Nanoseconds())
+			}
+			atomic.StoreInt32(inFlight, 0)
+			if r := recover(); r != nil {
+				res = result{err: errors.Errorf("tenant weight provider panicked: %v", r)}
+			}
+			resultC <- res
+		}()
+		res.weights, res.err = provider.GetTenantWeights(providerCtx)
 	}()
+	timer := n.timeSource.NewTimer()
+	defer timer.Stop()
+	timer.Reset(timeout)
+	select {
+	case res := <-resultC:
+		return res.weights, res.err
+	case <-timer.Ch():
+		timer.MarkRead()
+		return TenantWeights{}, errors.Errorf("tenant weight provider did not return within %s", timeout)
+	case <-stopper.ShouldQuiesce():
+		return TenantWeights{}, stop.ErrUnavailable
+	}
+}
+
+// SetTenantWeights implements the TenantWeightSink interface.
+func (n KVAdmissionControllerImpl) SetTenantWeights(weights TenantWeights) {
+	if n.kvAdmissionQ == nil {
+		return
+	}
+	kvDisabled := !admission.KVTenantWeightsEnabled.Get(&n.settings.SV)
+	kvStoresDisabled := !admission.KVStoresTenantWeightsEnabled.Get(&n.settings.SV)
+	localities := weights.Localities
+	if overrides, err := parseTenantWeightsLocalityOverrides(
+		tenantWeightsLocalityOverrides.Get(&n.settings.SV)); err == nil {
+		localities = append(localities[:len(localities):len(localities)], overrides...)
+	}
+	localityWeights := resolveLocalityTenantWeights(weights.Locality, localities)
+	// The setting is validated, so an error is not expected, and is ignored.
+	pinnedWeights, _ := parseTenantWeightsPinned(tenantWeightsPinned.Get(&n.settings.SV))
+	bandThresholds, _ := parseTenantWeightsPriorityBands(tenantWeightsPriorityBands.Get(&n.settings.SV))
+	if kvDisabled {
+		weights.Node = nil
+		weights.NodeBursts = nil
+	} else {
+		weights.Node = applyLocalityTenantWeights(weights.Node, localityWeights)
+		weights.Node = applyPinnedTenantWeights(weights.Node, pinnedWeights)
+	}
+	// Only the weights that changed since the last call are pushed into the
+	// queues.
+	ctx := context.Background()
+	prev, delta := n.appliedWeights.applyNode(n.kvAdmissionQ, weights.Node)
+	n.logTenantWeightChanges(ctx, 0 /* storeID */, prev, delta, tenantWeightSources{
+		disabled: kvDisabled,
+		locality: localityWeights,
+		pinned:   pinnedWeights,
+	})
+	n.kvAdmissionQ.SetTenantBursts(weights.NodeBursts)
+	if kvDisabled {
+		n.kvAdmissionQ.SetTenantPriorityBands(nil)
+	} else {
+		n.kvAdmissionQ.SetTenantPriorityBands(tenantPriorityBands(weights.Node, bandThresholds))
+	}
+	for _, storeWeights := range weights.Stores {
+		q := n.storeGrantCoords.TryGetQueueForStore(int32(storeWeights.StoreID))
+		if q != nil {
+			if kvStoresDisabled {
+				storeWeights.Weights = nil
+				storeWeights.Bursts = nil
+			} else {
+				storeWeights.Weights = applyLocalityTenantWeights(storeWeights.Weights, localityWeights)
+				storeWeights.Weights = applyPinnedTenantWeights(storeWeights.Weights, pinnedWeights)
+			}
+			prev, delta := n.appliedWeights.applyStore(storeWeights.StoreID, q, storeWeights.Weights)
+			n.logTenantWeightChanges(ctx, storeWeights.StoreID, prev, delta, tenantWeightSources{
+				disabled: kvStoresDisabled,
+				locality: localityWeights,
+				pinned:   pinnedWeights,
+			})
+			q.SetTenantBursts(storeWeights.Bursts)
+			if kvStoresDisabled {
+				q.SetTenantPriorityBands(nil)
+			} else {
+				q.SetTenantPriorityBands(tenantPriorityBands(storeWeights.Weights, bandThresholds))
+			}
+		}
+	}
+	n.weightsRefresh.record(n.timeSource.Now(), weights.Stores)
+}
+
+// GetTenantWeightsState implements the TenantWeightSink interface.
+func (n KVAdmissionControllerImpl) GetTenantWeightsState() TenantWeightsState {
+	if n.kvAdmissionQ == nil {
+		return TenantWeightsState{}
+	}
+	lastRefresh, storeIDs := n.weightsRefresh.get()
+	state := TenantWeightsState{
+		LastRefresh: lastRefresh,
+		Node:        makeTenantWeightStates(n.kvAdmissionQ.GetTenantUsage()),
+	}
+	for _, storeID := range storeIDs {
+		q := n.storeGrantCoords.TryGetQueueForStore(int32(storeID))
+		if q == nil {
+			continue
+		}
+		state.Stores = append(state.Stores, TenantWeightsStateForStore{
+			StoreID: storeID,
+			Tenants: makeTenantWeightStates(q.GetTenantUsage()),
+		})
+	}
+	return state
+}
+
+// GetTenantAdmissionStats implements the TenantWeightSink interface.
+func (n KVAdmissionControllerImpl) GetTenantAdmissionStats(
+	tenantID roachpb.TenantID,
+) roachpb.TenantAdmissionStats {
+	var stats roachpb.TenantAdmissionStats
+	if n.kvAdmissionQ == nil {
+		return stats
+	}
+	// The statistics are derived from the per-tenant metrics, so they are
+	// unavailable if the tenant is beyond the cardinality limit of the metrics.
+	if n.metrics != nil {
+		if tm, ok := n.metrics.lookupTenant(tenantID); ok {
+			stats.Admitted = tm.admitted.Value()
+			stats.Rejected = tm.rejected.Value()
+			stats.WaitDurationNanos = tm.waitDurationSum.Value()
+		}
+	}
+	stats.RateLimited = n.tenantRateLimiter.rateLimited(tenantID)
+	return stats
+}
+
+// applyLocalityTenantWeights overrides the weights of the tenants that have
+// a locality-scoped weight. The weights map is not modified, since it may be
+// reused by the caller, and a copy is returned instead. As in
+// applyPinnedTenantWeights, a nil weights map, which gives all tenants the
+// same weight, is replaced by one containing only the locality-scoped
+// weights.
+func applyLocalityTenantWeights(weights, localityWeights map[uint64]uint32) map[uint64]uint32 {
+	if len(localityWeights) == 0 {
+		return weights
+	}
+	result := make(map[uint64]uint32, len(weights)+len(localityWeights))
+	for tenantID, weight := range weights {
+		result[tenantID] = weight
+	}
+	for tenantID, weight := range localityWeights {
+		result[tenantID] = weight
+	}
+	return result
 }
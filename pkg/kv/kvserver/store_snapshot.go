@@ -151,6 +151,10 @@ type kvBatchSnapshotStrategy struct {
 	// Only used on the receiver side.
 	scratch *SSTSnapshotStorageScratch
 	st      *cluster.Settings
+	// admitBytes, if set, is called with the size of each received KV batch,
+	// and may block to pace the receipt of the snapshot. Only used on the
+	// receiver side.
+	admitBytes func(ctx context.Context, bytes int64) error
 }
 
 // multiSSTWriter is a wrapper around RocksDBSstFileWriter and
@@ -293,6 +297,11 @@ func (kvSS *kvBatchSnapshotStrategy) Receive(
 
 		if req.KVBatch != nil {
 			recordBytesReceived(int64(len(req.KVBatch)))
+			if kvSS.admitBytes != nil {
+				if err := kvSS.admitBytes(ctx, int64(len(req.KVBatch))); err != nil {
+					return noSnap, err
+				}
+			}
 			batchReader, err := storage.NewRocksDBBatchReader(req.KVBatch)
 			if err != nil {
 				return noSnap, errors.Wrap(err, "failed to decode batch")
@@ -757,11 +766,17 @@ func (s *Store) receiveSnapshot(
 			return sendSnapshotError(stream, err)
 		}
 
-		ss = &kvBatchSnapshotStrategy{
+		kvSS := &kvBatchSnapshotStrategy{
 			scratch:      s.sstSnapshotStorage.NewScratchSpace(header.State.Desc.RangeID, snapUUID),
 			sstChunkSize: snapshotSSTWriteSyncRate.Get(&s.cfg.Settings.SV),
 			st:           s.ClusterSettings(),
 		}
+		if ac := s.cfg.KVAdmissionController; ac != nil {
+			kvSS.admitBytes = func(ctx context.Context, bytes int64) error {
+				return ac.AdmitSnapshotBytes(ctx, s.StoreID(), bytes)
+			}
+		}
+		ss = kvSS
 		defer ss.Close(ctx)
 	default:
 		return sendSnapshotError(stream,
@@ -798,6 +813,20 @@ func (s *Store) receiveSnapshot(
 	}
 	inSnap.placeholder = placeholder
 
+	// Wait for admission before applying the snapshot, rather than while
+	// ingesting it, since application holds the replica's raftMu. The
+	// admission is done once the snapshot was applied, or abandoned, with the
+	// bytes that the application ingested into L0.
+	if ac := s.cfg.KVAdmissionController; ac != nil {
+		handle, err := ac.AdmitSnapshotIngest(ctx, s.StoreID(), header.Priority, inSnap.DataSize)
+		if err != nil {
+			return sendSnapshotError(stream, errors.Wrap(err, "failed to admit snapshot"))
+		}
+		var ingestedIntoL0Bytes int64
+		inSnap.ingestedIntoL0Bytes = &ingestedIntoL0Bytes
+		defer func() { ac.SnapshotIngestDone(handle, ingestedIntoL0Bytes) }()
+	}
+
 	// Use a background context for applying the snapshot, as handleRaftReady is
 	// not prepared to deal with arbitrary context cancellation. Also, we've
 	// already received the entire snapshot here, so there's no point in
@@ -1003,14 +1032,21 @@ var snapshotSSTWriteSyncRate = settings.RegisterByteSizeSetting(
 func snapshotRateLimit(
 	st *cluster.Settings, priority kvserverpb.SnapshotRequest_Priority,
 ) (rate.Limit, error) {
+	var limit rate.Limit
 	switch priority {
 	case kvserverpb.SnapshotRequest_RECOVERY:
-		return rate.Limit(recoverySnapshotRate.Get(&st.SV)), nil
+		limit = rate.Limit(recoverySnapshotRate.Get(&st.SV))
 	case kvserverpb.SnapshotRequest_REBALANCE:
-		return rate.Limit(rebalanceSnapshotRate.Get(&st.SV)), nil
+		limit = rate.Limit(rebalanceSnapshotRate.Get(&st.SV))
 	default:
 		return 0, errors.Errorf("unknown snapshot priority: %s", priority)
 	}
+	if snapshotIngestMaxRate.Get(&st.SV) != 0 {
+		// The receiving store paces the snapshot instead, see
+		// KVAdmissionController.AdmitSnapshotBytes.
+		limit = rate.Inf
+	}
+	return limit, nil
 }
 
 // SendEmptySnapshot creates an OutgoingSnapshot for the input range
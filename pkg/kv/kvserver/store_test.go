@@ -3075,6 +3075,13 @@ func TestSnapshotRateLimit(t *testing.T) {
 			}
 		})
 	}
+
+	// The sender does not limit the rate when the receiver paces snapshots.
+	st := cluster.MakeTestingClusterSettings()
+	snapshotIngestMaxRate.Override(context.Background(), &st.SV, 16<<20)
+	limit, err := snapshotRateLimit(st, kvserverpb.SnapshotRequest_REBALANCE)
+	require.NoError(t, err)
+	require.Equal(t, rate.Inf, limit)
 }
 
 // TestManuallyEnqueueUninitializedReplica makes sure that uninitialized
@@ -42,6 +42,7 @@ type StoreTestingKnobs struct {
 	TenantRateKnobs         tenantrate.TestingKnobs
 	StorageKnobs            storage.TestingKnobs
 	AllocatorKnobs          *allocator.TestingKnobs
+	KVAdmissionKnobs        KVAdmissionTestingKnobs
 
 	// TestingRequestFilter is called before evaluating each request on a
 	// replica. The filter is run before the request acquires latches, so
@@ -164,6 +164,10 @@ func NewTxnWithSteppingEnabled(
 ) *Txn {
 	txn := NewTxnWithAdmissionControl(ctx, db, gatewayNodeID,
 		roachpb.AdmissionHeader_FROM_SQL, admissionpb.WorkPriority(qualityOfService))
+	// NB: TTLLow and TTLStatsLow share the same value.
+	if qualityOfService == sessiondatapb.TTLLow {
+		txn.admissionHeader.WorkClass = roachpb.AdmissionHeader_BACKGROUND
+	}
 	_ = txn.ConfigureStepping(ctx, SteppingEnabled)
 	return txn
 }
@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondatapb"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
@@ -447,6 +448,53 @@ func TestSetPriority(t *testing.T) {
 	}
 }
 
+// TestTxnAdmissionHeaderWorkClass verifies that the admission header of a SQL
+// transaction, including the BACKGROUND work class of TTL transactions,
+// reaches the batches that it sends.
+func TestTxnAdmissionHeaderWorkClass(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+
+	clock := hlc.NewClockWithSystemTimeSource(time.Nanosecond /* maxOffset */)
+	var header roachpb.AdmissionHeader
+	db := NewDB(log.MakeTestingAmbientCtxWithNewTracer(), newTestTxnFactory(
+		func(ba roachpb.BatchRequest) (*roachpb.BatchResponse, *roachpb.Error) {
+			header = ba.AdmissionHeader
+			return ba.CreateReply(), nil
+		}), clock, stopper)
+
+	for _, tc := range []struct {
+		qos       sessiondatapb.QoSLevel
+		workClass roachpb.AdmissionHeader_WorkClass
+	}{
+		{qos: sessiondatapb.Normal, workClass: roachpb.AdmissionHeader_DEFAULT},
+		{qos: sessiondatapb.UserLow, workClass: roachpb.AdmissionHeader_DEFAULT},
+		{qos: sessiondatapb.TTLLow, workClass: roachpb.AdmissionHeader_BACKGROUND},
+	} {
+		t.Run(tc.qos.String(), func(t *testing.T) {
+			txn := NewTxnWithSteppingEnabled(ctx, db, 0 /* gatewayNodeID */, tc.qos)
+			// A batch constructed using Txn.NewBatch.
+			_, err := txn.Get(ctx, "a")
+			require.NoError(t, err)
+			require.Equal(t, roachpb.AdmissionHeader_FROM_SQL, header.Source)
+			require.Equal(t, int32(tc.qos), header.Priority)
+			require.Equal(t, tc.workClass, header.WorkClass)
+
+			// A batch sent without an admission header.
+			header = roachpb.AdmissionHeader{}
+			var ba roachpb.BatchRequest
+			ba.Add(roachpb.NewGet(roachpb.Key("a"), false /* forUpdate */))
+			_, pErr := txn.Send(ctx, ba)
+			require.Nil(t, pErr)
+			require.Equal(t, roachpb.AdmissionHeader_FROM_SQL, header.Source)
+			require.Equal(t, tc.workClass, header.WorkClass)
+		})
+	}
+}
+
 // Tests that a retryable error for an inner txn doesn't cause the outer txn to
 // be retried.
 func TestWrongTxnRetry(t *testing.T) {
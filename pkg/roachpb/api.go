@@ -560,6 +560,10 @@ func (h *BatchResponse_Header) combine(o BatchResponse_Header) error {
 	h.Now.Forward(o.Now)
 	h.RangeInfos = append(h.RangeInfos, o.RangeInfos...)
 	h.CollectedSpans = append(h.CollectedSpans, o.CollectedSpans...)
+	if o.AdmissionPressure > h.AdmissionPressure {
+		h.AdmissionPressure = o.AdmissionPressure
+	}
+	h.AdmissionWait += o.AdmissionWait
 	return nil
 }
 
@@ -218,6 +218,7 @@ func (s *ExecStats) Add(other ExecStats) {
 	s.ContentionTime.Add(other.ContentionTime, execStatCollectionCount, other.Count)
 	s.NetworkMessages.Add(other.NetworkMessages, execStatCollectionCount, other.Count)
 	s.MaxDiskUsage.Add(other.MaxDiskUsage, execStatCollectionCount, other.Count)
+	s.KVAdmissionWaitTime.Add(other.KVAdmissionWaitTime, execStatCollectionCount, other.Count)
 
 	s.Count += other.Count
 }
@@ -14,6 +14,7 @@ import (
 	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/concurrency/lock"
 	"github.com/cockroachdb/cockroach/pkg/storage/enginepb"
@@ -358,6 +359,34 @@ func TestBatchResponseCombine(t *testing.T) {
 			t.Fatal("Combine() did not update the header")
 		}
 	}
+	// The highest admission pressure is retained.
+	for _, pressure := range []int32{40, 10} {
+		brPressure := &BatchResponse{
+			BatchResponse_Header: BatchResponse_Header{
+				AdmissionPressure: pressure,
+			},
+		}
+		if err := br.Combine(brPressure, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if br.AdmissionPressure != 40 {
+		t.Fatalf("expected admission pressure 40, got %d", br.AdmissionPressure)
+	}
+	// The admission wait times are summed.
+	for i := 0; i < 2; i++ {
+		brWait := &BatchResponse{
+			BatchResponse_Header: BatchResponse_Header{
+				AdmissionWait: time.Second,
+			},
+		}
+		if err := br.Combine(brWait, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if br.AdmissionWait != 2*time.Second {
+		t.Fatalf("expected admission wait 2s, got %s", br.AdmissionWait)
+	}
 
 	br.Responses = make([]ResponseUnion, 1)
 
@@ -325,6 +325,7 @@ const (
 	MinTimestampBoundUnsatisfiableErrType   ErrorDetailType = 42
 	RefreshFailedErrType                    ErrorDetailType = 43
 	MVCCHistoryMutationErrType              ErrorDetailType = 44
+	AdmissionOverloadedErrType              ErrorDetailType = 45
 	// When adding new error types, don't forget to update NumErrors below.
 
 	// CommunicationErrType indicates a gRPC error; this is not an ErrorDetail.
@@ -334,7 +335,7 @@ const (
 	// detail. The value 25 is chosen because it's reserved in the errors proto.
 	InternalErrType ErrorDetailType = 25
 
-	NumErrors int = 45
+	NumErrors int = 46
 )
 
 // GoError returns a Go error converted from Error. If the error is a transaction
@@ -1420,6 +1421,26 @@ func (e *RefreshFailedError) Type() ErrorDetailType {
 
 var _ ErrorDetailInterface = &RefreshFailedError{}
 
+// NewAdmissionOverloadedError initializes a new AdmissionOverloadedError.
+func NewAdmissionOverloadedError(reason string) *AdmissionOverloadedError {
+	return &AdmissionOverloadedError{Reason: reason}
+}
+
+func (e *AdmissionOverloadedError) Error() string {
+	return e.message(nil)
+}
+
+func (e *AdmissionOverloadedError) message(_ *Error) string {
+	return fmt.Sprintf("admission queues overloaded, retry later: %s", e.Reason)
+}
+
+// Type is part of the ErrorDetailInterface.
+func (e *AdmissionOverloadedError) Type() ErrorDetailType {
+	return AdmissionOverloadedErrType
+}
+
+var _ ErrorDetailInterface = &AdmissionOverloadedError{}
+
 func (e *InsufficientSpaceError) Error() string {
 	return fmt.Sprintf("store %d has insufficient remaining capacity to %s (remaining: %s / %.1f%%, min required: %.1f%%)",
 		e.StoreID, e.Op, humanizeutil.IBytes(e.Available), float64(e.Available)/float64(e.Capacity)*100, e.Required*100)
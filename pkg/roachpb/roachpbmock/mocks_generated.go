@@ -197,6 +197,26 @@ func (mr *MockInternalClientMockRecorder) ResetQuorum(arg0, arg1 interface{}, ar
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetQuorum", reflect.TypeOf((*MockInternalClient)(nil).ResetQuorum), varargs...)
 }
 
+// TenantAdmissionStats mocks base method.
+func (m *MockInternalClient) TenantAdmissionStats(arg0 context.Context, arg1 *roachpb.TenantAdmissionStatsRequest, arg2 ...grpc.CallOption) (*roachpb.TenantAdmissionStatsResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0, arg1}
+	for _, a := range arg2 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "TenantAdmissionStats", varargs...)
+	ret0, _ := ret[0].(*roachpb.TenantAdmissionStatsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TenantAdmissionStats indicates an expected call of TenantAdmissionStats.
+func (mr *MockInternalClientMockRecorder) TenantAdmissionStats(arg0, arg1 interface{}, arg2 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0, arg1}, arg2...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TenantAdmissionStats", reflect.TypeOf((*MockInternalClient)(nil).TenantAdmissionStats), varargs...)
+}
+
 // TenantSettings mocks base method.
 func (m *MockInternalClient) TenantSettings(arg0 context.Context, arg1 *roachpb.TenantSettingsRequest, arg2 ...grpc.CallOption) (roachpb.Internal_TenantSettingsClient, error) {
 	m.ctrl.T.Helper()
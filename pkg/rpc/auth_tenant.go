@@ -62,6 +62,9 @@ func (a tenantAuthorizer) authorize(
 	case "/cockroach.roachpb.Internal/TokenBucket":
 		return a.authTokenBucket(tenID, req.(*roachpb.TokenBucketRequest))
 
+	case "/cockroach.roachpb.Internal/TenantAdmissionStats":
+		return a.authTenantAdmissionStats(tenID, req.(*roachpb.TenantAdmissionStatsRequest))
+
 	case "/cockroach.roachpb.Internal/TenantSettings":
 		return a.authTenantSettings(tenID, req.(*roachpb.TenantSettingsRequest))
 
@@ -271,6 +274,20 @@ func (a tenantAuthorizer) authTokenBucket(
 	return nil
 }
 
+// authTenantAdmissionStats authorizes the provided tenant to invoke the
+// TenantAdmissionStats RPC with the provided args.
+func (a tenantAuthorizer) authTenantAdmissionStats(
+	tenID roachpb.TenantID, args *roachpb.TenantAdmissionStatsRequest,
+) error {
+	if !args.TenantID.IsSet() {
+		return authErrorf("tenant admission stats request with unspecified tenant not permitted")
+	}
+	if args.TenantID != tenID {
+		return authErrorf("tenant admission stats request for tenant %s not permitted", args.TenantID)
+	}
+	return nil
+}
+
 // authTenantSettings authorizes the provided tenant to invoke the
 // TenantSettings RPC with the provided args.
 func (a tenantAuthorizer) authTenantSettings(
@@ -477,6 +477,24 @@ func TestTenantAuthRequest(t *testing.T) {
 				expErr: `token bucket request with unspecified tenant not permitted`,
 			},
 		},
+		"/cockroach.roachpb.Internal/TenantAdmissionStats": {
+			{
+				req:    &roachpb.TenantAdmissionStatsRequest{TenantID: tenID},
+				expErr: noError,
+			},
+			{
+				req:    &roachpb.TenantAdmissionStatsRequest{TenantID: roachpb.SystemTenantID},
+				expErr: `tenant admission stats request for tenant system not permitted`,
+			},
+			{
+				req:    &roachpb.TenantAdmissionStatsRequest{TenantID: roachpb.MakeTenantID(13)},
+				expErr: `tenant admission stats request for tenant 13 not permitted`,
+			},
+			{
+				req:    &roachpb.TenantAdmissionStatsRequest{},
+				expErr: `tenant admission stats request with unspecified tenant not permitted`,
+			},
+		},
 		"/cockroach.roachpb.Internal/GetSpanConfigs": {
 			{
 				req:    &roachpb.GetSpanConfigsRequest{},
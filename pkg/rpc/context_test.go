@@ -277,6 +277,12 @@ func (*internalServer) TokenBucket(
 	panic("unimplemented")
 }
 
+func (*internalServer) TenantAdmissionStats(
+	context.Context, *roachpb.TenantAdmissionStatsRequest,
+) (*roachpb.TenantAdmissionStatsResponse, error) {
+	panic("unimplemented")
+}
+
 func (*internalServer) GetSpanConfigs(
 	context.Context, *roachpb.GetSpanConfigsRequest,
 ) (*roachpb.GetSpanConfigsResponse, error) {
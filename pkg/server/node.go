@@ -357,6 +357,7 @@ func NewNode(
 	execCfg *sql.ExecutorConfig,
 	clusterID *base.ClusterIDContainer,
 	kvAdmissionQ *admission.WorkQueue,
+	kvGrantCoord *admission.GrantCoordinator,
 	storeGrantCoords *admission.StoreGrantCoordinators,
 	tenantUsage multitenant.TenantUsageServer,
 	tenantSettingsWatcher *tenantsettingswatcher.Watcher,
@@ -366,6 +367,8 @@ func NewNode(
 	if execCfg != nil {
 		sqlExec = execCfg.InternalExecutor
 	}
+	admissionMetrics := kvserver.MakeKVAdmissionMetrics(cfg.Settings, cfg.HistogramWindowInterval)
+	reg.AddMetricStruct(admissionMetrics)
 	n := &Node{
 		storeCfg:   cfg,
 		stopper:    stopper,
@@ -376,7 +379,9 @@ func NewNode(
 		sqlExec:    sqlExec,
 		clusterID:  clusterID,
 		admissionController: kvserver.MakeKVAdmissionController(
-			kvAdmissionQ, storeGrantCoords, cfg.Settings),
+			kvAdmissionQ, kvGrantCoord, storeGrantCoords, cfg.Settings, admissionMetrics,
+			timeutil.DefaultTimeSource{}, &cfg.TestingKnobs.KVAdmissionKnobs,
+		),
 		tenantUsage:           tenantUsage,
 		tenantSettingsWatcher: tenantSettingsWatcher,
 		spanConfigAccessor:    spanConfigAccessor,
@@ -606,6 +611,12 @@ func (n *Node) addStore(ctx context.Context, store *kvserver.Store) {
 	}
 	n.stores.AddStore(store)
 	n.recorder.AddStore(store)
+	// This is a no-op for the stores added while the node is starting, which
+	// are picked up when the store admission is initialized.
+	n.admissionController.OnStoreAdded(ctx, admission.StoreMetrics{
+		StoreID: int32(store.StoreID()),
+		Metrics: store.Engine().GetMetrics().Metrics,
+	})
 }
 
 // validateStores iterates over all stores, verifying they agree on node ID.
@@ -703,7 +714,9 @@ func (n *Node) startGossiping(ctx context.Context, stopper *stop.Stopper) {
 		statusTicker := time.NewTicker(gossipStatusInterval)
 		storesTicker := time.NewTicker(gossip.StoresInterval)
 		nodeTicker := time.NewTicker(gossip.NodeDescriptorInterval)
+		cpuOverloadTicker := time.NewTicker(gossip.NodeCPUOverloadInterval)
 		defer func() {
+			cpuOverloadTicker.Stop()
 			nodeTicker.Stop()
 			storesTicker.Stop()
 			statusTicker.Stop()
@@ -716,6 +729,8 @@ func (n *Node) startGossiping(ctx context.Context, stopper *stop.Stopper) {
 				n.storeCfg.Gossip.LogStatus()
 			case <-storesTicker.C:
 				n.gossipStores(ctx)
+			case <-cpuOverloadTicker.C:
+				n.gossipCPUOverloadScore(ctx)
 			case <-nodeTicker.C:
 				if err := n.storeCfg.Gossip.SetNodeDescriptor(&n.Descriptor); err != nil {
 					log.Warningf(ctx, "couldn't gossip descriptor for node %d: %s", n.Descriptor.NodeID, err)
@@ -736,6 +751,20 @@ func (n *Node) gossipStores(ctx context.Context) {
 	}
 }
 
+// gossipCPUOverloadScore broadcasts admission control's view of the CPU
+// health of the node to the gossip network, see
+// kvcoord.NodeCPUOverloadScores.
+func (n *Node) gossipCPUOverloadScore(ctx context.Context) {
+	score := n.admissionController.GetCPUOverloadScore()
+	if err := n.storeCfg.Gossip.AddInfo(
+		gossip.MakeNodeCPUOverloadKey(n.Descriptor.NodeID),
+		gossip.EncodeNodeCPUOverloadScore(score.Score), gossip.NodeCPUOverloadTTL,
+	); err != nil {
+		log.Warningf(ctx, "couldn't gossip CPU overload score for node %d: %s",
+			n.Descriptor.NodeID, err)
+	}
+}
+
 // startComputePeriodicMetrics starts a loop which periodically instructs each
 // store to compute the value of metrics which cannot be incrementally
 // maintained.
@@ -782,11 +811,17 @@ func (n *Node) GetPebbleMetrics() []admission.StoreMetrics {
 }
 
 // GetTenantWeights implements kvserver.TenantWeightProvider.
-func (n *Node) GetTenantWeights() kvserver.TenantWeights {
+func (n *Node) GetTenantWeights(ctx context.Context) (kvserver.TenantWeights, error) {
 	weights := kvserver.TenantWeights{
-		Node: make(map[uint64]uint32),
+		Node:     make(map[uint64]uint32),
+		Locality: n.Descriptor.Locality,
 	}
-	_ = n.stores.VisitStores(func(store *kvserver.Store) error {
+	err := n.stores.VisitStores(func(store *kvserver.Store) error {
+		// Visiting the replicas can be slow with many replicas, so stop if the
+		// caller is no longer waiting.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		sw := make(map[uint64]uint32)
 		weights.Stores = append(weights.Stores, kvserver.TenantWeightsForStore{
 			StoreID: store.StoreID(),
@@ -802,7 +837,10 @@ func (n *Node) GetTenantWeights() kvserver.TenantWeights {
 		})
 		return nil
 	})
-	return weights
+	if err != nil {
+		return kvserver.TenantWeights{}, err
+	}
+	return weights, nil
 }
 
 func (n *Node) startGraphiteStatsExporter(st *cluster.Settings) {
@@ -998,11 +1036,35 @@ func (n *Node) batchInternal(
 	}
 
 	tStart := timeutil.Now()
-	handle, err := n.admissionController.AdmitKVWork(ctx, tenID, args)
-	defer n.admissionController.AdmittedKVWorkDone(handle)
+	var queueDeadline time.Time
+	if d := args.AdmissionHeader.QueueDeadline; d != 0 {
+		queueDeadline = timeutil.Unix(0, d)
+	}
+	handle, err := n.admissionController.AdmitKVWorkWithDeadline(ctx, tenID, args, queueDeadline)
+	// NB: wrapped to delay br evaluation to its value when returning.
+	defer func() {
+		admissionWait := n.admissionController.AdmittedKVWorkDone(handle, br)
+		if br != nil {
+			br.AdmissionWait = admissionWait
+		}
+	}()
 	if err != nil {
 		return nil, err
 	}
+	// servingStoreID is the store whose admission pressure is returned in the
+	// response.
+	servingStoreID := args.Replica.StoreID
+	if handle.StoreID() != 0 && n.stores.GetStoreCount() > 1 && !n.hasReplica(args) {
+		// The write was admitted against the store in args.Replica, which does
+		// not hold the range, since the batch was misrouted. Charge the work to
+		// the store that has the replica instead.
+		if _, s, err := n.stores.GetReplicaForRangeID(ctx, args.RangeID); err == nil {
+			servingStoreID = s.StoreID()
+			if err := n.admissionController.RebindStoreAdmission(ctx, handle, s.StoreID()); err != nil {
+				return nil, err
+			}
+		}
+	}
 	var pErr *roachpb.Error
 	br, pErr = n.stores.Send(ctx, *args)
 	if pErr != nil {
@@ -1014,10 +1076,18 @@ func (n *Node) batchInternal(
 	}
 	n.metrics.callComplete(timeutil.Since(tStart), pErr)
 	br.Error = pErr
+	br.AdmissionPressure = n.admissionController.AdmissionPressure(servingStoreID)
 
 	return br, nil
 }
 
+// hasReplica returns true if the store in ba.Replica holds a replica of
+// ba.RangeID, which is the case unless the batch was misrouted.
+func (n *Node) hasReplica(ba *roachpb.BatchRequest) bool {
+	s, err := n.stores.GetStore(ba.Replica.StoreID)
+	return err == nil && s.GetReplicaIfExists(ba.RangeID) != nil
+}
+
 // incrementBatchCounters increments counters to track the batch and composite
 // request methods.
 func (n *Node) incrementBatchCounters(ba *roachpb.BatchRequest) {
@@ -1621,6 +1691,15 @@ var _ metric.Struct = emptyMetricStruct{}
 
 func (emptyMetricStruct) MetricStruct() {}
 
+// TenantAdmissionStats implements the roachpb.InternalServer interface.
+func (n *Node) TenantAdmissionStats(
+	ctx context.Context, req *roachpb.TenantAdmissionStatsRequest,
+) (*roachpb.TenantAdmissionStatsResponse, error) {
+	return &roachpb.TenantAdmissionStatsResponse{
+		Stats: n.admissionController.GetTenantAdmissionStats(req.TenantID),
+	}, nil
+}
+
 // GetSpanConfigs implements the roachpb.InternalServer interface.
 func (n *Node) GetSpanConfigs(
 	ctx context.Context, req *roachpb.GetSpanConfigsRequest,
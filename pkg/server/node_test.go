@@ -733,7 +733,8 @@ func TestGetTenantWeights(t *testing.T) {
 	// Unfortunately, the non-determinism of replica distribution can make this
 	// test more complicated than the code it is trying to test, if we were to
 	// validate exact counts. So we do some simple validation instead.
-	weights := s.Node().(*Node).GetTenantWeights()
+	weights, err := s.Node().(*Node).GetTenantWeights(ctx)
+	require.NoError(t, err)
 	// Both tenants have overall non-zero counts.
 	require.Less(t, uint32(0), weights.Node[roachpb.SystemTenantID.ToUint64()])
 	require.Less(t, uint32(0), weights.Node[otherTenantID])
@@ -335,6 +335,8 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 		retryOpts = base.DefaultRetryOptions()
 	}
 	retryOpts.Closer = stopper.ShouldQuiesce()
+	cpuOverloadScores := kvcoord.NewNodeCPUOverloadScores(
+		cfg.AmbientCtx, g, timeutil.DefaultTimeSource{})
 	distSenderCfg := kvcoord.DistSenderConfig{
 		AmbientCtx:         cfg.AmbientCtx,
 		Settings:           st,
@@ -344,6 +346,7 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 		RPCRetryOptions:    &retryOpts,
 		NodeDialer:         nodeDialer,
 		FirstRangeProvider: g,
+		CPUOverloadFunc:    cpuOverloadScores.Score,
 		TestingKnobs:       clientTestingKnobs,
 	}
 	distSender := kvcoord.NewDistSender(distSenderCfg)
@@ -686,6 +689,7 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 		nil,
 		cfg.ClusterIDContainer,
 		gcoords.Regular.GetWorkQueue(admission.KVWork),
+		gcoords.Regular,
 		gcoords.Stores,
 		tenantUsage,
 		tenantSettingsWatcher,
@@ -1442,6 +1446,7 @@ func (s *Server) PreStart(ctx context.Context) error {
 	// Raft commands like log application and snapshot application may be able
 	// to bypass admission control.
 	s.storeGrantCoords.SetPebbleMetricsProvider(ctx, s.node)
+	s.node.admissionController.StartWriteAmpFeedback(s.node, s.stopper)
 
 	// Once all stores are initialized, check if offline storage recovery
 	// was done prior to start and record any actions appropriately.
@@ -73,6 +73,9 @@ type KVReader interface {
 	// GetCumulativeContentionTime returns the amount of time KV reads spent
 	// contending. It must be safe for concurrent use.
 	GetCumulativeContentionTime() time.Duration
+	// GetKVAdmissionWaitTime returns the amount of time KV reads spent waiting
+	// in KV admission queues. It must be safe for concurrent use.
+	GetKVAdmissionWaitTime() time.Duration
 	// GetScanStats returns statistics about the scan that happened during the
 	// KV reads. It must be safe for concurrent use.
 	GetScanStats() execstats.ScanStats
@@ -234,6 +234,10 @@ type cFetcher struct {
 	// The field should not be accessed directly by the users of the cFetcher -
 	// getBytesRead() should be used instead.
 	bytesRead int64
+	// kvAdmissionWait is like bytesRead, but for the time the KV requests
+	// spent waiting in KV admission queues. getKVAdmissionWaitTime() should be
+	// used instead of accessing it directly.
+	kvAdmissionWait time.Duration
 
 	// machine contains fields that get updated during the run of the fetcher.
 	machine struct {
@@ -1354,6 +1358,15 @@ func (cf *cFetcher) getBytesRead() int64 {
 	return cf.bytesRead
 }
 
+// getKVAdmissionWaitTime returns the time the KV requests of the cFetcher
+// spent waiting in KV admission queues throughout its existence so far.
+func (cf *cFetcher) getKVAdmissionWaitTime() time.Duration {
+	if cf.fetcher != nil {
+		cf.kvAdmissionWait += cf.fetcher.ResetKVAdmissionWaitTime()
+	}
+	return cf.kvAdmissionWait
+}
+
 var cFetcherPool = sync.Pool{
 	New: func() interface{} {
 		return &cFetcher{}
@@ -1377,6 +1390,7 @@ func (cf *cFetcher) Release() {
 func (cf *cFetcher) Close(ctx context.Context) {
 	if cf != nil && cf.fetcher != nil {
 		cf.bytesRead += cf.fetcher.GetBytesRead()
+		cf.kvAdmissionWait += cf.fetcher.GetKVAdmissionWaitTime()
 		cf.fetcher.Close(ctx)
 		cf.fetcher = nil
 	}
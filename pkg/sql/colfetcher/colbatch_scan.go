@@ -164,6 +164,13 @@ func (s *ColBatchScan) GetCumulativeContentionTime() time.Duration {
 	return execstats.GetCumulativeContentionTime(s.Ctx, nil /* recording */)
 }
 
+// GetKVAdmissionWaitTime is part of the colexecop.KVReader interface.
+func (s *ColBatchScan) GetKVAdmissionWaitTime() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cf.getKVAdmissionWaitTime()
+}
+
 // GetScanStats is part of the colexecop.KVReader interface.
 func (s *ColBatchScan) GetScanStats() execstats.ScanStats {
 	return execstats.GetScanStats(s.Ctx, nil /* recording */)
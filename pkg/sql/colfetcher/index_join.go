@@ -442,6 +442,13 @@ func (s *ColIndexJoin) GetCumulativeContentionTime() time.Duration {
 	return execstats.GetCumulativeContentionTime(s.Ctx, nil /* recording */)
 }
 
+// GetKVAdmissionWaitTime is part of the colexecop.KVReader interface.
+func (s *ColIndexJoin) GetKVAdmissionWaitTime() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cf.getKVAdmissionWaitTime()
+}
+
 // inputBatchSizeLimit is a batch size limit for the number of input rows that
 // will be used to form lookup spans for each scan. This is used as a proxy for
 // result batch size in order to prevent OOMs, because index joins do not limit
@@ -145,6 +145,9 @@ func (s *ComponentStats) formatStats(fn func(suffix string, value interface{}))
 	if s.KV.ContentionTime.HasValue() {
 		fn("KV contention time", humanizeutil.Duration(s.KV.ContentionTime.Value()))
 	}
+	if s.KV.KVAdmissionWaitTime.HasValue() {
+		fn("KV admission wait time", humanizeutil.Duration(s.KV.KVAdmissionWaitTime.Value()))
+	}
 	if s.KV.TuplesRead.HasValue() {
 		fn("KV rows read", humanizeutil.Count(s.KV.TuplesRead.Value()))
 	}
@@ -231,6 +234,9 @@ func (s *ComponentStats) Union(other *ComponentStats) *ComponentStats {
 	if !result.KV.ContentionTime.HasValue() {
 		result.KV.ContentionTime = other.KV.ContentionTime
 	}
+	if !result.KV.KVAdmissionWaitTime.HasValue() {
+		result.KV.KVAdmissionWaitTime = other.KV.KVAdmissionWaitTime
+	}
 	if !result.KV.NumInterfaceSteps.HasValue() {
 		result.KV.NumInterfaceSteps = other.KV.NumInterfaceSteps
 	}
@@ -332,6 +338,9 @@ func (s *ComponentStats) MakeDeterministic() {
 	// KV.
 	timeVal(&s.KV.KVTime)
 	timeVal(&s.KV.ContentionTime)
+	// Whether KV requests waited for admission at all depends on the load on
+	// the cluster, so the admission wait time is omitted altogether.
+	s.KV.KVAdmissionWaitTime = optional.Duration{}
 	resetUint(&s.KV.NumInterfaceSteps)
 	resetUint(&s.KV.NumInternalSteps)
 	resetUint(&s.KV.NumInterfaceSeeks)
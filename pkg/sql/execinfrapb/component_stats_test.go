@@ -59,9 +59,10 @@ network bytes sent: 80 B`,
 		{ // 3
 			stats: ComponentStats{
 				KV: KVStats{
-					KVTime:     optional.MakeTimeValue(time.Second),
-					TuplesRead: optional.MakeUint(10),
-					BytesRead:  optional.MakeUint(12345),
+					KVTime:              optional.MakeTimeValue(time.Second),
+					TuplesRead:          optional.MakeUint(10),
+					BytesRead:           optional.MakeUint(12345),
+					KVAdmissionWaitTime: optional.MakeTimeValue(time.Millisecond),
 				},
 			},
 			expected: `
@@ -107,21 +107,25 @@ type NodeLevelStats struct {
 	KVTimeGroupedByNode           map[base.SQLInstanceID]time.Duration
 	NetworkMessagesGroupedByNode  map[base.SQLInstanceID]int64
 	ContentionTimeGroupedByNode   map[base.SQLInstanceID]time.Duration
+	// KVAdmissionWaitTimeGroupedByNode is the time the KV requests issued by
+	// each node spent waiting in KV admission queues.
+	KVAdmissionWaitTimeGroupedByNode map[base.SQLInstanceID]time.Duration
 }
 
 // QueryLevelStats returns all the query level stats that correspond to the
 // given traces and flow metadata.
 // NOTE: When adding fields to this struct, be sure to update Accumulate.
 type QueryLevelStats struct {
-	NetworkBytesSent int64
-	MaxMemUsage      int64
-	MaxDiskUsage     int64
-	KVBytesRead      int64
-	KVRowsRead       int64
-	KVTime           time.Duration
-	NetworkMessages  int64
-	ContentionTime   time.Duration
-	Regions          []string
+	NetworkBytesSent    int64
+	MaxMemUsage         int64
+	MaxDiskUsage        int64
+	KVBytesRead         int64
+	KVRowsRead          int64
+	KVTime              time.Duration
+	NetworkMessages     int64
+	ContentionTime      time.Duration
+	KVAdmissionWaitTime time.Duration
+	Regions             []string
 }
 
 // Accumulate accumulates other's stats into the receiver.
@@ -138,6 +142,7 @@ func (s *QueryLevelStats) Accumulate(other QueryLevelStats) {
 	s.KVTime += other.KVTime
 	s.NetworkMessages += other.NetworkMessages
 	s.ContentionTime += other.ContentionTime
+	s.KVAdmissionWaitTime += other.KVAdmissionWaitTime
 	s.Regions = util.CombineUniqueString(s.Regions, other.Regions)
 }
 
@@ -205,14 +210,15 @@ func (a *TraceAnalyzer) AddTrace(trace []tracingpb.RecordedSpan, makeDeterminist
 func (a *TraceAnalyzer) ProcessStats() error {
 	// Process node level stats.
 	a.nodeLevelStats = NodeLevelStats{
-		NetworkBytesSentGroupedByNode: make(map[base.SQLInstanceID]int64),
-		MaxMemoryUsageGroupedByNode:   make(map[base.SQLInstanceID]int64),
-		MaxDiskUsageGroupedByNode:     make(map[base.SQLInstanceID]int64),
-		KVBytesReadGroupedByNode:      make(map[base.SQLInstanceID]int64),
-		KVRowsReadGroupedByNode:       make(map[base.SQLInstanceID]int64),
-		KVTimeGroupedByNode:           make(map[base.SQLInstanceID]time.Duration),
-		NetworkMessagesGroupedByNode:  make(map[base.SQLInstanceID]int64),
-		ContentionTimeGroupedByNode:   make(map[base.SQLInstanceID]time.Duration),
+		NetworkBytesSentGroupedByNode:    make(map[base.SQLInstanceID]int64),
+		MaxMemoryUsageGroupedByNode:      make(map[base.SQLInstanceID]int64),
+		MaxDiskUsageGroupedByNode:        make(map[base.SQLInstanceID]int64),
+		KVBytesReadGroupedByNode:         make(map[base.SQLInstanceID]int64),
+		KVRowsReadGroupedByNode:          make(map[base.SQLInstanceID]int64),
+		KVTimeGroupedByNode:              make(map[base.SQLInstanceID]time.Duration),
+		NetworkMessagesGroupedByNode:     make(map[base.SQLInstanceID]int64),
+		ContentionTimeGroupedByNode:      make(map[base.SQLInstanceID]time.Duration),
+		KVAdmissionWaitTimeGroupedByNode: make(map[base.SQLInstanceID]time.Duration),
 	}
 	var errs error
 
@@ -226,6 +232,7 @@ func (a *TraceAnalyzer) ProcessStats() error {
 		a.nodeLevelStats.KVRowsReadGroupedByNode[instanceID] += int64(stats.KV.TuplesRead.Value())
 		a.nodeLevelStats.KVTimeGroupedByNode[instanceID] += stats.KV.KVTime.Value()
 		a.nodeLevelStats.ContentionTimeGroupedByNode[instanceID] += stats.KV.ContentionTime.Value()
+		a.nodeLevelStats.KVAdmissionWaitTimeGroupedByNode[instanceID] += stats.KV.KVAdmissionWaitTime.Value()
 	}
 
 	// Process streamStats.
@@ -331,6 +338,10 @@ func (a *TraceAnalyzer) ProcessStats() error {
 	for _, contentionTime := range a.nodeLevelStats.ContentionTimeGroupedByNode {
 		a.queryLevelStats.ContentionTime += contentionTime
 	}
+
+	for _, admissionWaitTime := range a.nodeLevelStats.KVAdmissionWaitTimeGroupedByNode {
+		a.queryLevelStats.KVAdmissionWaitTime += admissionWaitTime
+	}
 	return errs
 }
 
@@ -190,12 +190,15 @@ func TestTraceAnalyzer(t *testing.T) {
 
 func TestTraceAnalyzerProcessStats(t *testing.T) {
 	const (
-		node1KVTime              = 1 * time.Second
-		node1ContentionTime      = 2 * time.Second
-		node2KVTime              = 3 * time.Second
-		node2ContentionTime      = 4 * time.Second
-		cumulativeKVTime         = node1KVTime + node2KVTime
-		cumulativeContentionTime = node1ContentionTime + node2ContentionTime
+		node1KVTime                 = 1 * time.Second
+		node1ContentionTime         = 2 * time.Second
+		node1AdmissionWaitTime      = 500 * time.Millisecond
+		node2KVTime                 = 3 * time.Second
+		node2ContentionTime         = 4 * time.Second
+		node2AdmissionWaitTime      = 1500 * time.Millisecond
+		cumulativeKVTime            = node1KVTime + node2KVTime
+		cumulativeContentionTime    = node1ContentionTime + node2ContentionTime
+		cumulativeAdmissionWaitTime = node1AdmissionWaitTime + node2AdmissionWaitTime
 	)
 	a := &execstats.TraceAnalyzer{FlowsMetadata: &execstats.FlowsMetadata{}}
 	n1 := base.SQLInstanceID(1)
@@ -208,8 +211,9 @@ func TestTraceAnalyzerProcessStats(t *testing.T) {
 				1, /* processorID */
 			),
 			KV: execinfrapb.KVStats{
-				KVTime:         optional.MakeTimeValue(node1KVTime),
-				ContentionTime: optional.MakeTimeValue(node1ContentionTime),
+				KVTime:              optional.MakeTimeValue(node1KVTime),
+				ContentionTime:      optional.MakeTimeValue(node1ContentionTime),
+				KVAdmissionWaitTime: optional.MakeTimeValue(node1AdmissionWaitTime),
 			},
 		},
 	)
@@ -222,15 +226,17 @@ func TestTraceAnalyzerProcessStats(t *testing.T) {
 				2, /* processorID */
 			),
 			KV: execinfrapb.KVStats{
-				KVTime:         optional.MakeTimeValue(node2KVTime),
-				ContentionTime: optional.MakeTimeValue(node2ContentionTime),
+				KVTime:              optional.MakeTimeValue(node2KVTime),
+				ContentionTime:      optional.MakeTimeValue(node2ContentionTime),
+				KVAdmissionWaitTime: optional.MakeTimeValue(node2AdmissionWaitTime),
 			},
 		},
 	)
 
 	expected := execstats.QueryLevelStats{
-		KVTime:         cumulativeKVTime,
-		ContentionTime: cumulativeContentionTime,
+		KVTime:              cumulativeKVTime,
+		ContentionTime:      cumulativeContentionTime,
+		KVAdmissionWaitTime: cumulativeAdmissionWaitTime,
 	}
 
 	assert.NoError(t, a.ProcessStats())
@@ -241,37 +247,40 @@ func TestTraceAnalyzerProcessStats(t *testing.T) {
 
 func TestQueryLevelStatsAccumulate(t *testing.T) {
 	a := execstats.QueryLevelStats{
-		NetworkBytesSent: 1,
-		MaxMemUsage:      2,
-		KVBytesRead:      3,
-		KVRowsRead:       4,
-		KVTime:           5 * time.Second,
-		NetworkMessages:  6,
-		ContentionTime:   7 * time.Second,
-		MaxDiskUsage:     8,
-		Regions:          []string{"gcp-us-east1"},
+		NetworkBytesSent:    1,
+		MaxMemUsage:         2,
+		KVBytesRead:         3,
+		KVRowsRead:          4,
+		KVTime:              5 * time.Second,
+		NetworkMessages:     6,
+		ContentionTime:      7 * time.Second,
+		MaxDiskUsage:        8,
+		KVAdmissionWaitTime: 9 * time.Second,
+		Regions:             []string{"gcp-us-east1"},
 	}
 	b := execstats.QueryLevelStats{
-		NetworkBytesSent: 8,
-		MaxMemUsage:      9,
-		KVBytesRead:      10,
-		KVRowsRead:       11,
-		KVTime:           12 * time.Second,
-		NetworkMessages:  13,
-		ContentionTime:   14 * time.Second,
-		MaxDiskUsage:     15,
-		Regions:          []string{"gcp-us-west1"},
+		NetworkBytesSent:    8,
+		MaxMemUsage:         9,
+		KVBytesRead:         10,
+		KVRowsRead:          11,
+		KVTime:              12 * time.Second,
+		NetworkMessages:     13,
+		ContentionTime:      14 * time.Second,
+		MaxDiskUsage:        15,
+		KVAdmissionWaitTime: 16 * time.Second,
+		Regions:             []string{"gcp-us-west1"},
 	}
 	expected := execstats.QueryLevelStats{
-		NetworkBytesSent: 9,
-		MaxMemUsage:      9,
-		KVBytesRead:      13,
-		KVRowsRead:       15,
-		KVTime:           17 * time.Second,
-		NetworkMessages:  19,
-		ContentionTime:   21 * time.Second,
-		MaxDiskUsage:     15,
-		Regions:          []string{"gcp-us-east1", "gcp-us-west1"},
+		NetworkBytesSent:    9,
+		MaxMemUsage:         9,
+		KVBytesRead:         13,
+		KVRowsRead:          15,
+		KVTime:              17 * time.Second,
+		NetworkMessages:     19,
+		ContentionTime:      21 * time.Second,
+		MaxDiskUsage:        15,
+		KVAdmissionWaitTime: 25 * time.Second,
+		Regions:             []string{"gcp-us-east1", "gcp-us-west1"},
 	}
 
 	aCopy := a
@@ -477,6 +477,9 @@ func (ih *instrumentationHelper) emitExplainAnalyzePlanToOutputBuilder(
 	if queryStats.ContentionTime != 0 {
 		ob.AddContentionTime(queryStats.ContentionTime)
 	}
+	if queryStats.KVAdmissionWaitTime != 0 {
+		ob.AddKVAdmissionWaitTime(queryStats.KVAdmissionWaitTime)
+	}
 
 	ob.AddMaxMemUsage(queryStats.MaxMemUsage)
 	ob.AddNetworkStats(queryStats.NetworkMessages, queryStats.NetworkBytesSent)
@@ -604,6 +607,7 @@ func (m execNodeTraceMetadata) annotateExplain(
 				nodeStats.RowCount.MaybeAdd(stats.Output.NumTuples)
 				nodeStats.KVTime.MaybeAdd(stats.KV.KVTime)
 				nodeStats.KVContentionTime.MaybeAdd(stats.KV.ContentionTime)
+				nodeStats.KVAdmissionWaitTime.MaybeAdd(stats.KV.KVAdmissionWaitTime)
 				nodeStats.KVBytesRead.MaybeAdd(stats.KV.BytesRead)
 				nodeStats.KVRowsRead.MaybeAdd(stats.KV.TuplesRead)
 				nodeStats.StepCount.MaybeAdd(stats.KV.NumInterfaceSteps)
@@ -386,6 +386,9 @@ func (e *emitter) emitNodeAttributes(n *Node) error {
 		if s.KVContentionTime.HasValue() {
 			e.ob.AddField("KV contention time", string(humanizeutil.Duration(s.KVContentionTime.Value())))
 		}
+		if s.KVAdmissionWaitTime.HasValue() {
+			e.ob.AddField("KV admission wait time", string(humanizeutil.Duration(s.KVAdmissionWaitTime.Value())))
+		}
 		if s.KVRowsRead.HasValue() {
 			e.ob.AddField("KV rows read", string(humanizeutil.Count(s.KVRowsRead.Value())))
 		}
@@ -385,6 +385,16 @@ func (ob *OutputBuilder) AddContentionTime(contentionTime time.Duration) {
 	)
 }
 
+// AddKVAdmissionWaitTime adds a top-level field for the cumulative time KV
+// requests spent waiting in admission queues.
+func (ob *OutputBuilder) AddKVAdmissionWaitTime(admissionWaitTime time.Duration) {
+	ob.AddRedactableTopLevelField(
+		RedactVolatile,
+		"cumulative time spent in KV admission queues",
+		string(humanizeutil.Duration(admissionWaitTime)),
+	)
+}
+
 // AddMaxMemUsage adds a top-level field for the memory used by the query.
 func (ob *OutputBuilder) AddMaxMemUsage(bytes int64) {
 	ob.AddRedactableTopLevelField(
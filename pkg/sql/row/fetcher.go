@@ -72,6 +72,9 @@ type kvBatchFetcherResponse struct {
 	batchResponse []byte
 	// spanID is the ID associated with the span that generated this response.
 	spanID int
+	// kvAdmissionWait is the time the BatchRequests sent since the last
+	// response spent waiting in KV admission queues.
+	kvAdmissionWait time.Duration
 }
 
 // KVBatchFetcher abstracts the logic of fetching KVs in batches.
@@ -1188,3 +1191,9 @@ func (rf *Fetcher) Key() roachpb.Key {
 func (rf *Fetcher) GetBytesRead() int64 {
 	return rf.kvFetcher.GetBytesRead()
 }
+
+// GetKVAdmissionWaitTime returns the total time the requests of the
+// underlying KVFetcher spent waiting in KV admission queues.
+func (rf *Fetcher) GetKVAdmissionWaitTime() time.Duration {
+	return rf.kvFetcher.GetKVAdmissionWaitTime()
+}
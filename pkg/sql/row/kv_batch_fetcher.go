@@ -168,6 +168,9 @@ type txnKVFetcher struct {
 	// For request and response admission control.
 	requestAdmissionHeader roachpb.AdmissionHeader
 	responseAdmissionQ     *admission.WorkQueue
+	// kvAdmissionWait is the time the BatchRequests sent since the last
+	// response returned by nextBatch spent waiting in KV admission queues.
+	kvAdmissionWait time.Duration
 }
 
 var _ KVBatchFetcher = &txnKVFetcher{}
@@ -416,6 +419,7 @@ func (f *txnKVFetcher) fetch(ctx context.Context) error {
 	}
 	if br != nil {
 		f.responses = br.Responses
+		f.kvAdmissionWait += br.AdmissionWait
 	} else {
 		f.responses = nil
 	}
@@ -477,6 +481,12 @@ func popBatch(batches [][]byte) (batch []byte, remainingBatches [][]byte) {
 
 // nextBatch implements the KVBatchFetcher interface.
 func (f *txnKVFetcher) nextBatch(ctx context.Context) (resp kvBatchFetcherResponse, err error) {
+	defer func() {
+		// Attribute the KV admission wait to the response following the fetch
+		// regardless of whether it carries any KVs.
+		resp.kvAdmissionWait += f.kvAdmissionWait
+		f.kvAdmissionWait = 0
+	}()
 	// The purpose of this loop is to unpack the two-level batch structure that is
 	// returned from the KV layer.
 	//
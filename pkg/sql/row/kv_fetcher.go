@@ -39,7 +39,8 @@ type KVFetcher struct {
 	// Observability fields.
 	// Note: these need to be read via an atomic op.
 	atomics struct {
-		bytesRead int64
+		bytesRead       int64
+		kvAdmissionWait int64
 	}
 }
 
@@ -153,6 +154,26 @@ func (f *KVFetcher) ResetBytesRead() int64 {
 	return atomic.SwapInt64(&f.atomics.bytesRead, 0)
 }
 
+// GetKVAdmissionWaitTime returns the time the requests of this fetcher spent
+// waiting in KV admission queues. It is safe for concurrent use and is able to
+// handle a case of uninitialized fetcher.
+func (f *KVFetcher) GetKVAdmissionWaitTime() time.Duration {
+	if f == nil {
+		return 0
+	}
+	return time.Duration(atomic.LoadInt64(&f.atomics.kvAdmissionWait))
+}
+
+// ResetKVAdmissionWaitTime resets the KV admission wait time of this fetcher
+// and returns the time before the reset. It is safe for concurrent use and is
+// able to handle a case of uninitialized fetcher.
+func (f *KVFetcher) ResetKVAdmissionWaitTime() time.Duration {
+	if f == nil {
+		return 0
+	}
+	return time.Duration(atomic.SwapInt64(&f.atomics.kvAdmissionWait, 0))
+}
+
 // MVCCDecodingStrategy controls if and how the fetcher should decode MVCC
 // timestamps from returned KV's.
 type MVCCDecodingStrategy int
@@ -221,6 +242,9 @@ func (f *KVFetcher) NextKV(
 		}
 
 		resp, err := f.nextBatch(ctx)
+		if resp.kvAdmissionWait != 0 {
+			atomic.AddInt64(&f.atomics.kvAdmissionWait, int64(resp.kvAdmissionWait))
+		}
 		if err != nil || !resp.moreKVs {
 			return resp.moreKVs, roachpb.KeyValue{}, 0, false, err
 		}
@@ -764,10 +764,11 @@ func (ij *invertedJoiner) execStatsForTrace() *execinfrapb.ComponentStats {
 	ret := execinfrapb.ComponentStats{
 		Inputs: []execinfrapb.InputStats{is},
 		KV: execinfrapb.KVStats{
-			BytesRead:      optional.MakeUint(uint64(ij.fetcher.GetBytesRead())),
-			TuplesRead:     fis.NumTuples,
-			KVTime:         fis.WaitTime,
-			ContentionTime: optional.MakeTimeValue(execstats.GetCumulativeContentionTime(ij.Ctx, ij.ExecStatsTrace)),
+			BytesRead:           optional.MakeUint(uint64(ij.fetcher.GetBytesRead())),
+			TuplesRead:          fis.NumTuples,
+			KVTime:              fis.WaitTime,
+			ContentionTime:      optional.MakeTimeValue(execstats.GetCumulativeContentionTime(ij.Ctx, ij.ExecStatsTrace)),
+			KVAdmissionWaitTime: optional.MakeTimeValue(ij.fetcher.GetKVAdmissionWaitTime()),
 		},
 		Exec: execinfrapb.ExecStats{
 			MaxAllocatedMem:  optional.MakeUint(uint64(ij.MemMonitor.MaximumBytes())),
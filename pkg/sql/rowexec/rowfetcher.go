@@ -52,6 +52,7 @@ type rowFetcher interface {
 
 	Reset()
 	GetBytesRead() int64
+	GetKVAdmissionWaitTime() time.Duration
 	// Close releases any resources held by this fetcher.
 	Close(ctx context.Context)
 }
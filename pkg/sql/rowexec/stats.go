@@ -173,6 +173,11 @@ func (c *rowFetcherStatCollector) GetBytesRead() int64 {
 	return c.fetcher.GetBytesRead()
 }
 
+// GetKVAdmissionWaitTime is part of the rowFetcher interface.
+func (c *rowFetcherStatCollector) GetKVAdmissionWaitTime() time.Duration {
+	return c.fetcher.GetKVAdmissionWaitTime()
+}
+
 // Close is part of the rowFetcher interface.
 func (c *rowFetcherStatCollector) Close(ctx context.Context) {
 	c.fetcher.Close(ctx)
@@ -12,6 +12,7 @@ package rowexec
 
 import (
 	"context"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
@@ -846,8 +847,9 @@ func (z *zigzagJoiner) execStatsForTrace() *execinfrapb.ComponentStats {
 	z.scanStats = execstats.GetScanStats(z.Ctx, z.ExecStatsTrace)
 
 	kvStats := execinfrapb.KVStats{
-		BytesRead:      optional.MakeUint(uint64(z.getBytesRead())),
-		ContentionTime: optional.MakeTimeValue(execstats.GetCumulativeContentionTime(z.Ctx, z.ExecStatsTrace)),
+		BytesRead:           optional.MakeUint(uint64(z.getBytesRead())),
+		ContentionTime:      optional.MakeTimeValue(execstats.GetCumulativeContentionTime(z.Ctx, z.ExecStatsTrace)),
+		KVAdmissionWaitTime: optional.MakeTimeValue(z.getKVAdmissionWaitTime()),
 	}
 	execstats.PopulateKVMVCCStats(&kvStats, &z.scanStats)
 	for i := range z.infos {
@@ -872,6 +874,14 @@ func (z *zigzagJoiner) getBytesRead() int64 {
 	return bytesRead
 }
 
+func (z *zigzagJoiner) getKVAdmissionWaitTime() time.Duration {
+	var admissionWait time.Duration
+	for i := range z.infos {
+		admissionWait += z.infos[i].fetcher.GetKVAdmissionWaitTime()
+	}
+	return admissionWait
+}
+
 func (z *zigzagJoiner) getRowsRead() int64 {
 	var rowsRead int64
 	for i := range z.infos {
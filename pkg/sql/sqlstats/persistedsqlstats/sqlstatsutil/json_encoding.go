@@ -127,6 +127,7 @@ func BuildStmtMetadataJSON(statistics *roachpb.CollectedStatementStatistics) (js
 //            "contentionTime":  { "$ref": "#/definitions/numeric_stats" },
 //            "networkMsgs":     { "$ref": "#/definitions/numeric_stats" },
 //            "maxDiskUsage":    { "$ref": "#/definitions/numeric_stats" },
+//            "kvAdmissionWaitTime": { "$ref": "#/definitions/numeric_stats" },
 //          },
 //          "required": [
 //            "cnt",
@@ -135,6 +136,7 @@ func BuildStmtMetadataJSON(statistics *roachpb.CollectedStatementStatistics) (js
 //            "contentionTime",
 //            "networkMsgs",
 //            "maxDiskUsage",
+//            "kvAdmissionWaitTime",
 //          ]
 //        }
 //      },
@@ -224,6 +226,7 @@ func BuildTxnMetadataJSON(statistics *roachpb.CollectedTransactionStatistics) (j
 //         "contentionTime":  { "$ref": "#/definitions/numeric_stats" },
 //         "networkMsg":      { "$ref": "#/definitions/numeric_stats" },
 //         "maxDiskUsage":    { "$ref": "#/definitions/numeric_stats" },
+//         "kvAdmissionWaitTime": { "$ref": "#/definitions/numeric_stats" },
 //       },
 //       "required": [
 //         "cnt",
@@ -232,6 +235,7 @@ func BuildTxnMetadataJSON(statistics *roachpb.CollectedTransactionStatistics) (j
 //         "contentionTime",
 //         "networkMsg",
 //         "maxDiskUsage",
+//         "kvAdmissionWaitTime",
 //       ]
 //     }
 //   },
@@ -120,6 +120,10 @@ func TestSQLStatsJsonEncoding(t *testing.T) {
          "maxDiskUsage": {
            "mean": {{.Float}},
            "sqDiff": {{.Float}}
+         },
+         "kvAdmissionWaitTime": {
+           "mean": {{.Float}},
+           "sqDiff": {{.Float}}
          }
        }
      }
@@ -238,6 +242,10 @@ func TestSQLStatsJsonEncoding(t *testing.T) {
          "maxDiskUsage": {
            "mean": {{.Float}},
            "sqDiff": {{.Float}}
+         },
+         "kvAdmissionWaitTime": {
+           "mean": {{.Float}},
+           "sqDiff": {{.Float}}
          }
        }
      }
@@ -345,6 +353,10 @@ func TestSQLStatsJsonEncoding(t *testing.T) {
     "maxDiskUsage": {
       "mean": {{.Float}},
       "sqDiff": {{.Float}}
+    },
+    "kvAdmissionWaitTime": {
+      "mean": {{.Float}},
+      "sqDiff": {{.Float}}
     }
   }
 }
@@ -311,6 +311,7 @@ func (e *execStats) jsonFields() jsonFields {
 		{"contentionTime", (*numericStats)(&e.ContentionTime)},
 		{"networkMsgs", (*numericStats)(&e.NetworkMessages)},
 		{"maxDiskUsage", (*numericStats)(&e.MaxDiskUsage)},
+		{"kvAdmissionWaitTime", (*numericStats)(&e.KVAdmissionWaitTime)},
 	}
 }
 
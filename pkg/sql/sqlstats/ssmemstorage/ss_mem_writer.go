@@ -267,6 +267,7 @@ func (s *Container) RecordTransaction(
 		stats.mu.data.ExecStats.ContentionTime.Record(stats.mu.data.ExecStats.Count, value.ExecStats.ContentionTime.Seconds())
 		stats.mu.data.ExecStats.NetworkMessages.Record(stats.mu.data.ExecStats.Count, float64(value.ExecStats.NetworkMessages))
 		stats.mu.data.ExecStats.MaxDiskUsage.Record(stats.mu.data.ExecStats.Count, float64(value.ExecStats.MaxDiskUsage))
+		stats.mu.data.ExecStats.KVAdmissionWaitTime.Record(stats.mu.data.ExecStats.Count, value.ExecStats.KVAdmissionWaitTime.Seconds())
 	}
 
 	s.outliersRegistry.ObserveTransaction(value.SessionID, value.TransactionID)
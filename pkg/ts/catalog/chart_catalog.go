@@ -414,6 +414,7 @@ var charts = []sectionDescription{
 			{
 				Title: "Errors",
 				Metrics: []string{
+					"distsender.rpc.err.admissionoverloadederrtype",
 					"distsender.rpc.err.ambiguousresulterrtype",
 					"distsender.rpc.err.batchtimestampbeforegcerrtype",
 					"distsender.rpc.err.communicationerrtype",
@@ -3191,21 +3192,27 @@ var charts = []sectionDescription{
 					"admission.requested.kv",
 					"admission.admitted.kv",
 					"admission.errored.kv",
+					"admission.canceled.kv",
 					"admission.requested.kv-stores",
 					"admission.admitted.kv-stores",
 					"admission.errored.kv-stores",
+					"admission.canceled.kv-stores",
 					"admission.requested.sql-kv-response",
 					"admission.admitted.sql-kv-response",
 					"admission.errored.sql-kv-response",
+					"admission.canceled.sql-kv-response",
 					"admission.requested.sql-sql-response",
 					"admission.admitted.sql-sql-response",
 					"admission.errored.sql-sql-response",
+					"admission.canceled.sql-sql-response",
 					"admission.requested.sql-leaf-start",
 					"admission.admitted.sql-leaf-start",
 					"admission.errored.sql-leaf-start",
+					"admission.canceled.sql-leaf-start",
 					"admission.requested.sql-root-start",
 					"admission.admitted.sql-root-start",
 					"admission.errored.sql-root-start",
+					"admission.canceled.sql-root-start",
 				},
 			},
 			{
@@ -3256,6 +3263,101 @@ var charts = []sectionDescription{
 					"admission.granter.io_tokens_exhausted_duration.kv",
 				},
 			},
+			{
+				Title: "Tenant KV Admission Counter",
+				Metrics: []string{
+					"admission.tenant_admitted.kv",
+					"admission.tenant_rejected.kv",
+				},
+			},
+			{
+				Title: "Tenant KV Admission Work",
+				Metrics: []string{
+					"admission.tenant_waiting.kv",
+					"admission.tenant_executing.kv",
+				},
+			},
+			{
+				Title: "Tenant KV Admission Latency Sum",
+				Metrics: []string{
+					"admission.tenant_wait_duration_sum.kv",
+				},
+			},
+			{
+				Title: "Tenant KV Admission Reads",
+				Metrics: []string{
+					"admission.tenant_read_keys.kv",
+					"admission.tenant_read_bytes.kv",
+				},
+			},
+			{
+				Title: "KV Admission Double Work Done",
+				Metrics: []string{
+					"admission.double_work_done.kv",
+				},
+			},
+			{
+				Title: "KV Admission Bypassed",
+				Metrics: []string{
+					"admission.bypassed_admin.kv",
+					"admission.bypassed_other_source.kv",
+					"admission.bypassed_allowlist.kv",
+					"admission.bypassed_tenant.kv",
+					"admission.bypassed_store_heartbeat_txn.kv",
+				},
+			},
+			{
+				Title: "KV Admission Follower Application",
+				Metrics: []string{
+					"admission.follower_apply_accounted.kv",
+				},
+			},
+			{
+				Title: "KV Admission Load Shedding",
+				Metrics: []string{
+					"admission.load_shed_max_wait.kv",
+					"admission.load_shed_queue_deadline.kv",
+					"admission.load_shed_queue_length.kv",
+				},
+			},
+			{
+				Title: "KV Admission Store Rebinds",
+				Metrics: []string{
+					"admission.store_rebinds.kv",
+				},
+			},
+			{
+				Title: "KV Admission Store Work Done Errors",
+				Metrics: []string{
+					"admission.store_work_done_errors.kv",
+				},
+			},
+			{
+				Title: "KV Admission Tenant Weights Staleness",
+				Metrics: []string{
+					"admission.tenant_weights_staleness.kv",
+				},
+			},
+			{
+				Title: "KV Admission Wait Durations By Priority",
+				Metrics: []string{
+					"admission.wait_durations_high_pri.kv",
+					"admission.wait_durations_normal_pri.kv",
+					"admission.wait_durations_bulk_pri.kv",
+				},
+			},
+			{
+				Title: "KV Admission Tenant Weight Provider Latency",
+				Metrics: []string{
+					"admission.tenant_weights_provider_latency.kv",
+				},
+			},
+			{
+				Title: "KV Admission Tenant Weight Provider Errors",
+				Metrics: []string{
+					"admission.tenant_weights_provider_errors.kv",
+				},
+			},
 		},
 	},
 	{
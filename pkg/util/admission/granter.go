@@ -13,10 +13,12 @@ package admission
 import (
 	"context"
 	"math"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
@@ -56,6 +58,170 @@ var L0SubLevelCountOverloadThreshold = settings.RegisterIntSetting(
 	"when the L0 sub-level count exceeds this threshold, the store is considered overloaded",
 	l0SubLevelCountOverloadThreshold, settings.PositiveInt)
 
+// StoreOverloadScore returns a score for how close a store is to being
+// overloaded, based on the L0 sub-level count in its (gossiped) capacity. A
+// score of 1 or more means that admission control on that store is likely
+// throttling writes. It is meant for clients that issue bulk writes to many
+// stores, such as backup, restore and import, so that they can pace their
+// fan-out to overloaded stores instead of discovering the overload by
+// queueing on them.
+func StoreOverloadScore(sv *settings.Values, capacity roachpb.StoreCapacity) float64 {
+	score := float64(capacity.L0Sublevels) / float64(L0SubLevelCountOverloadThreshold.Get(sv))
+	// The IOOverloadScore computed by the store's own admission control, if
+	// gossiped, additionally accounts for the L0 file count.
+	return math.Max(score, capacity.IOOverloadScore)
+}
+
+// IOOverloadScore is a snapshot of admission control's view of the IO health
+// of a store.
+type IOOverloadScore struct {
+	// L0NumFiles and L0NumSubLevels are the shape of L0 as of the last time
+	// the tokens were adjusted.
+	L0NumFiles, L0NumSubLevels int64
+	// TokensExhausted is true if writes to the store are waiting for IO
+	// tokens.
+	TokensExhausted bool
+	// Score is the larger of the L0 file count and sub-level count, relative
+	// to their overload thresholds. A score of 1 or more means that admission
+	// control is throttling writes to the store.
+	Score float64
+}
+
+func makeIOOverloadScore(
+	l0NumFiles, l0NumSubLevels int64, thresholds L0OverloadThresholds, tokensExhausted bool,
+) IOOverloadScore {
+	return IOOverloadScore{
+		L0NumFiles:      l0NumFiles,
+		L0NumSubLevels:  l0NumSubLevels,
+		TokensExhausted: tokensExhausted,
+		Score: math.Max(
+			float64(l0NumFiles)/float64(thresholds.FileCount),
+			float64(l0NumSubLevels)/float64(thresholds.SubLevelCount)),
+	}
+}
+
+// CPUOverloadScore is a snapshot of admission control's view of the CPU
+// health of a node.
+type CPUOverloadScore struct {
+	// RunnablePerProc is the smoothed number of runnable goroutines per
+	// processor, which reflects the scheduling latency of goroutines.
+	RunnablePerProc float64
+	// SlotsExhausted is true if KV work was waiting for slots as of the last
+	// CPU load sample.
+	SlotsExhausted bool
+	// Score is the smoothed larger of RunnablePerProc relative to
+	// admission.kv_slot_adjuster.overload_threshold, and 1 while KV work is
+	// waiting for slots. A score of 1 or more means that the CPU of the node
+	// is saturated, and that admission control is queueing KV work.
+	Score float64
+}
+
+// cpuOverloadScoreSmoothingPeriod is the time constant of the exponential
+// smoothing of the CPUOverloadScore. The CPU load is sampled as often as
+// every millisecond, and the score is meant to be gossiped, so it should not
+// flap with each sample.
+const cpuOverloadScoreSmoothingPeriod = time.Second
+
+// L0OverloadThresholds are the L0 file count and sub-level count beyond which
+// a store is considered overloaded. They default to the
+// admission.l0_file_count_overload_threshold and
+// admission.l0_sub_level_count_overload_threshold cluster settings, and can
+// be overridden per store, since stores backed by different disks can have
+// very different healthy L0 shapes. See
+// StoreGrantCoordinators.SetL0OverloadThresholdOverrides.
+type L0OverloadThresholds struct {
+	// FileCount and SubLevelCount are ignored when <= 0, in which case the
+	// corresponding cluster setting is used.
+	FileCount, SubLevelCount int64
+}
+
+// storeHealthQueueAgeThreshold is the age of the oldest request waiting for
+// store admission beyond which the store is considered overloaded.
+var storeHealthQueueAgeThreshold = settings.RegisterDurationSetting(
+	settings.TenantWritable,
+	"admission.store_health.queue_age_threshold",
+	"when the oldest write waiting for admission to a store has waited longer than this "+
+		"threshold, the store is considered overloaded",
+	time.Second, settings.PositiveDuration)
+
+// severeOverloadMultiplier is the multiple of the overload thresholds (both
+// the IOOverloadScore and the queue age) beyond which a store is considered
+// severely overloaded.
+const severeOverloadMultiplier = 4
+
+// StoreHealthStatus is a coarse summary of the health of a store, as seen by
+// admission control.
+type StoreHealthStatus int8
+
+const (
+	// StoreHealthy means that writes to the store are not being throttled.
+	StoreHealthy StoreHealthStatus = iota
+	// StoreOverloaded means that admission control is throttling writes to
+	// the store.
+	StoreOverloaded
+	// StoreSeverelyOverloaded means that admission control is unable to keep
+	// the store healthy, and writes are queueing for a long time.
+	StoreSeverelyOverloaded
+)
+
+// SafeValue implements the redact.SafeValue interface.
+func (StoreHealthStatus) SafeValue() {}
+
+func (s StoreHealthStatus) String() string {
+	switch s {
+	case StoreHealthy:
+		return "healthy"
+	case StoreOverloaded:
+		return "overloaded"
+	case StoreSeverelyOverloaded:
+		return "severely-overloaded"
+	default:
+		return "unknown"
+	}
+}
+
+// StoreHealth is the single signal that components outside admission
+// control, such as circuit breakers and the store rebalancer, should use to
+// decide whether a store is overloaded, rather than re-deriving it from the
+// admission metrics.
+type StoreHealth struct {
+	Status StoreHealthStatus
+	// Reasons explains why the store is not healthy. It is empty when Status
+	// is StoreHealthy.
+	Reasons []string
+	// IOOverloadScore and OldestWaitingDuration are the inputs from which
+	// Status was computed.
+	IOOverloadScore       IOOverloadScore
+	OldestWaitingDuration time.Duration
+}
+
+func makeStoreHealth(
+	sv *settings.Values, score IOOverloadScore, oldestWaitingDuration time.Duration,
+) StoreHealth {
+	h := StoreHealth{IOOverloadScore: score, OldestWaitingDuration: oldestWaitingDuration}
+	setStatus := func(status StoreHealthStatus, reason string) {
+		if status > h.Status {
+			h.Status = status
+		}
+		h.Reasons = append(h.Reasons, reason)
+	}
+	if score.Score >= severeOverloadMultiplier {
+		setStatus(StoreSeverelyOverloaded, "l0 far exceeds overload thresholds")
+	} else if score.Score >= 1 {
+		setStatus(StoreOverloaded, "l0 exceeds overload thresholds")
+	}
+	if score.TokensExhausted {
+		setStatus(StoreOverloaded, "io tokens exhausted")
+	}
+	queueAgeThreshold := storeHealthQueueAgeThreshold.Get(sv)
+	if oldestWaitingDuration >= severeOverloadMultiplier*queueAgeThreshold {
+		setStatus(StoreSeverelyOverloaded, "writes queued far beyond age threshold")
+	} else if oldestWaitingDuration >= queueAgeThreshold {
+		setStatus(StoreOverloaded, "writes queued beyond age threshold")
+	}
+	return h
+}
+
 // grantChainID is the ID for a grant chain. See continueGrantChain for
 // details.
 type grantChainID uint64
@@ -986,6 +1152,18 @@ func (coord *GrantCoordinator) CPULoad(runnable int, procs int, samplePeriod tim
 	coord.tryGrant()
 }
 
+// GetCPUOverloadScore returns the CPUOverloadScore of the node. It is only
+// maintained by the GrantCoordinator that admits KV work, and is zero for
+// others.
+func (coord *GrantCoordinator) GetCPUOverloadScore() CPUOverloadScore {
+	coord.mu.Lock()
+	defer coord.mu.Unlock()
+	if kvsa, ok := coord.cpuLoadListener.(*kvSlotAdjuster); ok {
+		return kvsa.cpuOverloadScore
+	}
+	return CPUOverloadScore{}
+}
+
 // tryGet is called by granter.tryGet with the WorkKind.
 func (coord *GrantCoordinator) tryGet(workKind WorkKind, count int64) bool {
 	coord.mu.Lock()
@@ -1252,11 +1430,19 @@ type StoreGrantCoordinators struct {
 	gcMap syncutil.IntMap // map[int64(StoreID)]*GrantCoordinator
 	// numStores is used to track the number of stores which have been added
 	// to the gcMap. This is used because the IntMap doesn't expose a size
-	// api.
-	numStores             int
+	// api. Accessed atomically, since stores can be added and removed while
+	// the ticker is running.
+	numStores             int32
 	pebbleMetricsProvider PebbleMetricsProvider
 	closeCh               chan struct{}
 
+	// l0ThresholdOverrides are the per-store L0OverloadThresholds, applied to
+	// stores as they are added.
+	l0ThresholdOverrides struct {
+		syncutil.Mutex
+		m map[int32]L0OverloadThresholds
+	}
+
 	disableTickerForTesting bool
 }
 
@@ -1278,7 +1464,7 @@ func (sgc *StoreGrantCoordinators) SetPebbleMetricsProvider(
 		// guards against duplication of stores returned by GetPebbleMetrics.
 		_, loaded := sgc.gcMap.LoadOrStore(int64(m.StoreID), unsafe.Pointer(gc))
 		if !loaded {
-			sgc.numStores++
+			atomic.AddInt32(&sgc.numStores, 1)
 		}
 		gc.pebbleMetricsTick(startupCtx, m.Metrics)
 		gc.allocateIOTokensTick()
@@ -1299,9 +1485,9 @@ func (sgc *StoreGrantCoordinators) SetPebbleMetricsProvider(
 				ticks++
 				if ticks%adjustmentInterval == 0 {
 					metrics := sgc.pebbleMetricsProvider.GetPebbleMetrics()
-					if len(metrics) != sgc.numStores {
+					if numStores := int(atomic.LoadInt32(&sgc.numStores)); len(metrics) != numStores {
 						log.Warningf(ctx,
-							"expected %d store metrics and found %d metrics", sgc.numStores, len(metrics))
+							"expected %d store metrics and found %d metrics", numStores, len(metrics))
 					}
 					for _, m := range metrics {
 						if unsafeGc, ok := sgc.gcMap.Load(int64(m.StoreID)); ok {
@@ -1374,9 +1560,148 @@ func (sgc *StoreGrantCoordinators) initGrantCoordinator(storeID int32) *GrantCoo
 	}
 	coord.ioLoadListener.mu.Mutex = &coord.mu
 	coord.ioLoadListener.mu.kvGranter = kvg
+	sgc.l0ThresholdOverrides.Lock()
+	coord.ioLoadListener.mu.l0ThresholdOverrides = sgc.l0ThresholdOverrides.m[storeID]
+	sgc.l0ThresholdOverrides.Unlock()
 	return coord
 }
 
+// AddStore adds a GrantCoordinator for a store that was added to the node
+// after SetPebbleMetricsProvider was called, using the given metrics to
+// compute the initial tokens. It is a no-op if the store is already known,
+// and before SetPebbleMetricsProvider is called, since the store will be
+// picked up from the PebbleMetricsProvider then.
+func (sgc *StoreGrantCoordinators) AddStore(ctx context.Context, m StoreMetrics) {
+	if sgc.pebbleMetricsProvider == nil {
+		return
+	}
+	gc := sgc.initGrantCoordinator(m.StoreID)
+	gc.pebbleMetricsTick(ctx, m.Metrics)
+	gc.allocateIOTokensTick()
+	if _, loaded := sgc.gcMap.LoadOrStore(int64(m.StoreID), unsafe.Pointer(gc)); loaded {
+		gc.Close()
+		return
+	}
+	atomic.AddInt32(&sgc.numStores, 1)
+}
+
+// RemoveStore removes the GrantCoordinator for a store that was removed from
+// the node. Work waiting in the store's queue is admitted, since the queue
+// will no longer be given tokens, and subsequent calls to TryGetQueueForStore
+// return nil.
+func (sgc *StoreGrantCoordinators) RemoveStore(storeID int32) {
+	unsafeGc, ok := sgc.gcMap.Load(int64(storeID))
+	if !ok {
+		return
+	}
+	sgc.gcMap.Delete(int64(storeID))
+	atomic.AddInt32(&sgc.numStores, -1)
+	gc := (*GrantCoordinator)(unsafeGc)
+	gc.mu.Lock()
+	gc.granters[KVWork].(*kvStoreTokenGranter).availableIOTokens = unlimitedTokens
+	gc.tryGrant()
+	gc.mu.Unlock()
+	gc.Close()
+}
+
+// SetTokenMultiplier sets the cost, in IO tokens, of each byte written to the
+// given store. A multiplier greater than 1 reduces the rate at which work is
+// admitted to the store, for instance when its write amplification is high.
+// A multiplier <= 1 restores the default.
+func (sgc *StoreGrantCoordinators) SetTokenMultiplier(storeID int32, multiplier float64) {
+	if unsafeGc, ok := sgc.gcMap.Load(int64(storeID)); ok {
+		gc := (*GrantCoordinator)(unsafeGc)
+		gc.mu.Lock()
+		gc.ioLoadListener.mu.tokenMultiplier = multiplier
+		gc.mu.Unlock()
+	}
+}
+
+// SetL0OverloadThresholdOverrides replaces the per-store overrides of the L0
+// overload thresholds. Stores without an override use the cluster settings.
+func (sgc *StoreGrantCoordinators) SetL0OverloadThresholdOverrides(
+	overrides map[int32]L0OverloadThresholds,
+) {
+	sgc.l0ThresholdOverrides.Lock()
+	defer sgc.l0ThresholdOverrides.Unlock()
+	sgc.l0ThresholdOverrides.m = overrides
+	sgc.gcMap.Range(func(storeID int64, unsafeGc unsafe.Pointer) bool {
+		gc := (*GrantCoordinator)(unsafeGc)
+		gc.mu.Lock()
+		gc.ioLoadListener.mu.l0ThresholdOverrides = overrides[int32(storeID)]
+		gc.mu.Unlock()
+		// true indicates that iteration should continue after the
+		// current entry has been processed.
+		return true
+	})
+}
+
+// GetIOOverloadScores returns the IOOverloadScore of each known store.
+func (sgc *StoreGrantCoordinators) GetIOOverloadScores() map[int32]IOOverloadScore {
+	scores := make(map[int32]IOOverloadScore)
+	sgc.gcMap.Range(func(storeID int64, unsafeGc unsafe.Pointer) bool {
+		scores[int32(storeID)] = sgc.getIOOverloadScore((*GrantCoordinator)(unsafeGc))
+		// true indicates that iteration should continue after the
+		// current entry has been processed.
+		return true
+	})
+	return scores
+}
+
+// GetIOOverloadScore returns the IOOverloadScore of the given store, and
+// false if the store is not known.
+func (sgc *StoreGrantCoordinators) GetIOOverloadScore(storeID int32) (IOOverloadScore, bool) {
+	if unsafeGc, ok := sgc.gcMap.Load(int64(storeID)); ok {
+		return sgc.getIOOverloadScore((*GrantCoordinator)(unsafeGc)), true
+	}
+	return IOOverloadScore{}, false
+}
+
+func (sgc *StoreGrantCoordinators) getIOOverloadScore(gc *GrantCoordinator) IOOverloadScore {
+	gc.mu.Lock()
+	defer gc.mu.Unlock()
+	kvg := gc.granters[KVWork].(*kvStoreTokenGranter)
+	io := gc.ioLoadListener
+	return makeIOOverloadScore(io.mu.l0NumFiles, io.mu.l0NumSubLevels,
+		io.l0OverloadThresholdsLocked(), kvg.availableIOTokens <= 0 && gc.queues[KVWork].hasWaitingRequests())
+}
+
+// GetWaitingWork returns the requests waiting in the WorkQueue of each known
+// store. See WorkQueue.GetWaitingWork.
+func (sgc *StoreGrantCoordinators) GetWaitingWork() map[int32][]WaitingWork {
+	waiting := make(map[int32][]WaitingWork)
+	sgc.gcMap.Range(func(storeID int64, unsafeGc unsafe.Pointer) bool {
+		gc := (*GrantCoordinator)(unsafeGc)
+		// The queue is not a StoreWorkQueue in some tests.
+		if q, ok := gc.queues[KVWork].(*StoreWorkQueue); ok {
+			waiting[int32(storeID)] = q.GetWaitingWork()
+		}
+		// true indicates that iteration should continue after the
+		// current entry has been processed.
+		return true
+	})
+	return waiting
+}
+
+// GetStoreHealth returns the StoreHealth of each known store.
+func (sgc *StoreGrantCoordinators) GetStoreHealth() map[int32]StoreHealth {
+	health := make(map[int32]StoreHealth)
+	sgc.gcMap.Range(func(storeID int64, unsafeGc unsafe.Pointer) bool {
+		gc := (*GrantCoordinator)(unsafeGc)
+		var oldestWaitingDuration time.Duration
+		// The queue is not a StoreWorkQueue in some tests.
+		if q, ok := gc.queues[KVWork].(*StoreWorkQueue); ok {
+			oldestWaitingDuration = q.q.oldestWaitingDuration()
+		}
+		health[int32(storeID)] = makeStoreHealth(&sgc.settings.SV,
+			sgc.getIOOverloadScore(gc), oldestWaitingDuration)
+		// true indicates that iteration should continue after the
+		// current entry has been processed.
+		return true
+	})
+	return health
+}
+
 // TryGetQueueForStore returns a WorkQueue for the given storeID, or nil if
 // the storeID is not known.
 func (sgc *StoreGrantCoordinators) TryGetQueueForStore(storeID int32) *StoreWorkQueue {
@@ -1456,13 +1781,17 @@ type kvSlotAdjuster struct {
 	maxCPUSlots int
 
 	totalSlotsMetric *metric.Gauge
+
+	// cpuOverloadScore is updated with each CPU load sample.
+	cpuOverloadScore CPUOverloadScore
 }
 
 var _ cpuOverloadIndicator = &kvSlotAdjuster{}
 var _ CPULoadListener = &kvSlotAdjuster{}
 
-func (kvsa *kvSlotAdjuster) CPULoad(runnable int, procs int, _ time.Duration) {
+func (kvsa *kvSlotAdjuster) CPULoad(runnable int, procs int, samplePeriod time.Duration) {
 	threshold := int(KVSlotAdjusterOverloadThreshold.Get(&kvsa.settings.SV))
+	kvsa.updateCPUOverloadScore(runnable, procs, threshold, samplePeriod)
 
 	// Simple heuristic, which worked ok in experiments. More sophisticated ones
 	// could be devised.
@@ -1500,6 +1829,30 @@ func (kvsa *kvSlotAdjuster) CPULoad(runnable int, procs int, _ time.Duration) {
 	kvsa.totalSlotsMetric.Update(int64(kvsa.granter.totalSlots))
 }
 
+// updateCPUOverloadScore incorporates a CPU load sample into the smoothed
+// cpuOverloadScore. Each sample is weighted by the period that it covers.
+func (kvsa *kvSlotAdjuster) updateCPUOverloadScore(
+	runnable int, procs int, threshold int, samplePeriod time.Duration,
+) {
+	if procs <= 0 {
+		return
+	}
+	runnablePerProc := float64(runnable) / float64(procs)
+	slotsExhausted := kvsa.granter.requester != nil && kvsa.granter.requester.hasWaitingRequests()
+	score := runnablePerProc / float64(threshold)
+	if slotsExhausted {
+		score = math.Max(score, 1)
+	}
+	alpha := math.Min(1, float64(samplePeriod)/float64(cpuOverloadScoreSmoothingPeriod))
+	if samplePeriod <= 0 {
+		alpha = 1
+	}
+	s := &kvsa.cpuOverloadScore
+	s.RunnablePerProc = alpha*runnablePerProc + (1-alpha)*s.RunnablePerProc
+	s.Score = alpha*score + (1-alpha)*s.Score
+	s.SlotsExhausted = slotsExhausted
+}
+
 func (kvsa *kvSlotAdjuster) isOverloaded() bool {
 	return kvsa.granter.usedSlots >= kvsa.granter.totalSlots && !kvsa.granter.skipSlotEnforcement
 }
@@ -1568,6 +1921,10 @@ type storeAdmissionStats struct {
 	//
 	// INVARIANT: ingestedAccountedL0Bytes <= ingestedAccountedBytes
 	ingestedAccountedL0Bytes uint64
+	// Bytes appended to raft logs, reported by
+	// StoreWorkQueue.RaftLogAppendedBytes. These are not reflected in the
+	// fields above, since they are not written by admitted requests.
+	raftLogAppendedBytes uint64
 }
 
 // storeRequestEstimates are estimates that the storeRequester should use for
@@ -1633,6 +1990,16 @@ type ioLoadListener struct {
 		// the same as GrantCoordinator.mu.
 		*syncutil.Mutex
 		kvGranter granterWithIOTokens
+		// l0NumFiles and l0NumSubLevels are the shape of L0 as of the last
+		// call to pebbleMetricsTick.
+		l0NumFiles, l0NumSubLevels int64
+		// tokenMultiplier is the cost, in tokens, of each byte admitted. It is
+		// set by StoreGrantCoordinators.SetTokenMultiplier, and values <= 1
+		// are ignored.
+		tokenMultiplier float64
+		// l0ThresholdOverrides overrides the L0 overload thresholds of the
+		// cluster settings for this store.
+		l0ThresholdOverrides L0OverloadThresholds
 	}
 
 	// Stats used to compute interval stats.
@@ -1684,6 +2051,9 @@ const adjustmentInterval = 15
 // pebbleMetricsTicks is called every adjustmentInterval seconds, and decides
 // the token allocations until the next call.
 func (io *ioLoadListener) pebbleMetricsTick(ctx context.Context, m *pebble.Metrics) {
+	io.mu.Lock()
+	io.mu.l0NumFiles, io.mu.l0NumSubLevels = m.Levels[0].NumFiles, int64(m.Levels[0].Sublevels)
+	io.mu.Unlock()
 	if !io.statsInitialized {
 		io.statsInitialized = true
 		io.ioLoadListenerState = ioLoadListenerState{
@@ -1726,17 +2096,38 @@ func (io *ioLoadListener) allocateTokensTick() {
 	if io.tokensAllocated < 0 {
 		panic(errors.AssertionFailedf("tokens allocated is negative %d", io.tokensAllocated))
 	}
+	if m := io.mu.tokenMultiplier; m > 1 && toAllocate < unlimitedTokens/adjustmentInterval {
+		// The tokens are still accounted for as allocated in full above, so the
+		// remainder is not given out later in the interval.
+		toAllocate = int64(float64(toAllocate) / m)
+	}
 	io.mu.kvGranter.setAvailableIOTokensLocked(toAllocate)
 }
 
+// l0OverloadThresholdsLocked returns the L0 overload thresholds of the store,
+// i.e., the overrides if set, and the cluster settings otherwise.
+func (io *ioLoadListener) l0OverloadThresholdsLocked() L0OverloadThresholds {
+	t := io.mu.l0ThresholdOverrides
+	if t.FileCount <= 0 {
+		t.FileCount = L0FileCountOverloadThreshold.Get(&io.settings.SV)
+	}
+	if t.SubLevelCount <= 0 {
+		t.SubLevelCount = L0SubLevelCountOverloadThreshold.Get(&io.settings.SV)
+	}
+	return t
+}
+
 // adjustTokens computes a new value of totalNumByteTokens (and resets
 // tokensAllocated). The new value, when overloaded, is based on comparing how
 // many bytes are being moved out of L0 via compactions with the average
 // number of bytes being added to L0 per KV work. We want the former to be
 // (significantly) larger so that L0 returns to a healthy state.
 func (io *ioLoadListener) adjustTokens(ctx context.Context, m *pebble.Metrics) {
+	io.mu.Lock()
+	thresholds := io.l0OverloadThresholdsLocked()
+	io.mu.Unlock()
 	res := io.adjustTokensInner(ctx, io.ioLoadListenerState, io.kvRequester.getStoreAdmissionStats(), m.Levels[0],
-		L0FileCountOverloadThreshold.Get(&io.settings.SV), L0SubLevelCountOverloadThreshold.Get(&io.settings.SV),
+		thresholds.FileCount, thresholds.SubLevelCount,
 	)
 	io.adjustTokensResult = res
 	io.kvRequester.setStoreRequestEstimates(res.requestEstimates)
@@ -1815,12 +2206,18 @@ func (*ioLoadListener) adjustTokensInner(
 	// tracked here are also tracked in intIngestedAccountedBytes.
 	intIngestedAccountedL0Bytes :=
 		cumAdmissionStats.ingestedAccountedL0Bytes - prev.cumAdmissionStats.ingestedAccountedL0Bytes
+	// intRaftLogAppendedBytes are the bytes appended to raft logs since the last
+	// token adjustment.
+	intRaftLogAppendedBytes :=
+		cumAdmissionStats.raftLogAppendedBytes - prev.cumAdmissionStats.raftLogAppendedBytes
 
 	// intAccountedL0Bytes are the bytes that we expect to have entered L0 based
 	// on the requests tracked over the interval: the bytes ingested into L0 and
 	// all non-ingestion bytes (since regular writes flush to L0 before getting
-	// compacted down).
-	intAccountedL0Bytes := intIngestedAccountedL0Bytes + (intAdmittedBytes - intIngestedAccountedBytes)
+	// compacted down). Raft log appends are accounted like regular writes, even
+	// though some of them are truncated before being flushed.
+	intAccountedL0Bytes := intIngestedAccountedL0Bytes + (intAdmittedBytes - intIngestedAccountedBytes) +
+		intRaftLogAppendedBytes
 	// Bytes that were added to L0 based on LSM stats, but we don't know where
 	// they came from. The sum of the accounted and unaccounted L0 bytes is
 	// exactly the actual L0 growth observed over the interval.
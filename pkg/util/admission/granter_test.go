@@ -18,15 +18,18 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 	"unsafe"
 
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/echotest"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/datadriven"
 	"github.com/cockroachdb/pebble"
@@ -368,6 +371,33 @@ func TestStoreCoordinators(t *testing.T) {
 	require.Equal(t,
 		"kv: tryGet(1) returned false\nkv: tryGet(1) returned true\nkv: tryGet(1) returned true\n",
 		buf.String())
+	require.Equal(t, map[int32]IOOverloadScore{10: {}, 20: {}}, storeCoords.GetIOOverloadScores())
+	require.Equal(t, map[int32]StoreHealth{10: {}, 20: {}}, storeCoords.GetStoreHealth())
+	score, ok := storeCoords.GetIOOverloadScore(10)
+	require.True(t, ok)
+	require.Equal(t, IOOverloadScore{}, score)
+	_, ok = storeCoords.GetIOOverloadScore(30)
+	require.False(t, ok)
+	// Overrides of the L0 thresholds apply to known stores, and to stores that
+	// are added later.
+	overrides := map[int32]L0OverloadThresholds{20: {FileCount: 10}, 30: {SubLevelCount: 5}}
+	storeCoords.SetL0OverloadThresholdOverrides(overrides)
+	unsafeGc, _ := storeCoords.gcMap.Load(20)
+	require.Equal(t, overrides[20], (*GrantCoordinator)(unsafeGc).ioLoadListener.mu.l0ThresholdOverrides)
+	// A store added to the running node gets a GrantCoordinator, and a removed
+	// store no longer has one.
+	storeCoords.AddStore(context.Background(), StoreMetrics{StoreID: 30, Metrics: &metrics})
+	require.Equal(t, 4, len(requesters))
+	unsafeGc, ok = storeCoords.gcMap.Load(30)
+	require.True(t, ok)
+	require.Equal(t, overrides[30], (*GrantCoordinator)(unsafeGc).ioLoadListener.mu.l0ThresholdOverrides)
+	// Adding a known store is a no-op.
+	storeCoords.AddStore(context.Background(), StoreMetrics{StoreID: 30, Metrics: &metrics})
+	require.Equal(t, int32(3), atomic.LoadInt32(&storeCoords.numStores))
+	storeCoords.RemoveStore(10)
+	_, ok = storeCoords.gcMap.Load(10)
+	require.False(t, ok)
+	require.Equal(t, int32(2), atomic.LoadInt32(&storeCoords.numStores))
 	coords.Close()
 }
 
@@ -538,6 +568,33 @@ func (g *testGranterNonNegativeTokens) setAvailableIOTokensLocked(tokens int64)
 	require.LessOrEqual(g.t, int64(0), tokens)
 }
 
+func TestIOLoadListenerTokenMultiplier(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	kvGranter := &testGranterWithIOTokens{}
+	ioll := ioLoadListener{
+		settings:    cluster.MakeTestingClusterSettings(),
+		kvRequester: &testRequesterForIOLL{},
+	}
+	ioll.mu.Mutex = &syncutil.Mutex{}
+	ioll.mu.kvGranter = kvGranter
+	ioll.totalNumByteTokens = 1500
+	ioll.allocateTokensTick()
+	ioll.mu.tokenMultiplier = 2
+	ioll.allocateTokensTick()
+	require.Equal(t, "setAvailableIOTokens: 100setAvailableIOTokens: 50", kvGranter.buf.String())
+	// The scaled down tokens are accounted for in full.
+	require.Equal(t, int64(200), ioll.tokensAllocated)
+
+	// Unlimited tokens stay unlimited.
+	kvGranter.buf.Reset()
+	ioll.totalNumByteTokens = unlimitedTokens
+	ioll.tokensAllocated = 0
+	ioll.allocateTokensTick()
+	require.Equal(t, "setAvailableIOTokens: unlimited", kvGranter.buf.String())
+}
+
 func TestAdjustTokensInnerAndLogging(t *testing.T) {
 	const mb = 12 + 1<<20
 	prevAdmStats := storeAdmissionStats{
@@ -641,3 +698,119 @@ func TestBadIOLoadListenerStats(t *testing.T) {
 		}
 	}
 }
+
+func TestStoreOverloadScore(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	st := cluster.MakeTestingClusterSettings()
+	L0SubLevelCountOverloadThreshold.Override(context.Background(), &st.SV, 20)
+	for _, tc := range []struct {
+		l0Sublevels     int64
+		ioOverloadScore float64
+		expected        float64
+	}{
+		{0, 0, 0},
+		{10, 0, 0.5},
+		{20, 0, 1},
+		{50, 0, 2.5},
+		// The gossiped IOOverloadScore is used when it is higher.
+		{10, 1.5, 1.5},
+		{50, 1.5, 2.5},
+	} {
+		require.Equal(t, tc.expected, StoreOverloadScore(&st.SV, roachpb.StoreCapacity{
+			L0Sublevels:     tc.l0Sublevels,
+			IOOverloadScore: tc.ioOverloadScore,
+		}))
+	}
+}
+
+func TestIOOverloadScore(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	thresholds := L0OverloadThresholds{FileCount: 1000, SubLevelCount: 20}
+	require.Equal(t, IOOverloadScore{L0NumFiles: 500, L0NumSubLevels: 5, Score: 0.5},
+		makeIOOverloadScore(500, 5, thresholds, false /* tokensExhausted */))
+	require.Equal(t, IOOverloadScore{
+		L0NumFiles: 100, L0NumSubLevels: 30, TokensExhausted: true, Score: 1.5},
+		makeIOOverloadScore(100, 30, thresholds, true /* tokensExhausted */))
+}
+
+func TestCPUOverloadScore(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	st := cluster.MakeTestingClusterSettings()
+	KVSlotAdjusterOverloadThreshold.Override(context.Background(), &st.SV, 32)
+	requester := &testRequester{}
+	kvsa := &kvSlotAdjuster{
+		settings:         st,
+		granter:          &slotGranter{requester: requester, totalSlots: 1},
+		minCPUSlots:      1,
+		maxCPUSlots:      100,
+		totalSlotsMetric: metric.NewGauge(metric.Metadata{}),
+	}
+	// A sample covering the smoothing period replaces the score.
+	kvsa.CPULoad(128, 8, time.Second)
+	require.Equal(t, CPUOverloadScore{RunnablePerProc: 16, Score: 0.5}, kvsa.cpuOverloadScore)
+	// Work waiting for slots means that the CPU is saturated, regardless of
+	// the runnable goroutines.
+	requester.waitingRequests = true
+	kvsa.CPULoad(8, 8, time.Second)
+	require.Equal(t, CPUOverloadScore{RunnablePerProc: 1, SlotsExhausted: true, Score: 1},
+		kvsa.cpuOverloadScore)
+	// Shorter samples are smoothed.
+	requester.waitingRequests = false
+	kvsa.CPULoad(0, 8, 100*time.Millisecond)
+	require.False(t, kvsa.cpuOverloadScore.SlotsExhausted)
+	require.InDelta(t, 0.9, kvsa.cpuOverloadScore.Score, 1e-9)
+	require.InDelta(t, 0.9, kvsa.cpuOverloadScore.RunnablePerProc, 1e-9)
+}
+
+func TestL0OverloadThresholdOverrides(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	st := cluster.MakeTestingClusterSettings()
+	L0FileCountOverloadThreshold.Override(context.Background(), &st.SV, 1000)
+	L0SubLevelCountOverloadThreshold.Override(context.Background(), &st.SV, 20)
+	ioll := ioLoadListener{settings: st}
+	ioll.mu.Mutex = &syncutil.Mutex{}
+	require.Equal(t, L0OverloadThresholds{FileCount: 1000, SubLevelCount: 20},
+		ioll.l0OverloadThresholdsLocked())
+	ioll.mu.l0ThresholdOverrides = L0OverloadThresholds{SubLevelCount: 40}
+	require.Equal(t, L0OverloadThresholds{FileCount: 1000, SubLevelCount: 40},
+		ioll.l0OverloadThresholdsLocked())
+	ioll.mu.l0ThresholdOverrides = L0OverloadThresholds{FileCount: 500, SubLevelCount: 10}
+	require.Equal(t, L0OverloadThresholds{FileCount: 500, SubLevelCount: 10},
+		ioll.l0OverloadThresholdsLocked())
+}
+
+func TestStoreHealth(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	st := cluster.MakeTestingClusterSettings()
+	storeHealthQueueAgeThreshold.Override(context.Background(), &st.SV, time.Second)
+	healthy := IOOverloadScore{Score: 0.5}
+	require.Equal(t, StoreHealth{IOOverloadScore: healthy},
+		makeStoreHealth(&st.SV, healthy, 0))
+
+	exhausted := IOOverloadScore{TokensExhausted: true, Score: 1.5}
+	require.Equal(t, StoreHealth{
+		Status:                StoreOverloaded,
+		Reasons:               []string{"l0 exceeds overload thresholds", "io tokens exhausted"},
+		IOOverloadScore:       exhausted,
+		OldestWaitingDuration: 500 * time.Millisecond,
+	}, makeStoreHealth(&st.SV, exhausted, 500*time.Millisecond))
+
+	// The most severe status wins.
+	h := makeStoreHealth(&st.SV, exhausted, 5*time.Second)
+	require.Equal(t, StoreSeverelyOverloaded, h.Status)
+	require.Equal(t, []string{"l0 exceeds overload thresholds", "io tokens exhausted",
+		"writes queued far beyond age threshold"}, h.Reasons)
+	require.Equal(t, StoreSeverelyOverloaded,
+		makeStoreHealth(&st.SV, IOOverloadScore{Score: 4}, 0).Status)
+	require.Equal(t, StoreOverloaded, makeStoreHealth(&st.SV, healthy, time.Second).Status)
+}
@@ -16,6 +16,7 @@ import (
 	"math"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
@@ -91,6 +92,26 @@ var KVStoresTenantWeightsEnabled = settings.RegisterBoolSetting(
 	"when true, tenant weights are enabled for KV-stores admission control",
 	false).WithPublic()
 
+// KVStoresSystemTenantMinShare is the minimum share of the store tokens that
+// is reserved for the system tenant when it has waiting work. This partitions
+// each store work queue into a system tenant partition and an application
+// tenant partition, so that the system tenant's work (e.g. node liveness,
+// jobs and the settings and descriptor tables) makes progress regardless of
+// the tenant weights.
+var KVStoresSystemTenantMinShare = settings.RegisterFloatSetting(
+	settings.SystemOnly,
+	"admission.kv.stores.system_tenant_min_share",
+	"the minimum fraction of the store tokens granted to the system tenant when it "+
+		"has waiting work; 0 disables the reservation",
+	0,
+	func(v float64) error {
+		if v < 0 || v > 1 {
+			return errors.Errorf("cannot be set to a value outside [0, 1]: %f", v)
+		}
+		return nil
+	},
+)
+
 // EpochLIFOEnabled controls whether the adaptive epoch-LIFO scheme is enabled
 // for admission control. Is only relevant when the above admission control
 // settings are also set to true. Unlike those settings, which are granular
@@ -161,6 +182,18 @@ type WorkInfo struct {
 	// when KV work generates other KV work (to avoid deadlock). Ignored
 	// otherwise.
 	BypassAdmission bool
+	// FairnessKey optionally identifies a workload within the tenant, such as
+	// a database or a job. Work with the same priority is shared fairly among
+	// the fairness keys of a tenant, so that a single workload submitting a lot
+	// of work cannot starve the tenant's other workloads. Zero means that the
+	// work has no fairness key, and it is then never delayed behind the work of
+	// other fairness keys.
+	FairnessKey uint64
+	// DisableEpochLIFO forces FIFO ordering of the work, even when epoch-LIFO
+	// ordering is enabled and the work's priority is below the tenant's FIFO
+	// priority threshold. It lets the caller restrict epoch-LIFO ordering to
+	// the work for which tail latency matters, e.g. foreground KV work.
+	DisableEpochLIFO bool
 
 	// Optional information specified only for WorkQueues where the work is tied
 	// to a range. This allows queued work to return early as soon as the range
@@ -211,13 +244,20 @@ type WorkQueue struct {
 	usesTokens  bool
 	tiedToRange bool
 	settings    *cluster.Settings
+	// enforceSystemTenantMinShare is true for the store work queues, which
+	// reserve KVStoresSystemTenantMinShare of their tokens for the system
+	// tenant.
+	enforceSystemTenantMinShare bool
 
 	// Prevents more than one caller to be in Admit and calling tryGet or adding
 	// to the queue. It allows WorkQueue to release mu before calling tryGet and
 	// be assured that it is not competing with another Admit.
 	// Lock ordering is admitMu < mu.
 	admitMu syncutil.Mutex
-	mu      struct {
+	// hasWaitingWork is 1 iff mu.tenantHeap is non-empty. It is updated while
+	// holding mu, and read without holding mu by TryFastAdmit.
+	hasWaitingWork int32
+	mu             struct {
 		syncutil.Mutex
 		// Tenants with waiting work.
 		tenantHeap tenantHeap
@@ -234,7 +274,24 @@ type WorkQueue struct {
 			//
 			// The maps are lazily allocated.
 			active, inactive map[uint64]uint32
+			// unscaled contains the weights provided by SetTenantWeights and
+			// UpdateTenantWeights, before enforcing the tenantWeightCap, and
+			// maxUnscaled is the maximum of these weights, or 1 if that is higher.
+			// Protected by mu. unscaled is lazily allocated.
+			unscaled    map[uint64]uint32
+			maxUnscaled uint32
 		}
+		// tenantBursts is the tenant ID => burst map, set by SetTenantBursts.
+		// Lazily allocated.
+		tenantBursts map[uint64]uint64
+		// tenantPriorityBands is the tenant ID => priority band map, set by
+		// SetTenantPriorityBands. Lazily allocated.
+		tenantPriorityBands map[uint64]uint8
+		// The tokens granted by granted, in total and to the system tenant, since
+		// the last reset of tenantInfo.used. Only maintained when
+		// enforceSystemTenantMinShare is true. Tokens taken without waiting are
+		// not included, since there was no contention for them.
+		grantedTokens, systemGrantedTokens uint64
 		// The highest epoch that is closed.
 		closedEpochThreshold int64
 		// Following values are copied from the cluster settings.
@@ -475,11 +532,30 @@ func (q *WorkQueue) tryCloseEpoch(timeNow time.Time) {
 	}
 }
 
+// canceledWhileWaitingError wraps the error returned by WorkQueue.Admit when
+// the context of the work is done before the work is admitted.
+type canceledWhileWaitingError struct {
+	cause error
+}
+
+func (e *canceledWhileWaitingError) Error() string { return e.cause.Error() }
+
+func (e *canceledWhileWaitingError) Unwrap() error { return e.cause }
+
+// IsCanceledWhileWaiting returns true if err was returned by the Admit method
+// of a WorkQueue or StoreWorkQueue because the context of the work was
+// canceled, or its deadline expired, before the work was admitted. Such an
+// error also wraps the error of the context.
+func IsCanceledWhileWaiting(err error) bool {
+	return errors.HasType(err, (*canceledWhileWaitingError)(nil))
+}
+
 // Admit is called when requesting admission for some work. If err!=nil, the
-// request was not admitted, potentially due to the deadline being exceeded.
-// The enabled return value is relevant when err=nil, and represents whether
-// admission control is enabled. AdmittedWorkDone must be called iff
-// enabled=true && err!=nil, and the WorkKind for this queue uses slots.
+// request was not admitted, potentially due to the deadline being exceeded,
+// see IsCanceledWhileWaiting. The enabled return value is relevant when
+// err=nil, and represents whether admission control is enabled.
+// AdmittedWorkDone must be called iff enabled=true && err!=nil, and the
+// WorkKind for this queue uses slots.
 func (q *WorkQueue) Admit(ctx context.Context, info WorkInfo) (enabled bool, err error) {
 	enabledSetting := admissionControlEnabledSettings[q.workKind]
 	if enabledSetting != nil && !enabledSetting.Get(&q.settings.SV) {
@@ -504,7 +580,8 @@ func (q *WorkQueue) Admit(ctx context.Context, info WorkInfo) (enabled bool, err
 	q.mu.Lock()
 	tenant, ok := q.mu.tenants[tenantID]
 	if !ok {
-		tenant = newTenantInfo(tenantID, q.getTenantWeightLocked(tenantID))
+		tenant = newTenantInfo(tenantID, q.getTenantWeightLocked(tenantID), q.mu.tenantBursts[tenantID],
+			q.mu.tenantPriorityBands[tenantID])
 		q.mu.tenants[tenantID] = tenant
 	}
 	if info.BypassAdmission && roachpb.IsSystemTenantID(tenantID) && q.workKind == KVWork {
@@ -567,7 +644,8 @@ func (q *WorkQueue) Admit(ctx context.Context, info WorkInfo) (enabled bool, err
 			tenant.used -= uint64(info.requestedCount)
 		} else {
 			if !ok {
-				tenant = newTenantInfo(tenantID, q.getTenantWeightLocked(tenantID))
+				tenant = newTenantInfo(tenantID, q.getTenantWeightLocked(tenantID), q.mu.tenantBursts[tenantID],
+					q.mu.tenantPriorityBands[tenantID])
 				q.mu.tenants[tenantID] = tenant
 			}
 			// Don't want to overflow tenant.used if it is already 0 because of
@@ -585,17 +663,19 @@ func (q *WorkQueue) Admit(ctx context.Context, info WorkInfo) (enabled bool, err
 		q.mu.Unlock()
 		q.admitMu.Unlock()
 		q.metrics.Errored.Inc(1)
+		q.metrics.Canceled.Inc(1)
 		deadline, _ := ctx.Deadline()
-		return true,
-			errors.Newf("work %s deadline already expired: deadline: %v, now: %v",
-				workKindString(q.workKind), deadline, startTime)
+		return true, &canceledWhileWaitingError{cause: errors.Wrapf(ctx.Err(),
+			"work %s deadline already expired: deadline: %v, now: %v",
+			workKindString(q.workKind), deadline, startTime)}
 	}
 	// Push onto heap(s).
 	ordering := fifoWorkOrdering
-	if int(info.Priority) < tenant.fifoPriorityThreshold {
+	if int(info.Priority) < tenant.fifoPriorityThreshold && !info.DisableEpochLIFO {
 		ordering = lifoWorkOrdering
 	}
 	work := newWaitingWork(info.Priority, ordering, info.CreateTime, info.requestedCount, startTime, q.mu.epochLengthNanos)
+	work.fairnessSeq = tenant.nextFairnessSeq(info.FairnessKey)
 	inTenantHeap := isInTenantHeap(tenant)
 	if work.epoch <= q.mu.closedEpochThreshold || ordering == fifoWorkOrdering {
 		heap.Push(&tenant.waitingWorkHeap, work)
@@ -604,6 +684,7 @@ func (q *WorkQueue) Admit(ctx context.Context, info WorkInfo) (enabled bool, err
 	}
 	if !inTenantHeap {
 		heap.Push(&q.mu.tenantHeap, tenant)
+		q.updateHasWaitingWorkLocked()
 	}
 	// Else already in tenantHeap.
 
@@ -651,19 +732,21 @@ func (q *WorkQueue) Admit(ctx context.Context, info WorkInfo) (enabled bool, err
 			}
 			if !isInTenantHeap(tenant) {
 				q.mu.tenantHeap.remove(tenant)
+				q.updateHasWaitingWorkLocked()
 			}
 			q.mu.Unlock()
 		}
 		q.metrics.Errored.Inc(1)
+		q.metrics.Canceled.Inc(1)
 		q.metrics.WaitDurationSum.Inc(waitDur.Microseconds())
 		q.metrics.WaitDurations.RecordValue(waitDur.Nanoseconds())
 		q.metrics.WaitQueueLength.Dec(1)
 		deadline, _ := ctx.Deadline()
 		log.Eventf(ctx, "deadline expired, waited in %s queue for %v",
 			workKindString(q.workKind), waitDur)
-		return true,
-			errors.Newf("work %s deadline expired while waiting: deadline: %v, start: %v, dur: %v",
-				workKindString(q.workKind), deadline, startTime, waitDur)
+		return true, &canceledWhileWaitingError{cause: errors.Wrapf(ctx.Err(),
+			"work %s deadline expired while waiting: deadline: %v, start: %v, dur: %v",
+			workKindString(q.workKind), deadline, startTime, waitDur)}
 	case chainID, ok := <-work.ch:
 		if !ok {
 			panic(errors.AssertionFailedf("channel should not be closed"))
@@ -704,12 +787,120 @@ func (q *WorkQueue) AdmittedWorkDone(tenantID roachpb.TenantID) {
 	q.granter.returnGrant(1)
 }
 
+// TryFastAdmit attempts to admit the work without waiting, and is meant to
+// be called before Admit by callers that admit a lot of small work. It
+// returns true if the work was admitted, in which case AdmittedWorkDone must
+// be called, and Admit must not be called. Otherwise, the caller must call
+// Admit.
+//
+// Unlike Admit, it does not serialize with other admissions, and when work
+// is waiting it fails without acquiring any mutex, since the work must queue
+// behind the waiting work. When no work is waiting, the only mutexes
+// acquired are the ones protecting the accounting of the tenant and the
+// granter. Since Admit may queue work concurrently, the waiting work is
+// checked for again once the slot is acquired, and the slot is given back to
+// the waiting work if there is any, such that the work admitted here never
+// jumps ahead of queued work. It only supports WorkQueues that use slots, and
+// work that is subject to admission control.
+func (q *WorkQueue) TryFastAdmit(info WorkInfo) bool {
+	if q.usesTokens || info.BypassAdmission || atomic.LoadInt32(&q.hasWaitingWork) != 0 {
+		return false
+	}
+	enabledSetting := admissionControlEnabledSettings[q.workKind]
+	if enabledSetting != nil && !enabledSetting.Get(&q.settings.SV) {
+		// Admit will report that admission control is disabled.
+		return false
+	}
+	tenantID := info.TenantID.ToUint64()
+	q.mu.Lock()
+	if len(q.mu.tenantHeap) > 0 {
+		// Raced with work that started waiting.
+		q.mu.Unlock()
+		return false
+	}
+	tenant, ok := q.mu.tenants[tenantID]
+	if !ok {
+		tenant = newTenantInfo(tenantID, q.getTenantWeightLocked(tenantID), q.mu.tenantBursts[tenantID],
+			q.mu.tenantPriorityBands[tenantID])
+		q.mu.tenants[tenantID] = tenant
+	}
+	// NB: requestAtPriority is idempotent, so it is harmless for Admit to
+	// repeat it if the work is not admitted here.
+	tenant.priorityStates.requestAtPriority(info.Priority)
+	// Optimistically update used to avoid locking again, as in Admit. Since
+	// the queue uses slots, the tenant is not removed while used is non-zero.
+	tenant.used++
+	q.mu.Unlock()
+	if !q.granter.tryGet(1) {
+		q.undoFastAdmitUsed(tenant)
+		return false
+	}
+	if atomic.LoadInt32(&q.hasWaitingWork) != 0 {
+		// Raced with work that started waiting after the check above. The slot
+		// is returned, which grants it to the waiting work, and the caller
+		// queues behind it in Admit.
+		q.undoFastAdmitUsed(tenant)
+		q.granter.returnGrant(1)
+		return false
+	}
+	q.metrics.Requested.Inc(1)
+	q.metrics.Admitted.Inc(1)
+	// The work did not wait, which is recorded so that the wait durations
+	// reflect all the work admitted by the queue.
+	q.metrics.WaitDurations.RecordValue(0)
+	return true
+}
+
+// undoFastAdmitUsed undoes the optimistic update of tenant.used by
+// TryFastAdmit, when the work is not admitted.
+func (q *WorkQueue) undoFastAdmitUsed(tenant *tenantInfo) {
+	q.mu.Lock()
+	tenant.used--
+	if isInTenantHeap(tenant) {
+		q.mu.tenantHeap.fix(tenant)
+	}
+	q.mu.Unlock()
+}
+
+// updateHasWaitingWorkLocked must be called after adding a tenant to, or
+// removing a tenant from, mu.tenantHeap.
+func (q *WorkQueue) updateHasWaitingWorkLocked() {
+	var v int32
+	if len(q.mu.tenantHeap) > 0 {
+		v = 1
+	}
+	atomic.StoreInt32(&q.hasWaitingWork, v)
+}
+
 func (q *WorkQueue) hasWaitingRequests() bool {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 	return len(q.mu.tenantHeap) > 0
 }
 
+// oldestWaitingDuration returns how long the oldest waiting request has been
+// waiting, or 0 if there are no waiting requests. It is O(number of waiting
+// requests), and is meant to be called infrequently.
+func (q *WorkQueue) oldestWaitingDuration() time.Duration {
+	now := q.timeNow()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var oldest time.Duration
+	for _, tenant := range q.mu.tenantHeap {
+		for _, work := range tenant.waitingWorkHeap {
+			if d := now.Sub(work.enqueueingTime); d > oldest {
+				oldest = d
+			}
+		}
+		for _, work := range tenant.openEpochsHeap {
+			if d := now.Sub(work.enqueueingTime); d > oldest {
+				oldest = d
+			}
+		}
+	}
+	return oldest
+}
+
 func (q *WorkQueue) granted(grantChainID grantChainID) int64 {
 	// Reduce critical section by getting time before mutex acquisition.
 	now := q.timeNow()
@@ -719,6 +910,9 @@ func (q *WorkQueue) granted(grantChainID grantChainID) int64 {
 		return 0
 	}
 	tenant := q.mu.tenantHeap[0]
+	if q.enforceSystemTenantMinShare {
+		tenant = q.maybeSelectSystemTenantLocked(tenant)
+	}
 	var item *waitingWork
 	if len(tenant.waitingWorkHeap) > 0 {
 		item = heap.Pop(&tenant.waitingWorkHeap).(*waitingWork)
@@ -728,10 +922,20 @@ func (q *WorkQueue) granted(grantChainID grantChainID) int64 {
 	waitDur := now.Sub(item.enqueueingTime)
 	tenant.priorityStates.updateDelayLocked(item.priority, waitDur, false /* canceled */)
 	tenant.used += uint64(item.requestedCount)
+	if item.fairnessSeq > tenant.fairnessVirtualTime {
+		tenant.fairnessVirtualTime = item.fairnessSeq
+	}
+	if q.enforceSystemTenantMinShare {
+		q.mu.grantedTokens += uint64(item.requestedCount)
+		if roachpb.IsSystemTenantID(tenant.id) {
+			q.mu.systemGrantedTokens += uint64(item.requestedCount)
+		}
+	}
 	if isInTenantHeap(tenant) {
 		q.mu.tenantHeap.fix(tenant)
 	} else {
 		q.mu.tenantHeap.remove(tenant)
+		q.updateHasWaitingWorkLocked()
 	}
 	// Get the value of requestedCount before releasing the mutex, since after
 	// releasing Admit can notice that item is no longer in the heap and call
@@ -743,9 +947,33 @@ func (q *WorkQueue) granted(grantChainID grantChainID) int64 {
 	return requestedCount
 }
 
+// maybeSelectSystemTenantLocked returns the system tenant instead of the top
+// tenant in the tenantHeap, if the system tenant has waiting work and has been
+// granted less than KVStoresSystemTenantMinShare of the tokens.
+func (q *WorkQueue) maybeSelectSystemTenantLocked(top *tenantInfo) *tenantInfo {
+	if roachpb.IsSystemTenantID(top.id) {
+		return top
+	}
+	minShare := KVStoresSystemTenantMinShare.Get(&q.settings.SV)
+	if minShare == 0 {
+		return top
+	}
+	systemTenant, ok := q.mu.tenants[roachpb.SystemTenantID.ToUint64()]
+	if !ok || !isInTenantHeap(systemTenant) {
+		return top
+	}
+	if float64(q.mu.systemGrantedTokens) >= minShare*float64(q.mu.grantedTokens) {
+		return top
+	}
+	return systemTenant
+}
+
 func (q *WorkQueue) gcTenantsAndResetTokens() {
 	q.mu.Lock()
 	defer q.mu.Unlock()
+	if q.usesTokens {
+		q.mu.grantedTokens, q.mu.systemGrantedTokens = 0, 0
+	}
 	// With large numbers of active tenants, this iteration could hold the lock
 	// longer than desired. We could break this iteration into smaller parts if
 	// needed.
@@ -753,10 +981,13 @@ func (q *WorkQueue) gcTenantsAndResetTokens() {
 		if info.used == 0 && !isInTenantHeap(info) {
 			delete(q.mu.tenants, id)
 			releaseTenantInfo(info)
-		} else if q.usesTokens {
-			info.used = 0
-			// All the heap members will reset used=0, so no need to change heap
-			// ordering.
+		} else {
+			info.gcFairnessKeys()
+			if q.usesTokens {
+				info.used = 0
+				// All the heap members will reset used=0, so no need to change heap
+				// ordering.
+			}
 		}
 	}
 }
@@ -789,6 +1020,90 @@ func (q *WorkQueue) forceAllocateTokens(tenantID roachpb.TenantID, count int64)
 	}
 }
 
+// TenantUsage describes the weight and usage of a tenant in a WorkQueue.
+type TenantUsage struct {
+	TenantID uint64
+	// Weight is the weight in effect for the tenant, after applying the cap on
+	// tenant weights.
+	Weight uint32
+	// Used is the currently used slots, or the tokens granted within the last
+	// interval.
+	Used uint64
+}
+
+// GetTenantUsage returns the weight and usage of the tenants that have been
+// assigned a weight or have recently used the WorkQueue, in increasing order
+// of tenant ID.
+func (q *WorkQueue) GetTenantUsage() []TenantUsage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	usage := make([]TenantUsage, 0, len(q.mu.tenants))
+	for id, tenant := range q.mu.tenants {
+		usage = append(usage, TenantUsage{TenantID: id, Weight: tenant.weight, Used: tenant.used})
+	}
+	for id, weight := range q.mu.tenantWeights.active {
+		if _, ok := q.mu.tenants[id]; !ok {
+			usage = append(usage, TenantUsage{TenantID: id, Weight: weight})
+		}
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].TenantID < usage[j].TenantID })
+	return usage
+}
+
+// WaitingWork describes a request that is waiting in a WorkQueue.
+type WaitingWork struct {
+	TenantID   uint64
+	Priority   admissionpb.WorkPriority
+	CreateTime int64
+	// WaitDuration is how long the request has been waiting.
+	WaitDuration time.Duration
+	// RequestedCount is the number of slots or tokens requested.
+	RequestedCount int64
+}
+
+// GetWaitingWork returns the requests waiting in the WorkQueue, in
+// decreasing order of WaitDuration. It is O(number of waiting requests), and
+// is meant for debugging.
+func (q *WorkQueue) GetWaitingWork() []WaitingWork {
+	now := q.timeNow()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var waiting []WaitingWork
+	add := func(tenantID uint64, work *waitingWork) {
+		waiting = append(waiting, WaitingWork{
+			TenantID:       tenantID,
+			Priority:       work.priority,
+			CreateTime:     work.createTime,
+			WaitDuration:   now.Sub(work.enqueueingTime),
+			RequestedCount: work.requestedCount,
+		})
+	}
+	for _, tenant := range q.mu.tenantHeap {
+		for _, work := range tenant.waitingWorkHeap {
+			add(tenant.id, work)
+		}
+		for _, work := range tenant.openEpochsHeap {
+			add(tenant.id, work)
+		}
+	}
+	sort.SliceStable(waiting, func(i, j int) bool {
+		return waiting[i].WaitDuration > waiting[j].WaitDuration
+	})
+	return waiting
+}
+
+// NumWaiting returns the number of requests waiting in the WorkQueue. It is
+// O(number of tenants with waiting requests).
+func (q *WorkQueue) NumWaiting() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var n int
+	for _, tenant := range q.mu.tenantHeap {
+		n += len(tenant.waitingWorkHeap) + len(tenant.openEpochsHeap)
+	}
+	return n
+}
+
 func (q *WorkQueue) String() string {
 	return redact.StringWithoutMarkers(q)
 }
@@ -811,6 +1126,12 @@ func (q *WorkQueue) SafeFormat(s redact.SafePrinter, verb rune) {
 		tenant := q.mu.tenants[id]
 		s.Printf("\n tenant-id: %d used: %d, w: %d, fifo: %d", tenant.id, tenant.used,
 			tenant.weight, tenant.fifoPriorityThreshold)
+		if tenant.burst > 0 {
+			s.Printf(", burst: %d", tenant.burst)
+		}
+		if tenant.priorityBand > 0 {
+			s.Printf(", band: %d", tenant.priorityBand)
+		}
 		if len(tenant.waitingWorkHeap) > 0 {
 			s.Printf(" waiting work heap:")
 			for i := range tenant.waitingWorkHeap {
@@ -818,11 +1139,15 @@ func (q *WorkQueue) SafeFormat(s redact.SafePrinter, verb rune) {
 				if tenant.waitingWorkHeap[i].arrivalTimeWorkOrdering == lifoWorkOrdering {
 					workOrdering = ", lifo-ordering"
 				}
-				s.Printf(" [%d: pri: %d, ct: %d, epoch: %d, qt: %d%s]", i,
+				s.Printf(" [%d: pri: %d, ct: %d, epoch: %d, qt: %d%s", i,
 					tenant.waitingWorkHeap[i].priority,
 					tenant.waitingWorkHeap[i].createTime/int64(time.Millisecond),
 					tenant.waitingWorkHeap[i].epoch,
 					tenant.waitingWorkHeap[i].enqueueingTime.UnixNano()/int64(time.Millisecond), workOrdering)
+				if tenant.waitingWorkHeap[i].fairnessSeq > 0 {
+					s.Printf(", seq: %d", tenant.waitingWorkHeap[i].fairnessSeq)
+				}
+				s.Printf("]")
 			}
 		}
 		if len(tenant.openEpochsHeap) > 0 {
@@ -865,31 +1190,125 @@ func (q *WorkQueue) getTenantWeightLocked(tenantID uint64) uint32 {
 func (q *WorkQueue) SetTenantWeights(tenantWeights map[uint64]uint32) {
 	q.mu.tenantWeights.mu.Lock()
 	defer q.mu.tenantWeights.mu.Unlock()
-	if q.mu.tenantWeights.inactive == nil {
-		q.mu.tenantWeights.inactive = make(map[uint64]uint32)
+	unscaled := make(map[uint64]uint32, len(tenantWeights))
+	for k, v := range tenantWeights {
+		unscaled[k] = v
 	}
-	// Remove all elements from the inactive map.
-	for k := range q.mu.tenantWeights.inactive {
-		delete(q.mu.tenantWeights.inactive, k)
+	q.mu.tenantWeights.unscaled = unscaled
+	q.mu.tenantWeights.maxUnscaled = maxTenantWeight(unscaled)
+	q.setTenantWeightsLocked()
+}
+
+// UpdateTenantWeights updates the weights of the tenants in the provided
+// tenant ID => weight map, and resets the weights of the tenants in removals
+// to the default, leaving the weights of other tenants unchanged. If a
+// tenant is in both, it is updated. Unlike SetTenantWeights, the work done is
+// proportional to the number of changes, unless they change the scaling
+// required to enforce the tenantWeightCap.
+func (q *WorkQueue) UpdateTenantWeights(updates map[uint64]uint32, removals []uint64) {
+	q.mu.tenantWeights.mu.Lock()
+	defer q.mu.tenantWeights.mu.Unlock()
+	tw := &q.mu.tenantWeights
+	if tw.unscaled == nil {
+		tw.unscaled = make(map[uint64]uint32)
+		tw.maxUnscaled = 1
+	}
+	prevMax := tw.maxUnscaled
+	// The max weight needs to be recomputed if a tenant with the max weight is
+	// removed or has its weight decreased.
+	recomputeMax := false
+	for _, id := range removals {
+		if w, ok := tw.unscaled[id]; ok {
+			delete(tw.unscaled, id)
+			recomputeMax = recomputeMax || w == prevMax
+		}
+	}
+	for id, w := range updates {
+		if prev, ok := tw.unscaled[id]; ok && prev == prevMax && w < prev {
+			recomputeMax = true
+		}
+		tw.unscaled[id] = w
+		if w > tw.maxUnscaled {
+			tw.maxUnscaled = w
+		}
 	}
-	// Compute the max weight in the new map, for enforcing the tenantWeightCap.
+	if recomputeMax {
+		tw.maxUnscaled = maxTenantWeight(tw.unscaled)
+	}
+	if tw.maxUnscaled != prevMax && (tw.maxUnscaled > tenantWeightCap || prevMax > tenantWeightCap) {
+		// The scaling has changed, so all the weights need to be recomputed.
+		q.setTenantWeightsLocked()
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if tw.active == nil {
+		tw.active = make(map[uint64]uint32)
+	}
+	for _, id := range removals {
+		if _, ok := updates[id]; !ok {
+			delete(tw.active, id)
+			q.updateTenantWeightLocked(id)
+		}
+	}
+	for id, w := range updates {
+		tw.active[id] = scaleTenantWeight(w, tw.maxUnscaled)
+		q.updateTenantWeightLocked(id)
+	}
+}
+
+// maxTenantWeight returns the maximum of the weights, or 1 if that is higher.
+func maxTenantWeight(weights map[uint64]uint32) uint32 {
 	maxWeight := uint32(1)
-	for _, v := range tenantWeights {
+	for _, v := range weights {
 		if v > maxWeight {
 			maxWeight = v
 		}
 	}
+	return maxWeight
+}
+
+// scaleTenantWeight scales the weight to enforce the tenantWeightCap, given
+// the max weight of all tenants.
+func scaleTenantWeight(weight uint32, maxWeight uint32) uint32 {
 	scaling := float64(1)
 	if maxWeight > tenantWeightCap {
 		scaling = tenantWeightCap / float64(maxWeight)
 	}
-	// Populate the weights in the inactive map.
-	for k, v := range tenantWeights {
-		w := uint32(math.Ceil(float64(v) * scaling))
-		if w < defaultTenantWeight {
-			w = defaultTenantWeight
+	w := uint32(math.Ceil(float64(weight) * scaling))
+	if w < defaultTenantWeight {
+		w = defaultTenantWeight
+	}
+	return w
+}
+
+// updateTenantWeightLocked updates the weight of the tenant's tenantInfo, if
+// any, to its active weight.
+func (q *WorkQueue) updateTenantWeightLocked(tenantID uint64) {
+	tenantInfo := q.mu.tenants[tenantID]
+	weight := q.getTenantWeightLocked(tenantID)
+	if tenantInfo != nil && tenantInfo.weight != weight {
+		tenantInfo.weight = weight
+		if isInTenantHeap(tenantInfo) {
+			q.mu.tenantHeap.fix(tenantInfo)
 		}
-		q.mu.tenantWeights.inactive[k] = w
+	}
+}
+
+// setTenantWeightsLocked makes tenantWeights.unscaled the active weights,
+// after enforcing the tenantWeightCap. tenantWeights.mu must be held.
+func (q *WorkQueue) setTenantWeightsLocked() {
+	if q.mu.tenantWeights.inactive == nil {
+		q.mu.tenantWeights.inactive = make(map[uint64]uint32)
+	}
+	// Remove all elements from the inactive map.
+	for k := range q.mu.tenantWeights.inactive {
+		delete(q.mu.tenantWeights.inactive, k)
+	}
+	// Populate the weights in the inactive map.
+	maxWeight := q.mu.tenantWeights.maxUnscaled
+	for k, v := range q.mu.tenantWeights.unscaled {
+		q.mu.tenantWeights.inactive[k] = scaleTenantWeight(v, maxWeight)
 	}
 	q.mu.Lock()
 	// Establish the new active map.
@@ -922,15 +1341,7 @@ func (q *WorkQueue) SetTenantWeights(tenantWeights map[uint64]uint32) {
 			if index >= n {
 				return false
 			}
-			tenantID := tenantIDs[index]
-			tenantInfo := q.mu.tenants[tenantID]
-			weight := q.getTenantWeightLocked(tenantID)
-			if tenantInfo != nil && tenantInfo.weight != weight {
-				tenantInfo.weight = weight
-				if isInTenantHeap(tenantInfo) {
-					q.mu.tenantHeap.fix(tenantInfo)
-				}
-			}
+			q.updateTenantWeightLocked(tenantIDs[index])
 			index++
 		}
 		return true
@@ -939,6 +1350,62 @@ func (q *WorkQueue) SetTenantWeights(tenantWeights map[uint64]uint32) {
 	}
 }
 
+// SetTenantBursts sets the burst allowance of tenants, using the provided
+// tenant ID => burst map. The first burst units of a tenant's usage (slots
+// currently in use, or tokens granted within the last interval) are not
+// counted when ordering tenants, so a tenant with a spiky workload is not
+// penalized relative to other tenants until it exceeds its burst. A nil map
+// removes the burst allowance of all tenants.
+func (q *WorkQueue) SetTenantBursts(tenantBursts map[uint64]uint64) {
+	bursts := make(map[uint64]uint64, len(tenantBursts))
+	for k, v := range tenantBursts {
+		if v > 0 {
+			bursts[k] = v
+		}
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.mu.tenantBursts = bursts
+	// Unlike SetTenantWeights, we don't bother splitting the update into
+	// batches, since tenants with a burst allowance are expected to be rare.
+	for id, tenant := range q.mu.tenants {
+		burst := bursts[id]
+		if tenant.burst != burst {
+			tenant.burst = burst
+			if isInTenantHeap(tenant) {
+				q.mu.tenantHeap.fix(tenant)
+			}
+		}
+	}
+}
+
+// SetTenantPriorityBands sets the priority band of tenants, using the
+// provided tenant ID => band map. Tenants in a higher band are always
+// preferred over tenants in a lower band, regardless of their usage and
+// weights, so work of a lower band tenant cannot delay work of a higher band
+// tenant that is waiting in this queue. Tenants not in the map are in band 0.
+// A nil map puts all tenants in band 0.
+func (q *WorkQueue) SetTenantPriorityBands(tenantBands map[uint64]uint8) {
+	bands := make(map[uint64]uint8, len(tenantBands))
+	for k, v := range tenantBands {
+		if v > 0 {
+			bands[k] = v
+		}
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.mu.tenantPriorityBands = bands
+	for id, tenant := range q.mu.tenants {
+		band := bands[id]
+		if tenant.priorityBand != band {
+			tenant.priorityBand = band
+			if isInTenantHeap(tenant) {
+				q.mu.tenantHeap.fix(tenant)
+			}
+		}
+	}
+}
+
 // close tells the gc goroutine to stop.
 func (q *WorkQueue) close() {
 	close(q.stopCh)
@@ -1090,6 +1557,12 @@ type tenantInfo struct {
 	id uint64
 	// The weight assigned to the tenant. Must be > 0.
 	weight uint32
+	// The burst allowance of the tenant. The first burst units of used are not
+	// counted when ordering tenants in the tenantHeap.
+	burst uint64
+	// The priority band of the tenant. Tenants in higher bands are ordered
+	// before tenants in lower bands in the tenantHeap.
+	priorityBand uint8
 	// used can be the currently used slots, or the tokens granted within the last
 	// interval.
 	//
@@ -1119,6 +1592,16 @@ type tenantInfo struct {
 	waitingWorkHeap waitingWorkHeap
 	openEpochsHeap  openEpochsHeap
 
+	// Fairness among the fairness keys (see WorkInfo.FairnessKey) of this
+	// tenant is achieved using start-time fair queueing: each queued work is
+	// assigned a sequence number, which orders work with the same priority.
+	// fairnessVirtualTime is the highest sequence number granted, and
+	// fairnessKeySeqs contains the next sequence number for each fairness key
+	// (lazily allocated). So work from a fairness key with n queued works is
+	// ordered after the first n works queued by other fairness keys.
+	fairnessVirtualTime uint64
+	fairnessKeySeqs     map[uint64]uint64
+
 	priorityStates priorityStates
 	// priority >= fifoPriorityThreshold is FIFO. This uses a larger sized type
 	// than WorkPriority since the threshold can be > MaxPri.
@@ -1129,9 +1612,12 @@ type tenantInfo struct {
 	heapIndex int
 }
 
-// tenantHeap is a heap of tenants with waiting work, ordered in increasing
-// order of tenantInfo.used/tenantInfo.weight (weights are an optional
-// feature, and default to 1). That is, we prefer tenants that are using less.
+// tenantHeap is a heap of tenants with waiting work, ordered in decreasing
+// order of tenantInfo.priorityBand (an optional feature, defaulting to 0),
+// and then in increasing order of tenantInfo.used/tenantInfo.weight (weights
+// are an optional feature, and default to 1), where used excludes the
+// tenant's burst allowance (also optional, and defaults to 0). That is, we
+// prefer tenants that are using less.
 type tenantHeap []*tenantInfo
 
 var _ heap.Interface = (*tenantHeap)(nil)
@@ -1142,11 +1628,13 @@ var tenantInfoPool = sync.Pool{
 	},
 }
 
-func newTenantInfo(id uint64, weight uint32) *tenantInfo {
+func newTenantInfo(id uint64, weight uint32, burst uint64, priorityBand uint8) *tenantInfo {
 	ti := tenantInfoPool.Get().(*tenantInfo)
 	*ti = tenantInfo{
 		id:                    id,
 		weight:                weight,
+		burst:                 burst,
+		priorityBand:          priorityBand,
 		waitingWorkHeap:       ti.waitingWorkHeap,
 		openEpochsHeap:        ti.openEpochsHeap,
 		priorityStates:        makePriorityStates(ti.priorityStates.ps),
@@ -1156,6 +1644,43 @@ func newTenantInfo(id uint64, weight uint32) *tenantInfo {
 	return ti
 }
 
+// usedBeyondBurst returns the usage of the tenant in excess of its burst
+// allowance.
+func (ti *tenantInfo) usedBeyondBurst() uint64 {
+	if ti.used <= ti.burst {
+		return 0
+	}
+	return ti.used - ti.burst
+}
+
+// nextFairnessSeq returns the sequence number to assign to new work with the
+// given fairness key.
+func (ti *tenantInfo) nextFairnessSeq(fairnessKey uint64) uint64 {
+	seq := ti.fairnessVirtualTime
+	if fairnessKey == 0 {
+		return seq
+	}
+	if next := ti.fairnessKeySeqs[fairnessKey]; next > seq {
+		seq = next
+	}
+	if ti.fairnessKeySeqs == nil {
+		ti.fairnessKeySeqs = make(map[uint64]uint64)
+	}
+	ti.fairnessKeySeqs[fairnessKey] = seq + 1
+	return seq
+}
+
+// gcFairnessKeys removes the fairness keys whose next sequence number does not
+// exceed the virtual time, since new work for them would be assigned the
+// virtual time anyway.
+func (ti *tenantInfo) gcFairnessKeys() {
+	for key, next := range ti.fairnessKeySeqs {
+		if next <= ti.fairnessVirtualTime {
+			delete(ti.fairnessKeySeqs, key)
+		}
+	}
+}
+
 func releaseTenantInfo(ti *tenantInfo) {
 	if isInTenantHeap(ti) {
 		panic("tenantInfo has non-empty heap")
@@ -1190,8 +1715,12 @@ func (th *tenantHeap) Len() int {
 }
 
 func (th *tenantHeap) Less(i, j int) bool {
+	if (*th)[i].priorityBand != (*th)[j].priorityBand {
+		return (*th)[i].priorityBand > (*th)[j].priorityBand
+	}
 	// used_i/weight_i < used_j/weight_j
-	return (*th)[i].used*uint64((*th)[j].weight) < (*th)[j].used*uint64((*th)[i].weight)
+	return (*th)[i].usedBeyondBurst()*uint64((*th)[j].weight) <
+		(*th)[j].usedBeyondBurst()*uint64((*th)[i].weight)
 }
 
 func (th *tenantHeap) Swap(i, j int) {
@@ -1226,6 +1755,9 @@ type waitingWork struct {
 	requestedCount          int64
 	// epoch is a function of the createTime.
 	epoch int64
+	// fairnessSeq orders work with the same priority within a tenant, before
+	// createTime. See tenantInfo.fairnessVirtualTime.
+	fairnessSeq uint64
 
 	// ch is used to communicate a grant to the waiting goroutine. The
 	// grantChainID is used by the waiting goroutine to call continueGrantChain.
@@ -1347,9 +1879,9 @@ func releaseWaitingWork(ww *waitingWork) {
 
 // waitingWorkHeap is a heap of waiting work within a tenant. It is ordered in
 // decreasing order of priority, and within the same priority in increasing
-// order of createTime (to prefer older work) for FIFO, and in decreasing
-// order of createTime for LIFO. In the LIFO case the heap only contains
-// epochs that are closed.
+// order of fairnessSeq, and then in increasing order of createTime (to prefer
+// older work) for FIFO, and in decreasing order of createTime for LIFO. In
+// the LIFO case the heap only contains epochs that are closed.
 type waitingWorkHeap []*waitingWork
 
 var _ heap.Interface = (*waitingWorkHeap)(nil)
@@ -1381,6 +1913,9 @@ func (wwh *waitingWorkHeap) Len() int { return len(*wwh) }
 //  w1 < w3, w3 < w2, w2 < w1, which is a cycle.
 func (wwh *waitingWorkHeap) Less(i, j int) bool {
 	if (*wwh)[i].priority == (*wwh)[j].priority {
+		if (*wwh)[i].fairnessSeq != (*wwh)[j].fairnessSeq {
+			return (*wwh)[i].fairnessSeq < (*wwh)[j].fairnessSeq
+		}
 		if (*wwh)[i].arrivalTimeWorkOrdering == lifoWorkOrdering ||
 			(*wwh)[i].arrivalTimeWorkOrdering != (*wwh)[j].arrivalTimeWorkOrdering {
 			// LIFO, and the epoch is closed, so can simply use createTime.
@@ -1495,6 +2030,12 @@ var (
 		Measurement: "Requests",
 		Unit:        metric.Unit_COUNT,
 	}
+	canceledMeta = metric.Metadata{
+		Name:        "admission.canceled.",
+		Help:        "Number of requests not admitted because their context was canceled, or their deadline expired, while waiting",
+		Measurement: "Requests",
+		Unit:        metric.Unit_COUNT,
+	}
 	waitDurationSumMeta = metric.Metadata{
 		Name:        "admission.wait_sum.",
 		Help:        "Total wait time in micros",
@@ -1531,6 +2072,9 @@ type WorkQueueMetrics struct {
 	WaitDurationSum *metric.Counter
 	WaitDurations   *metric.Histogram
 	WaitQueueLength *metric.Gauge
+	// Canceled counts the subset of Errored requests that were not admitted
+	// because their context was done, see IsCanceledWhileWaiting.
+	Canceled *metric.Counter
 }
 
 // MetricStruct implements the metric.Struct interface.
@@ -1545,6 +2089,7 @@ func makeWorkQueueMetrics(name string) WorkQueueMetrics {
 		WaitDurations: metric.NewLatency(
 			addName(name, waitDurationsMeta), base.DefaultHistogramWindowInterval()),
 		WaitQueueLength: metric.NewGauge(addName(name, waitQueueLengthMeta)),
+		Canceled:        metric.NewCounter(addName(name, canceledMeta)),
 	}
 }
 
@@ -1595,6 +2140,17 @@ func (h StoreWorkHandle) AdmissionEnabled() bool {
 	return h.admissionEnabled
 }
 
+// MakeStoreWorkHandleForTesting returns a handle for which admission is
+// enabled, for use by fakes of StoreWorkQueue in other packages.
+func MakeStoreWorkHandleForTesting(tenantID roachpb.TenantID, writeBytes int64) StoreWorkHandle {
+	return StoreWorkHandle{
+		tenantID:         tenantID,
+		writeBytes:       writeBytes,
+		writeTokens:      writeBytes,
+		admissionEnabled: true,
+	}
+}
+
 // Admit is called when requesting admission for store work. If err!=nil, the
 // request was not admitted, potentially due to a deadline being exceeded. If
 // err=nil and handle.AdmissionEnabled() is true, AdmittedWorkDone must be
@@ -1673,11 +2229,81 @@ func (q *StoreWorkQueue) AdmittedWorkDone(h StoreWorkHandle, ingestedIntoL0Bytes
 	return err
 }
 
+// RaftLogAppendedBytes is called when bytes are appended to the raft log of a
+// range of the given tenant. These bytes are written to the store in addition
+// to the bytes written by the admitted requests, so they consume tokens
+// without waiting for admission, and are accounted for when computing the
+// tokens.
+func (q *StoreWorkQueue) RaftLogAppendedBytes(tenantID roachpb.TenantID, bytes int64) {
+	if bytes <= 0 {
+		return
+	}
+	if enabledSetting := admissionControlEnabledSettings[q.q.workKind]; enabledSetting != nil &&
+		!enabledSetting.Get(&q.q.settings.SV) {
+		return
+	}
+	q.mu.Lock()
+	q.mu.stats.raftLogAppendedBytes += uint64(bytes)
+	q.mu.Unlock()
+	q.q.forceAllocateTokens(tenantID, bytes)
+}
+
+// FollowerAppliedBytes is called when a follower replica of a range of the
+// given tenant applies commands that were proposed, and admitted, on another
+// store. Waiting for admission would block the raft processing of the range,
+// so these bytes consume tokens without waiting, and are accounted for like
+// the bytes of an admitted request.
+func (q *StoreWorkQueue) FollowerAppliedBytes(tenantID roachpb.TenantID, bytes int64) {
+	if bytes <= 0 {
+		return
+	}
+	if enabledSetting := admissionControlEnabledSettings[q.q.workKind]; enabledSetting != nil &&
+		!enabledSetting.Get(&q.q.settings.SV) {
+		return
+	}
+	q.mu.Lock()
+	q.mu.stats.admittedCount++
+	q.mu.stats.admittedWithBytesCount++
+	q.mu.stats.admittedAccountedBytes += uint64(bytes)
+	q.mu.Unlock()
+	q.q.forceAllocateTokens(tenantID, bytes)
+}
+
 // SetTenantWeights passes through to WorkQueue.SetTenantWeights.
 func (q *StoreWorkQueue) SetTenantWeights(tenantWeights map[uint64]uint32) {
 	q.q.SetTenantWeights(tenantWeights)
 }
 
+// SetTenantPriorityBands passes through to WorkQueue.SetTenantPriorityBands.
+func (q *StoreWorkQueue) SetTenantPriorityBands(tenantBands map[uint64]uint8) {
+	q.q.SetTenantPriorityBands(tenantBands)
+}
+
+// UpdateTenantWeights passes through to WorkQueue.UpdateTenantWeights.
+func (q *StoreWorkQueue) UpdateTenantWeights(updates map[uint64]uint32, removals []uint64) {
+	q.q.UpdateTenantWeights(updates, removals)
+}
+
+// GetTenantUsage passes through to WorkQueue.GetTenantUsage.
+func (q *StoreWorkQueue) GetTenantUsage() []TenantUsage {
+	return q.q.GetTenantUsage()
+}
+
+// GetWaitingWork passes through to WorkQueue.GetWaitingWork.
+func (q *StoreWorkQueue) GetWaitingWork() []WaitingWork {
+	return q.q.GetWaitingWork()
+}
+
+// NumWaiting passes through to WorkQueue.NumWaiting.
+func (q *StoreWorkQueue) NumWaiting() int {
+	return q.q.NumWaiting()
+}
+
+// SetTenantBursts passes through to WorkQueue.SetTenantBursts.
+func (q *StoreWorkQueue) SetTenantBursts(tenantBursts map[uint64]uint64) {
+	q.q.SetTenantBursts(tenantBursts)
+}
+
 func (q *StoreWorkQueue) hasWaitingRequests() bool {
 	return q.q.hasWaitingRequests()
 }
@@ -1707,6 +2333,7 @@ func makeStoreWorkQueue(
 ) storeRequester {
 	q := &StoreWorkQueue{}
 	initWorkQueue(&q.q, ambientCtx, KVWork, granter, settings, opts)
+	q.q.enforceSystemTenantMinShare = true
 	// Arbitrary initial values. These will be replaced before any meaningful
 	// token constraints are enforced.
 	q.mu.estimates = storeRequestEstimates{
@@ -15,6 +15,8 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 	"unicode"
@@ -29,6 +31,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 	"github.com/cockroachdb/datadriven"
+	"github.com/cockroachdb/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -142,11 +145,16 @@ func (m *workMap) get(id int) (work testWork, ok bool) {
 /*
 TestWorkQueueBasic is a datadriven test with the following commands:
 init
-admit id=<int> tenant=<int> priority=<int> create-time-millis=<int> bypass=<bool>
+admit id=<int> tenant=<int> priority=<int> create-time-millis=<int> bypass=<bool> [fairness-key=<int>] [disable-epoch-lifo=<bool>]
+try-fast-admit id=<int> tenant=<int> priority=<int>
 set-try-get-return-value v=<bool>
 granted chain-id=<int>
 cancel-work id=<int>
 work-done id=<int>
+set-tenant-weights weights=<tenant>:<weight>,...
+set-tenant-bursts bursts=<tenant>:<burst>,...
+update-tenant-weights [weights=<tenant>:<weight>,...] [remove=<tenant>,...]
+set-tenant-priority-bands bands=<tenant>:<band>,...
 advance-time millis=<int>
 print
 */
@@ -197,13 +205,23 @@ func TestWorkQueueBasic(t *testing.T) {
 				d.ScanArgs(t, "create-time-millis", &createTime)
 				var bypass bool
 				d.ScanArgs(t, "bypass", &bypass)
+				var fairnessKey int
+				if d.HasArg("fairness-key") {
+					d.ScanArgs(t, "fairness-key", &fairnessKey)
+				}
+				var disableEpochLIFO bool
+				if d.HasArg("disable-epoch-lifo") {
+					d.ScanArgs(t, "disable-epoch-lifo", &disableEpochLIFO)
+				}
 				ctx, cancel := context.WithCancel(context.Background())
 				wrkMap.set(id, &testWork{tenantID: tenant, cancel: cancel})
 				workInfo := WorkInfo{
-					TenantID:        tenant,
-					Priority:        admissionpb.WorkPriority(priority),
-					CreateTime:      int64(createTime) * int64(time.Millisecond),
-					BypassAdmission: bypass,
+					TenantID:         tenant,
+					Priority:         admissionpb.WorkPriority(priority),
+					CreateTime:       int64(createTime) * int64(time.Millisecond),
+					BypassAdmission:  bypass,
+					FairnessKey:      uint64(fairnessKey),
+					DisableEpochLIFO: disableEpochLIFO,
 				}
 				go func(ctx context.Context, info WorkInfo, id int) {
 					enabled, err := q.Admit(ctx, info)
@@ -221,6 +239,24 @@ func TestWorkQueueBasic(t *testing.T) {
 				time.Sleep(50 * time.Millisecond)
 				return buf.stringAndReset()
 
+			case "try-fast-admit":
+				var id int
+				d.ScanArgs(t, "id", &id)
+				if _, ok := wrkMap.get(id); ok {
+					panic(fmt.Sprintf("id %d is already used", id))
+				}
+				tenant := scanTenantID(t, d)
+				var priority int
+				d.ScanArgs(t, "priority", &priority)
+				if q.TryFastAdmit(WorkInfo{TenantID: tenant, Priority: admissionpb.WorkPriority(priority)}) {
+					wrkMap.set(id, &testWork{tenantID: tenant})
+					wrkMap.setAdmitted(id, StoreWorkHandle{})
+					buf.printf("id %d: fast admit succeeded", id)
+				} else {
+					buf.printf("id %d: fast admit failed", id)
+				}
+				return buf.stringAndReset()
+
 			case "set-try-get-return-value":
 				var v bool
 				d.ScanArgs(t, "v", &v)
@@ -283,9 +319,92 @@ func TestWorkQueueBasic(t *testing.T) {
 				q.SetTenantWeights(weightMap)
 				return q.String()
 
+			case "update-tenant-weights":
+				weightMap := make(map[uint64]uint32)
+				if d.HasArg("weights") {
+					var weights string
+					d.ScanArgs(t, "weights", &weights)
+					fields := strings.FieldsFunc(weights, func(r rune) bool {
+						return r == ':' || r == ',' || unicode.IsSpace(r)
+					})
+					if len(fields)%2 != 0 {
+						return "tenant and weight are not paired"
+					}
+					for i := 0; i < len(fields); i += 2 {
+						tenantID, err := strconv.Atoi(fields[i])
+						require.NoError(t, err)
+						weight, err := strconv.Atoi(fields[i+1])
+						require.NoError(t, err)
+						weightMap[uint64(tenantID)] = uint32(weight)
+					}
+				}
+				var removals []uint64
+				if d.HasArg("remove") {
+					var remove string
+					d.ScanArgs(t, "remove", &remove)
+					for _, field := range strings.Split(remove, ",") {
+						tenantID, err := strconv.Atoi(field)
+						require.NoError(t, err)
+						removals = append(removals, uint64(tenantID))
+					}
+				}
+				q.UpdateTenantWeights(weightMap, removals)
+				return q.String()
+
+			case "set-tenant-priority-bands":
+				var bands string
+				d.ScanArgs(t, "bands", &bands)
+				fields := strings.FieldsFunc(bands, func(r rune) bool {
+					return r == ':' || r == ',' || unicode.IsSpace(r)
+				})
+				if len(fields)%2 != 0 {
+					return "tenant and band are not paired"
+				}
+				bandMap := make(map[uint64]uint8)
+				for i := 0; i < len(fields); i += 2 {
+					tenantID, err := strconv.Atoi(fields[i])
+					require.NoError(t, err)
+					band, err := strconv.Atoi(fields[i+1])
+					require.NoError(t, err)
+					bandMap[uint64(tenantID)] = uint8(band)
+				}
+				q.SetTenantPriorityBands(bandMap)
+				return q.String()
+
+			case "set-tenant-bursts":
+				var bursts string
+				d.ScanArgs(t, "bursts", &bursts)
+				fields := strings.FieldsFunc(bursts, func(r rune) bool {
+					return r == ':' || r == ',' || unicode.IsSpace(r)
+				})
+				if len(fields)%2 != 0 {
+					return "tenant and burst are not paired"
+				}
+				burstMap := make(map[uint64]uint64)
+				for i := 0; i < len(fields); i += 2 {
+					tenantID, err := strconv.Atoi(fields[i])
+					require.NoError(t, err)
+					burst, err := strconv.Atoi(fields[i+1])
+					require.NoError(t, err)
+					burstMap[uint64(tenantID)] = uint64(burst)
+				}
+				q.SetTenantBursts(burstMap)
+				return q.String()
+
 			case "print":
 				return q.String()
 
+			case "waiting-work":
+				var b strings.Builder
+				for _, w := range q.GetWaitingWork() {
+					fmt.Fprintf(&b, "tenant: %d, pri: %d, ct: %d, wait: %s, count: %d\n", w.TenantID,
+						w.Priority, w.CreateTime/int64(time.Millisecond), w.WaitDuration, w.RequestedCount)
+				}
+				return b.String()
+
+			case "num-waiting":
+				return fmt.Sprintf("%d", q.NumWaiting())
+
 			case "advance-time":
 				var millis int
 				d.ScanArgs(t, "millis", &millis)
@@ -386,6 +505,101 @@ func TestWorkQueueTokenResetRace(t *testing.T) {
 	mu.Unlock()
 }
 
+func TestWorkQueueCanceledWhileWaiting(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	var buf builderWithMu
+	// The granter never admits work, so it waits in the queue.
+	tg := &testGranter{buf: &buf}
+	st := cluster.MakeTestingClusterSettings()
+	q := makeWorkQueue(log.MakeTestingAmbientContext(tracing.NewTracer()), KVWork, tg,
+		st, makeWorkQueueOptions(KVWork)).(*WorkQueue)
+	defer q.close()
+	tg.r = q
+	info := WorkInfo{TenantID: roachpb.MakeTenantID(53)}
+
+	// Work whose context is already canceled is not queued.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := q.Admit(ctx, info)
+	require.True(t, IsCanceledWhileWaiting(err))
+	require.True(t, errors.Is(err, context.Canceled))
+	require.Equal(t, int64(1), q.metrics.Canceled.Count())
+
+	// Work whose deadline expires while it is queued.
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err = q.Admit(ctx, info)
+	require.True(t, IsCanceledWhileWaiting(err))
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+	require.Equal(t, int64(2), q.metrics.Canceled.Count())
+	require.Equal(t, int64(2), q.metrics.Errored.Count())
+
+	require.False(t, IsCanceledWhileWaiting(context.Canceled))
+	require.False(t, IsCanceledWhileWaiting(nil))
+}
+
+// racingGranter is a testGranter that calls onTryGet after deciding the
+// return value of tryGet.
+type racingGranter struct {
+	testGranter
+	onTryGet func()
+}
+
+func (g *racingGranter) tryGet(count int64) bool {
+	rv := g.testGranter.tryGet(count)
+	if f := g.onTryGet; f != nil {
+		g.onTryGet = nil
+		f()
+	}
+	return rv
+}
+
+// TestWorkQueueTryFastAdmitRace tests that TryFastAdmit gives back its slot
+// when work is queued by Admit while the slot is being acquired.
+func TestWorkQueueTryFastAdmitRace(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	var buf builderWithMu
+	tg := &racingGranter{testGranter: testGranter{buf: &buf, returnValueFromTryGet: true}}
+	st := cluster.MakeTestingClusterSettings()
+	q := makeWorkQueue(log.MakeTestingAmbientContext(tracing.NewTracer()), KVWork, tg,
+		st, makeWorkQueueOptions(KVWork)).(*WorkQueue)
+	defer q.close()
+	tg.r = q
+	info := WorkInfo{TenantID: roachpb.MakeTenantID(53)}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tg.onTryGet = func() {
+		// Other work fails to acquire a slot, and queues.
+		tg.returnValueFromTryGet = false
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = q.Admit(ctx, info)
+		}()
+		testutils.SucceedsSoon(t, func() error {
+			if atomic.LoadInt32(&q.hasWaitingWork) == 0 {
+				return errors.New("work is not waiting")
+			}
+			return nil
+		})
+	}
+	require.False(t, q.TryFastAdmit(info))
+	require.Equal(t, "tryGet: returning true\ntryGet: returning false\nreturnGrant 1",
+		buf.stringAndReset())
+	require.Zero(t, q.metrics.Admitted.Count())
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	require.Zero(t, q.mu.tenants[53].used)
+	require.Equal(t, 1, len(q.mu.tenantHeap))
+}
+
 func TestPriorityStates(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -445,9 +659,12 @@ init
 admit id=<int> tenant=<int> priority=<int> create-time-millis=<int> bypass=<bool>
   [write-bytes=<int>] [ingest-request=<bool>]
 set-try-get-return-value v=<bool>
+set-system-tenant-min-share share=<float>
 granted
 cancel-work id=<int>
 work-done id=<int> [ingested-into-l0=<int>]
+raft-log-appended tenant=<int> bytes=<int>
+follower-applied tenant=<int> bytes=<int>
 print
 */
 func TestStoreWorkQueueBasic(t *testing.T) {
@@ -553,6 +770,12 @@ func TestStoreWorkQueueBasic(t *testing.T) {
 				q.setStoreRequestEstimates(estimates)
 				return printQueue()
 
+			case "set-system-tenant-min-share":
+				var share float64
+				d.ScanArgs(t, "share", &share)
+				KVStoresSystemTenantMinShare.Override(context.Background(), &st.SV, share)
+				return ""
+
 			case "granted":
 				tg.grant(0)
 				return buf.stringAndReset()
@@ -591,6 +814,20 @@ func TestStoreWorkQueueBasic(t *testing.T) {
 				wrkMap.delete(id)
 				return buf.stringAndReset()
 
+			case "raft-log-appended":
+				tenant := scanTenantID(t, d)
+				var bytes int
+				d.ScanArgs(t, "bytes", &bytes)
+				q.RaftLogAppendedBytes(tenant, int64(bytes))
+				return buf.stringAndReset()
+
+			case "follower-applied":
+				tenant := scanTenantID(t, d)
+				var bytes int
+				d.ScanArgs(t, "bytes", &bytes)
+				q.FollowerAppliedBytes(tenant, int64(bytes))
+				return buf.stringAndReset()
+
 			case "print":
 				return printQueue()
 
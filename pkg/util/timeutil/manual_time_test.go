@@ -165,4 +165,28 @@ func TestManualTime(t *testing.T) {
 		ensureNoSend(t, t1.Ch())
 		ensureNoSend(t, t2.Ch())
 	})
+
+	t.Run("Ticker reset", func(t *testing.T) {
+		mt := timeutil.NewManualTime(t0)
+		advanceTo := func(d time.Duration) {
+			mt.AdvanceTo(t0.Add(d))
+		}
+		t1 := mt.NewTicker(5 * time.Second)
+
+		// The next tick is a full period after the reset.
+		advanceTo(2 * time.Second)
+		t1.Reset(time.Second)
+		advanceTo(2*time.Second + 100*time.Millisecond)
+		ensureNoSend(t, t1.Ch())
+		advanceTo(4 * time.Second)
+		ensureSend(t, t1.Ch(), 3*time.Second)
+		ensureSend(t, t1.Ch(), 4*time.Second)
+
+		t1.Reset(10 * time.Second)
+		advanceTo(13 * time.Second)
+		ensureNoSend(t, t1.Ch())
+		advanceTo(14 * time.Second)
+		ensureSend(t, t1.Ch(), 14*time.Second)
+		t1.Stop()
+	})
 }